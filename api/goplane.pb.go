@@ -0,0 +1,2683 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.33.0
+// 	protoc        (unknown)
+// source: goplane.proto
+
+package goplaneapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type VirtualNetworkState_Status int32
+
+const (
+	VirtualNetworkState_STARTING VirtualNetworkState_Status = 0
+	VirtualNetworkState_RUNNING  VirtualNetworkState_Status = 1
+	// restarting after an error
+	VirtualNetworkState_FAILED  VirtualNetworkState_Status = 2
+	VirtualNetworkState_STOPPED VirtualNetworkState_Status = 3
+)
+
+// Enum value maps for VirtualNetworkState_Status.
+var (
+	VirtualNetworkState_Status_name = map[int32]string{
+		0: "STARTING",
+		1: "RUNNING",
+		2: "FAILED",
+		3: "STOPPED",
+	}
+	VirtualNetworkState_Status_value = map[string]int32{
+		"STARTING": 0,
+		"RUNNING":  1,
+		"FAILED":   2,
+		"STOPPED":  3,
+	}
+)
+
+func (x VirtualNetworkState_Status) Enum() *VirtualNetworkState_Status {
+	p := new(VirtualNetworkState_Status)
+	*p = x
+	return p
+}
+
+func (x VirtualNetworkState_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (VirtualNetworkState_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_goplane_proto_enumTypes[0].Descriptor()
+}
+
+func (VirtualNetworkState_Status) Type() protoreflect.EnumType {
+	return &file_goplane_proto_enumTypes[0]
+}
+
+func (x VirtualNetworkState_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use VirtualNetworkState_Status.Descriptor instead.
+func (VirtualNetworkState_Status) EnumDescriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{11, 0}
+}
+
+type FibDiff_Type int32
+
+const (
+	// in BGP but not in the kernel
+	FibDiff_MISSING FibDiff_Type = 0
+	// in the kernel but not in BGP
+	FibDiff_STALE FibDiff_Type = 1
+	// nexthops differ
+	FibDiff_MISMATCH FibDiff_Type = 2
+)
+
+// Enum value maps for FibDiff_Type.
+var (
+	FibDiff_Type_name = map[int32]string{
+		0: "MISSING",
+		1: "STALE",
+		2: "MISMATCH",
+	}
+	FibDiff_Type_value = map[string]int32{
+		"MISSING":  0,
+		"STALE":    1,
+		"MISMATCH": 2,
+	}
+)
+
+func (x FibDiff_Type) Enum() *FibDiff_Type {
+	p := new(FibDiff_Type)
+	*p = x
+	return p
+}
+
+func (x FibDiff_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FibDiff_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_goplane_proto_enumTypes[1].Descriptor()
+}
+
+func (FibDiff_Type) Type() protoreflect.EnumType {
+	return &file_goplane_proto_enumTypes[1]
+}
+
+func (x FibDiff_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FibDiff_Type.Descriptor instead.
+func (FibDiff_Type) EnumDescriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{14, 0}
+}
+
+type GetReloadStatusResponse_Trigger int32
+
+const (
+	GetReloadStatusResponse_STARTUP GetReloadStatusResponse_Trigger = 0
+	// SIGHUP or ReloadConfig
+	GetReloadStatusResponse_REQUEST GetReloadStatusResponse_Trigger = 1
+	// change of the config file
+	GetReloadStatusResponse_FILE GetReloadStatusResponse_Trigger = 2
+)
+
+// Enum value maps for GetReloadStatusResponse_Trigger.
+var (
+	GetReloadStatusResponse_Trigger_name = map[int32]string{
+		0: "STARTUP",
+		1: "REQUEST",
+		2: "FILE",
+	}
+	GetReloadStatusResponse_Trigger_value = map[string]int32{
+		"STARTUP": 0,
+		"REQUEST": 1,
+		"FILE":    2,
+	}
+)
+
+func (x GetReloadStatusResponse_Trigger) Enum() *GetReloadStatusResponse_Trigger {
+	p := new(GetReloadStatusResponse_Trigger)
+	*p = x
+	return p
+}
+
+func (x GetReloadStatusResponse_Trigger) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GetReloadStatusResponse_Trigger) Descriptor() protoreflect.EnumDescriptor {
+	return file_goplane_proto_enumTypes[2].Descriptor()
+}
+
+func (GetReloadStatusResponse_Trigger) Type() protoreflect.EnumType {
+	return &file_goplane_proto_enumTypes[2]
+}
+
+func (x GetReloadStatusResponse_Trigger) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GetReloadStatusResponse_Trigger.Descriptor instead.
+func (GetReloadStatusResponse_Trigger) EnumDescriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{17, 0}
+}
+
+type GetReloadStatusResponse_Result int32
+
+const (
+	GetReloadStatusResponse_APPLIED GetReloadStatusResponse_Result = 0
+	// the running config was kept
+	GetReloadStatusResponse_REJECTED GetReloadStatusResponse_Result = 1
+	// the file changed but the config didn't
+	GetReloadStatusResponse_UNCHANGED GetReloadStatusResponse_Result = 2
+)
+
+// Enum value maps for GetReloadStatusResponse_Result.
+var (
+	GetReloadStatusResponse_Result_name = map[int32]string{
+		0: "APPLIED",
+		1: "REJECTED",
+		2: "UNCHANGED",
+	}
+	GetReloadStatusResponse_Result_value = map[string]int32{
+		"APPLIED":   0,
+		"REJECTED":  1,
+		"UNCHANGED": 2,
+	}
+)
+
+func (x GetReloadStatusResponse_Result) Enum() *GetReloadStatusResponse_Result {
+	p := new(GetReloadStatusResponse_Result)
+	*p = x
+	return p
+}
+
+func (x GetReloadStatusResponse_Result) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GetReloadStatusResponse_Result) Descriptor() protoreflect.EnumDescriptor {
+	return file_goplane_proto_enumTypes[3].Descriptor()
+}
+
+func (GetReloadStatusResponse_Result) Type() protoreflect.EnumType {
+	return &file_goplane_proto_enumTypes[3]
+}
+
+func (x GetReloadStatusResponse_Result) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GetReloadStatusResponse_Result.Descriptor instead.
+func (GetReloadStatusResponse_Result) EnumDescriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{17, 1}
+}
+
+type AddVirtualNetworkRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	VirtualNetwork *VirtualNetwork `protobuf:"bytes,1,opt,name=virtual_network,json=virtualNetwork,proto3" json:"virtual_network,omitempty"`
+}
+
+func (x *AddVirtualNetworkRequest) Reset() {
+	*x = AddVirtualNetworkRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AddVirtualNetworkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddVirtualNetworkRequest) ProtoMessage() {}
+
+func (x *AddVirtualNetworkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddVirtualNetworkRequest.ProtoReflect.Descriptor instead.
+func (*AddVirtualNetworkRequest) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AddVirtualNetworkRequest) GetVirtualNetwork() *VirtualNetwork {
+	if x != nil {
+		return x.VirtualNetwork
+	}
+	return nil
+}
+
+type DeleteVirtualNetworkRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// RD of the virtual network, or "evi-<index>" when the RD is
+	// auto-derived.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (x *DeleteVirtualNetworkRequest) Reset() {
+	*x = DeleteVirtualNetworkRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteVirtualNetworkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteVirtualNetworkRequest) ProtoMessage() {}
+
+func (x *DeleteVirtualNetworkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteVirtualNetworkRequest.ProtoReflect.Descriptor instead.
+func (*DeleteVirtualNetworkRequest) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DeleteVirtualNetworkRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type UpdateVirtualNetworkRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	VirtualNetwork *VirtualNetwork `protobuf:"bytes,1,opt,name=virtual_network,json=virtualNetwork,proto3" json:"virtual_network,omitempty"`
+}
+
+func (x *UpdateVirtualNetworkRequest) Reset() {
+	*x = UpdateVirtualNetworkRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdateVirtualNetworkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateVirtualNetworkRequest) ProtoMessage() {}
+
+func (x *UpdateVirtualNetworkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateVirtualNetworkRequest.ProtoReflect.Descriptor instead.
+func (*UpdateVirtualNetworkRequest) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UpdateVirtualNetworkRequest) GetVirtualNetwork() *VirtualNetwork {
+	if x != nil {
+		return x.VirtualNetwork
+	}
+	return nil
+}
+
+type ListVirtualNetworkRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListVirtualNetworkRequest) Reset() {
+	*x = ListVirtualNetworkRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListVirtualNetworkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVirtualNetworkRequest) ProtoMessage() {}
+
+func (x *ListVirtualNetworkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVirtualNetworkRequest.ProtoReflect.Descriptor instead.
+func (*ListVirtualNetworkRequest) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{3}
+}
+
+type ListVirtualNetworkResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	VirtualNetwork *VirtualNetwork `protobuf:"bytes,1,opt,name=virtual_network,json=virtualNetwork,proto3" json:"virtual_network,omitempty"`
+}
+
+func (x *ListVirtualNetworkResponse) Reset() {
+	*x = ListVirtualNetworkResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListVirtualNetworkResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVirtualNetworkResponse) ProtoMessage() {}
+
+func (x *ListVirtualNetworkResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVirtualNetworkResponse.ProtoReflect.Descriptor instead.
+func (*ListVirtualNetworkResponse) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListVirtualNetworkResponse) GetVirtualNetwork() *VirtualNetwork {
+	if x != nil {
+		return x.VirtualNetwork
+	}
+	return nil
+}
+
+type GetVirtualNetworkRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (x *GetVirtualNetworkRequest) Reset() {
+	*x = GetVirtualNetworkRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetVirtualNetworkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetVirtualNetworkRequest) ProtoMessage() {}
+
+func (x *GetVirtualNetworkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetVirtualNetworkRequest.ProtoReflect.Descriptor instead.
+func (*GetVirtualNetworkRequest) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetVirtualNetworkRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type GetVirtualNetworkResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	VirtualNetwork *VirtualNetwork      `protobuf:"bytes,1,opt,name=virtual_network,json=virtualNetwork,proto3" json:"virtual_network,omitempty"`
+	State          *VirtualNetworkState `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+}
+
+func (x *GetVirtualNetworkResponse) Reset() {
+	*x = GetVirtualNetworkResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetVirtualNetworkResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetVirtualNetworkResponse) ProtoMessage() {}
+
+func (x *GetVirtualNetworkResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetVirtualNetworkResponse.ProtoReflect.Descriptor instead.
+func (*GetVirtualNetworkResponse) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetVirtualNetworkResponse) GetVirtualNetwork() *VirtualNetwork {
+	if x != nil {
+		return x.VirtualNetwork
+	}
+	return nil
+}
+
+func (x *GetVirtualNetworkResponse) GetState() *VirtualNetworkState {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+type VirtualNetwork struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rd               string   `protobuf:"bytes,1,opt,name=rd,proto3" json:"rd,omitempty"`
+	Evi              uint32   `protobuf:"varint,2,opt,name=evi,proto3" json:"evi,omitempty"`
+	Vni              uint32   `protobuf:"varint,3,opt,name=vni,proto3" json:"vni,omitempty"`
+	VxlanPort        uint32   `protobuf:"varint,4,opt,name=vxlan_port,json=vxlanPort,proto3" json:"vxlan_port,omitempty"`
+	VtepInterface    string   `protobuf:"bytes,5,opt,name=vtep_interface,json=vtepInterface,proto3" json:"vtep_interface,omitempty"`
+	Etag             uint32   `protobuf:"varint,6,opt,name=etag,proto3" json:"etag,omitempty"`
+	SniffInterfaces  []string `protobuf:"bytes,7,rep,name=sniff_interfaces,json=sniffInterfaces,proto3" json:"sniff_interfaces,omitempty"`
+	MemberInterfaces []string `protobuf:"bytes,8,rep,name=member_interfaces,json=memberInterfaces,proto3" json:"member_interfaces,omitempty"`
+	ImportRt         []string `protobuf:"bytes,9,rep,name=import_rt,json=importRt,proto3" json:"import_rt,omitempty"`
+	ExportRt         []string `protobuf:"bytes,10,rep,name=export_rt,json=exportRt,proto3" json:"export_rt,omitempty"`
+	// "kernel" or "userspace"
+	VtepMode string `protobuf:"bytes,11,opt,name=vtep_mode,json=vtepMode,proto3" json:"vtep_mode,omitempty"`
+	// "vxlan" or "geneve"
+	Encap              string   `protobuf:"bytes,12,opt,name=encap,proto3" json:"encap,omitempty"`
+	MulticastGroup     string   `protobuf:"bytes,13,opt,name=multicast_group,json=multicastGroup,proto3" json:"multicast_group,omitempty"`
+	MulticastInterface string   `protobuf:"bytes,14,opt,name=multicast_interface,json=multicastInterface,proto3" json:"multicast_interface,omitempty"`
+	GatewayAddresses   []string `protobuf:"bytes,15,rep,name=gateway_addresses,json=gatewayAddresses,proto3" json:"gateway_addresses,omitempty"`
+	GatewayMac         string   `protobuf:"bytes,16,opt,name=gateway_mac,json=gatewayMac,proto3" json:"gateway_mac,omitempty"`
+}
+
+func (x *VirtualNetwork) Reset() {
+	*x = VirtualNetwork{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VirtualNetwork) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VirtualNetwork) ProtoMessage() {}
+
+func (x *VirtualNetwork) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VirtualNetwork.ProtoReflect.Descriptor instead.
+func (*VirtualNetwork) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *VirtualNetwork) GetRd() string {
+	if x != nil {
+		return x.Rd
+	}
+	return ""
+}
+
+func (x *VirtualNetwork) GetEvi() uint32 {
+	if x != nil {
+		return x.Evi
+	}
+	return 0
+}
+
+func (x *VirtualNetwork) GetVni() uint32 {
+	if x != nil {
+		return x.Vni
+	}
+	return 0
+}
+
+func (x *VirtualNetwork) GetVxlanPort() uint32 {
+	if x != nil {
+		return x.VxlanPort
+	}
+	return 0
+}
+
+func (x *VirtualNetwork) GetVtepInterface() string {
+	if x != nil {
+		return x.VtepInterface
+	}
+	return ""
+}
+
+func (x *VirtualNetwork) GetEtag() uint32 {
+	if x != nil {
+		return x.Etag
+	}
+	return 0
+}
+
+func (x *VirtualNetwork) GetSniffInterfaces() []string {
+	if x != nil {
+		return x.SniffInterfaces
+	}
+	return nil
+}
+
+func (x *VirtualNetwork) GetMemberInterfaces() []string {
+	if x != nil {
+		return x.MemberInterfaces
+	}
+	return nil
+}
+
+func (x *VirtualNetwork) GetImportRt() []string {
+	if x != nil {
+		return x.ImportRt
+	}
+	return nil
+}
+
+func (x *VirtualNetwork) GetExportRt() []string {
+	if x != nil {
+		return x.ExportRt
+	}
+	return nil
+}
+
+func (x *VirtualNetwork) GetVtepMode() string {
+	if x != nil {
+		return x.VtepMode
+	}
+	return ""
+}
+
+func (x *VirtualNetwork) GetEncap() string {
+	if x != nil {
+		return x.Encap
+	}
+	return ""
+}
+
+func (x *VirtualNetwork) GetMulticastGroup() string {
+	if x != nil {
+		return x.MulticastGroup
+	}
+	return ""
+}
+
+func (x *VirtualNetwork) GetMulticastInterface() string {
+	if x != nil {
+		return x.MulticastInterface
+	}
+	return ""
+}
+
+func (x *VirtualNetwork) GetGatewayAddresses() []string {
+	if x != nil {
+		return x.GatewayAddresses
+	}
+	return nil
+}
+
+func (x *VirtualNetwork) GetGatewayMac() string {
+	if x != nil {
+		return x.GatewayMac
+	}
+	return ""
+}
+
+type MacEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Mac string `protobuf:"bytes,1,opt,name=mac,proto3" json:"mac,omitempty"`
+	Ip  string `protobuf:"bytes,2,opt,name=ip,proto3" json:"ip,omitempty"`
+}
+
+func (x *MacEntry) Reset() {
+	*x = MacEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MacEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MacEntry) ProtoMessage() {}
+
+func (x *MacEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MacEntry.ProtoReflect.Descriptor instead.
+func (*MacEntry) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *MacEntry) GetMac() string {
+	if x != nil {
+		return x.Mac
+	}
+	return ""
+}
+
+func (x *MacEntry) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+type FdbEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Mac  string `protobuf:"bytes,1,opt,name=mac,proto3" json:"mac,omitempty"`
+	Vtep string `protobuf:"bytes,2,opt,name=vtep,proto3" json:"vtep,omitempty"`
+}
+
+func (x *FdbEntry) Reset() {
+	*x = FdbEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FdbEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FdbEntry) ProtoMessage() {}
+
+func (x *FdbEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FdbEntry.ProtoReflect.Descriptor instead.
+func (*FdbEntry) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *FdbEntry) GetMac() string {
+	if x != nil {
+		return x.Mac
+	}
+	return ""
+}
+
+func (x *FdbEntry) GetVtep() string {
+	if x != nil {
+		return x.Vtep
+	}
+	return ""
+}
+
+// MAC frozen by the duplicate MAC detection
+type DuplicateMac struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Mac string `protobuf:"bytes,1,opt,name=mac,proto3" json:"mac,omitempty"`
+	// VTEP the MAC was last seen at. It is empty for the local host.
+	Vtep   string                 `protobuf:"bytes,2,opt,name=vtep,proto3" json:"vtep,omitempty"`
+	Moves  uint32                 `protobuf:"varint,3,opt,name=moves,proto3" json:"moves,omitempty"`
+	Frozen *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=frozen,proto3" json:"frozen,omitempty"`
+}
+
+func (x *DuplicateMac) Reset() {
+	*x = DuplicateMac{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DuplicateMac) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DuplicateMac) ProtoMessage() {}
+
+func (x *DuplicateMac) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DuplicateMac.ProtoReflect.Descriptor instead.
+func (*DuplicateMac) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DuplicateMac) GetMac() string {
+	if x != nil {
+		return x.Mac
+	}
+	return ""
+}
+
+func (x *DuplicateMac) GetVtep() string {
+	if x != nil {
+		return x.Vtep
+	}
+	return ""
+}
+
+func (x *DuplicateMac) GetMoves() uint32 {
+	if x != nil {
+		return x.Moves
+	}
+	return 0
+}
+
+func (x *DuplicateMac) GetFrozen() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Frozen
+	}
+	return nil
+}
+
+type VirtualNetworkState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name          string                     `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Rd            string                     `protobuf:"bytes,2,opt,name=rd,proto3" json:"rd,omitempty"`
+	Bridge        string                     `protobuf:"bytes,3,opt,name=bridge,proto3" json:"bridge,omitempty"`
+	Vtep          string                     `protobuf:"bytes,4,opt,name=vtep,proto3" json:"vtep,omitempty"`
+	RemoteVteps   []string                   `protobuf:"bytes,5,rep,name=remote_vteps,json=remoteVteps,proto3" json:"remote_vteps,omitempty"`
+	LocalMacs     []*MacEntry                `protobuf:"bytes,6,rep,name=local_macs,json=localMacs,proto3" json:"local_macs,omitempty"`
+	RemoteFdb     []*FdbEntry                `protobuf:"bytes,7,rep,name=remote_fdb,json=remoteFdb,proto3" json:"remote_fdb,omitempty"`
+	Status        VirtualNetworkState_Status `protobuf:"varint,8,opt,name=status,proto3,enum=goplaneapi.VirtualNetworkState_Status" json:"status,omitempty"`
+	LastError     string                     `protobuf:"bytes,9,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	Restarts      uint32                     `protobuf:"varint,10,opt,name=restarts,proto3" json:"restarts,omitempty"`
+	DuplicateMacs []*DuplicateMac            `protobuf:"bytes,11,rep,name=duplicate_macs,json=duplicateMacs,proto3" json:"duplicate_macs,omitempty"`
+}
+
+func (x *VirtualNetworkState) Reset() {
+	*x = VirtualNetworkState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VirtualNetworkState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VirtualNetworkState) ProtoMessage() {}
+
+func (x *VirtualNetworkState) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VirtualNetworkState.ProtoReflect.Descriptor instead.
+func (*VirtualNetworkState) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *VirtualNetworkState) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *VirtualNetworkState) GetRd() string {
+	if x != nil {
+		return x.Rd
+	}
+	return ""
+}
+
+func (x *VirtualNetworkState) GetBridge() string {
+	if x != nil {
+		return x.Bridge
+	}
+	return ""
+}
+
+func (x *VirtualNetworkState) GetVtep() string {
+	if x != nil {
+		return x.Vtep
+	}
+	return ""
+}
+
+func (x *VirtualNetworkState) GetRemoteVteps() []string {
+	if x != nil {
+		return x.RemoteVteps
+	}
+	return nil
+}
+
+func (x *VirtualNetworkState) GetLocalMacs() []*MacEntry {
+	if x != nil {
+		return x.LocalMacs
+	}
+	return nil
+}
+
+func (x *VirtualNetworkState) GetRemoteFdb() []*FdbEntry {
+	if x != nil {
+		return x.RemoteFdb
+	}
+	return nil
+}
+
+func (x *VirtualNetworkState) GetStatus() VirtualNetworkState_Status {
+	if x != nil {
+		return x.Status
+	}
+	return VirtualNetworkState_STARTING
+}
+
+func (x *VirtualNetworkState) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *VirtualNetworkState) GetRestarts() uint32 {
+	if x != nil {
+		return x.Restarts
+	}
+	return 0
+}
+
+func (x *VirtualNetworkState) GetDuplicateMacs() []*DuplicateMac {
+	if x != nil {
+		return x.DuplicateMacs
+	}
+	return nil
+}
+
+type GetFibDiffRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetFibDiffRequest) Reset() {
+	*x = GetFibDiffRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetFibDiffRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFibDiffRequest) ProtoMessage() {}
+
+func (x *GetFibDiffRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFibDiffRequest.ProtoReflect.Descriptor instead.
+func (*GetFibDiffRequest) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{12}
+}
+
+type GetFibDiffResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Diffs []*FibDiff `protobuf:"bytes,1,rep,name=diffs,proto3" json:"diffs,omitempty"`
+}
+
+func (x *GetFibDiffResponse) Reset() {
+	*x = GetFibDiffResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetFibDiffResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFibDiffResponse) ProtoMessage() {}
+
+func (x *GetFibDiffResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFibDiffResponse.ProtoReflect.Descriptor instead.
+func (*GetFibDiffResponse) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetFibDiffResponse) GetDiffs() []*FibDiff {
+	if x != nil {
+		return x.Diffs
+	}
+	return nil
+}
+
+// Difference between the BGP best paths and the routes goplane
+// installed in the kernel.
+type FibDiff struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type           FibDiff_Type `protobuf:"varint,1,opt,name=type,proto3,enum=goplaneapi.FibDiff_Type" json:"type,omitempty"`
+	Prefix         string       `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	BgpNexthops    []string     `protobuf:"bytes,3,rep,name=bgp_nexthops,json=bgpNexthops,proto3" json:"bgp_nexthops,omitempty"`
+	KernelNexthops []string     `protobuf:"bytes,4,rep,name=kernel_nexthops,json=kernelNexthops,proto3" json:"kernel_nexthops,omitempty"`
+}
+
+func (x *FibDiff) Reset() {
+	*x = FibDiff{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FibDiff) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FibDiff) ProtoMessage() {}
+
+func (x *FibDiff) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FibDiff.ProtoReflect.Descriptor instead.
+func (*FibDiff) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *FibDiff) GetType() FibDiff_Type {
+	if x != nil {
+		return x.Type
+	}
+	return FibDiff_MISSING
+}
+
+func (x *FibDiff) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *FibDiff) GetBgpNexthops() []string {
+	if x != nil {
+		return x.BgpNexthops
+	}
+	return nil
+}
+
+func (x *FibDiff) GetKernelNexthops() []string {
+	if x != nil {
+		return x.KernelNexthops
+	}
+	return nil
+}
+
+type ReloadConfigRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ReloadConfigRequest) Reset() {
+	*x = ReloadConfigRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReloadConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadConfigRequest) ProtoMessage() {}
+
+func (x *ReloadConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadConfigRequest.ProtoReflect.Descriptor instead.
+func (*ReloadConfigRequest) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{15}
+}
+
+type GetReloadStatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetReloadStatusRequest) Reset() {
+	*x = GetReloadStatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetReloadStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReloadStatusRequest) ProtoMessage() {}
+
+func (x *GetReloadStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReloadStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetReloadStatusRequest) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{16}
+}
+
+// Outcome of the last read of the config file.
+type GetReloadStatusResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Time    *timestamppb.Timestamp          `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Trigger GetReloadStatusResponse_Trigger `protobuf:"varint,2,opt,name=trigger,proto3,enum=goplaneapi.GetReloadStatusResponse_Trigger" json:"trigger,omitempty"`
+	Result  GetReloadStatusResponse_Result  `protobuf:"varint,3,opt,name=result,proto3,enum=goplaneapi.GetReloadStatusResponse_Result" json:"result,omitempty"`
+	Error   string                          `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	Changes []string                        `protobuf:"bytes,5,rep,name=changes,proto3" json:"changes,omitempty"`
+}
+
+func (x *GetReloadStatusResponse) Reset() {
+	*x = GetReloadStatusResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetReloadStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReloadStatusResponse) ProtoMessage() {}
+
+func (x *GetReloadStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReloadStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetReloadStatusResponse) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetReloadStatusResponse) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *GetReloadStatusResponse) GetTrigger() GetReloadStatusResponse_Trigger {
+	if x != nil {
+		return x.Trigger
+	}
+	return GetReloadStatusResponse_STARTUP
+}
+
+func (x *GetReloadStatusResponse) GetResult() GetReloadStatusResponse_Result {
+	if x != nil {
+		return x.Result
+	}
+	return GetReloadStatusResponse_APPLIED
+}
+
+func (x *GetReloadStatusResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *GetReloadStatusResponse) GetChanges() []string {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+type GetLogLevelRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetLogLevelRequest) Reset() {
+	*x = GetLogLevelRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetLogLevelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLogLevelRequest) ProtoMessage() {}
+
+func (x *GetLogLevelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLogLevelRequest.ProtoReflect.Descriptor instead.
+func (*GetLogLevelRequest) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{18}
+}
+
+type GetLogLevelResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// default level
+	Level string `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	// levels of the topics overriding the default level
+	Topics map[string]string `protobuf:"bytes,2,rep,name=topics,proto3" json:"topics,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (x *GetLogLevelResponse) Reset() {
+	*x = GetLogLevelResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetLogLevelResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLogLevelResponse) ProtoMessage() {}
+
+func (x *GetLogLevelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLogLevelResponse.ProtoReflect.Descriptor instead.
+func (*GetLogLevelResponse) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetLogLevelResponse) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *GetLogLevelResponse) GetTopics() map[string]string {
+	if x != nil {
+		return x.Topics
+	}
+	return nil
+}
+
+type SetLogLevelRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Level string `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	// the default level is changed when empty
+	Topic string `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`
+}
+
+func (x *SetLogLevelRequest) Reset() {
+	*x = SetLogLevelRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SetLogLevelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetLogLevelRequest) ProtoMessage() {}
+
+func (x *SetLogLevelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetLogLevelRequest.ProtoReflect.Descriptor instead.
+func (*SetLogLevelRequest) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *SetLogLevelRequest) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *SetLogLevelRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+type ClearDuplicateMacRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// name of the virtual network
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// all the frozen MACs of the virtual network are cleared when empty
+	Mac string `protobuf:"bytes,2,opt,name=mac,proto3" json:"mac,omitempty"`
+}
+
+func (x *ClearDuplicateMacRequest) Reset() {
+	*x = ClearDuplicateMacRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_goplane_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClearDuplicateMacRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearDuplicateMacRequest) ProtoMessage() {}
+
+func (x *ClearDuplicateMacRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_goplane_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearDuplicateMacRequest.ProtoReflect.Descriptor instead.
+func (*ClearDuplicateMacRequest) Descriptor() ([]byte, []int) {
+	return file_goplane_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ClearDuplicateMacRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ClearDuplicateMacRequest) GetMac() string {
+	if x != nil {
+		return x.Mac
+	}
+	return ""
+}
+
+var File_goplane_proto protoreflect.FileDescriptor
+
+var file_goplane_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x0a, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69, 0x1a, 0x1b, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70,
+	0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x5f, 0x0a, 0x18, 0x41, 0x64, 0x64,
+	0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x43, 0x0a, 0x0f, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c,
+	0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x56, 0x69, 0x72, 0x74,
+	0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x52, 0x0e, 0x76, 0x69, 0x72, 0x74,
+	0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x22, 0x31, 0x0a, 0x1b, 0x44, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f,
+	0x72, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x62, 0x0a,
+	0x1b, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65,
+	0x74, 0x77, 0x6f, 0x72, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x43, 0x0a, 0x0f,
+	0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61,
+	0x70, 0x69, 0x2e, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72,
+	0x6b, 0x52, 0x0e, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72,
+	0x6b, 0x22, 0x1b, 0x0a, 0x19, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c,
+	0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x61,
+	0x0a, 0x1a, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74,
+	0x77, 0x6f, 0x72, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x43, 0x0a, 0x0f,
+	0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61,
+	0x70, 0x69, 0x2e, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72,
+	0x6b, 0x52, 0x0e, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72,
+	0x6b, 0x22, 0x2e, 0x0a, 0x18, 0x47, 0x65, 0x74, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e,
+	0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x22, 0x97, 0x01, 0x0a, 0x19, 0x47, 0x65, 0x74, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c,
+	0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x43, 0x0a, 0x0f, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f,
+	0x72, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61,
+	0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74,
+	0x77, 0x6f, 0x72, 0x6b, 0x52, 0x0e, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74,
+	0x77, 0x6f, 0x72, 0x6b, 0x12, 0x35, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69,
+	0x2e, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x53,
+	0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x22, 0x8b, 0x04, 0x0a, 0x0e,
+	0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x12, 0x0e,
+	0x0a, 0x02, 0x72, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x72, 0x64, 0x12, 0x10,
+	0x0a, 0x03, 0x65, 0x76, 0x69, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x65, 0x76, 0x69,
+	0x12, 0x10, 0x0a, 0x03, 0x76, 0x6e, 0x69, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x76,
+	0x6e, 0x69, 0x12, 0x1d, 0x0a, 0x0a, 0x76, 0x78, 0x6c, 0x61, 0x6e, 0x5f, 0x70, 0x6f, 0x72, 0x74,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x76, 0x78, 0x6c, 0x61, 0x6e, 0x50, 0x6f, 0x72,
+	0x74, 0x12, 0x25, 0x0a, 0x0e, 0x76, 0x74, 0x65, 0x70, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66,
+	0x61, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x76, 0x74, 0x65, 0x70, 0x49,
+	0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x65, 0x74, 0x61, 0x67,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x65, 0x74, 0x61, 0x67, 0x12, 0x29, 0x0a, 0x10,
+	0x73, 0x6e, 0x69, 0x66, 0x66, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x73,
+	0x18, 0x07, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x73, 0x6e, 0x69, 0x66, 0x66, 0x49, 0x6e, 0x74,
+	0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x73, 0x12, 0x2b, 0x0a, 0x11, 0x6d, 0x65, 0x6d, 0x62, 0x65,
+	0x72, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x73, 0x18, 0x08, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x10, 0x6d, 0x65, 0x6d, 0x62, 0x65, 0x72, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66,
+	0x61, 0x63, 0x65, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x69, 0x6d, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x72,
+	0x74, 0x18, 0x09, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x69, 0x6d, 0x70, 0x6f, 0x72, 0x74, 0x52,
+	0x74, 0x12, 0x1b, 0x0a, 0x09, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x72, 0x74, 0x18, 0x0a,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x74, 0x12, 0x1b,
+	0x0a, 0x09, 0x76, 0x74, 0x65, 0x70, 0x5f, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x76, 0x74, 0x65, 0x70, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65,
+	0x6e, 0x63, 0x61, 0x70, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x6e, 0x63, 0x61,
+	0x70, 0x12, 0x27, 0x0a, 0x0f, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x63, 0x61, 0x73, 0x74, 0x5f, 0x67,
+	0x72, 0x6f, 0x75, 0x70, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x6d, 0x75, 0x6c, 0x74,
+	0x69, 0x63, 0x61, 0x73, 0x74, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x2f, 0x0a, 0x13, 0x6d, 0x75,
+	0x6c, 0x74, 0x69, 0x63, 0x61, 0x73, 0x74, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63,
+	0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x63, 0x61,
+	0x73, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x12, 0x2b, 0x0a, 0x11, 0x67,
+	0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73,
+	0x18, 0x0f, 0x20, 0x03, 0x28, 0x09, 0x52, 0x10, 0x67, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x41,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x67, 0x61, 0x74, 0x65,
+	0x77, 0x61, 0x79, 0x5f, 0x6d, 0x61, 0x63, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x67,
+	0x61, 0x74, 0x65, 0x77, 0x61, 0x79, 0x4d, 0x61, 0x63, 0x22, 0x2c, 0x0a, 0x08, 0x4d, 0x61, 0x63,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x61, 0x63, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6d, 0x61, 0x63, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x70, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x70, 0x22, 0x30, 0x0a, 0x08, 0x46, 0x64, 0x62, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x61, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6d, 0x61, 0x63, 0x12, 0x12, 0x0a, 0x04, 0x76, 0x74, 0x65, 0x70, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x76, 0x74, 0x65, 0x70, 0x22, 0x7e, 0x0a, 0x0c, 0x44, 0x75, 0x70,
+	0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x4d, 0x61, 0x63, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x61, 0x63,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6d, 0x61, 0x63, 0x12, 0x12, 0x0a, 0x04, 0x76,
+	0x74, 0x65, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x76, 0x74, 0x65, 0x70, 0x12,
+	0x14, 0x0a, 0x05, 0x6d, 0x6f, 0x76, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05,
+	0x6d, 0x6f, 0x76, 0x65, 0x73, 0x12, 0x32, 0x0a, 0x06, 0x66, 0x72, 0x6f, 0x7a, 0x65, 0x6e, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x06, 0x66, 0x72, 0x6f, 0x7a, 0x65, 0x6e, 0x22, 0xec, 0x03, 0x0a, 0x13, 0x56, 0x69,
+	0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x53, 0x74, 0x61, 0x74,
+	0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x02, 0x72, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x62, 0x72, 0x69, 0x64, 0x67, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x76, 0x74, 0x65, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x76, 0x74, 0x65,
+	0x70, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x5f, 0x76, 0x74, 0x65, 0x70,
+	0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x56,
+	0x74, 0x65, 0x70, 0x73, 0x12, 0x33, 0x0a, 0x0a, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x6d, 0x61,
+	0x63, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61,
+	0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x4d, 0x61, 0x63, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x09,
+	0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x4d, 0x61, 0x63, 0x73, 0x12, 0x33, 0x0a, 0x0a, 0x72, 0x65, 0x6d,
+	0x6f, 0x74, 0x65, 0x5f, 0x66, 0x64, 0x62, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e,
+	0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x46, 0x64, 0x62, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x09, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x46, 0x64, 0x62, 0x12, 0x3e,
+	0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x26,
+	0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x56, 0x69, 0x72, 0x74,
+	0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x65, 0x2e,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1d,
+	0x0a, 0x0a, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x09, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x6c, 0x61, 0x73, 0x74, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x1a, 0x0a,
+	0x08, 0x72, 0x65, 0x73, 0x74, 0x61, 0x72, 0x74, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x08, 0x72, 0x65, 0x73, 0x74, 0x61, 0x72, 0x74, 0x73, 0x12, 0x3f, 0x0a, 0x0e, 0x64, 0x75, 0x70,
+	0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x5f, 0x6d, 0x61, 0x63, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x18, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x44,
+	0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x4d, 0x61, 0x63, 0x52, 0x0d, 0x64, 0x75, 0x70,
+	0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x4d, 0x61, 0x63, 0x73, 0x22, 0x3c, 0x0a, 0x06, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x12, 0x0c, 0x0a, 0x08, 0x53, 0x54, 0x41, 0x52, 0x54, 0x49, 0x4e, 0x47,
+	0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x52, 0x55, 0x4e, 0x4e, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12,
+	0x0a, 0x0a, 0x06, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x53,
+	0x54, 0x4f, 0x50, 0x50, 0x45, 0x44, 0x10, 0x03, 0x22, 0x13, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x46,
+	0x69, 0x62, 0x44, 0x69, 0x66, 0x66, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x3f, 0x0a,
+	0x12, 0x47, 0x65, 0x74, 0x46, 0x69, 0x62, 0x44, 0x69, 0x66, 0x66, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x05, 0x64, 0x69, 0x66, 0x66, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x13, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e,
+	0x46, 0x69, 0x62, 0x44, 0x69, 0x66, 0x66, 0x52, 0x05, 0x64, 0x69, 0x66, 0x66, 0x73, 0x22, 0xc9,
+	0x01, 0x0a, 0x07, 0x46, 0x69, 0x62, 0x44, 0x69, 0x66, 0x66, 0x12, 0x2c, 0x0a, 0x04, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x18, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61,
+	0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x46, 0x69, 0x62, 0x44, 0x69, 0x66, 0x66, 0x2e, 0x54, 0x79,
+	0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72, 0x65, 0x66,
+	0x69, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78,
+	0x12, 0x21, 0x0a, 0x0c, 0x62, 0x67, 0x70, 0x5f, 0x6e, 0x65, 0x78, 0x74, 0x68, 0x6f, 0x70, 0x73,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x62, 0x67, 0x70, 0x4e, 0x65, 0x78, 0x74, 0x68,
+	0x6f, 0x70, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x6b, 0x65, 0x72, 0x6e, 0x65, 0x6c, 0x5f, 0x6e, 0x65,
+	0x78, 0x74, 0x68, 0x6f, 0x70, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x6b, 0x65,
+	0x72, 0x6e, 0x65, 0x6c, 0x4e, 0x65, 0x78, 0x74, 0x68, 0x6f, 0x70, 0x73, 0x22, 0x2c, 0x0a, 0x04,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x4d, 0x49, 0x53, 0x53, 0x49, 0x4e, 0x47, 0x10,
+	0x00, 0x12, 0x09, 0x0a, 0x05, 0x53, 0x54, 0x41, 0x4c, 0x45, 0x10, 0x01, 0x12, 0x0c, 0x0a, 0x08,
+	0x4d, 0x49, 0x53, 0x4d, 0x41, 0x54, 0x43, 0x48, 0x10, 0x02, 0x22, 0x15, 0x0a, 0x13, 0x52, 0x65,
+	0x6c, 0x6f, 0x61, 0x64, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x22, 0x18, 0x0a, 0x16, 0x47, 0x65, 0x74, 0x52, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xe7, 0x02, 0x0a, 0x17,
+	0x47, 0x65, 0x74, 0x52, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x45, 0x0a, 0x07, 0x74, 0x72, 0x69, 0x67, 0x67,
+	0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x2b, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61,
+	0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x54, 0x72,
+	0x69, 0x67, 0x67, 0x65, 0x72, 0x52, 0x07, 0x74, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72, 0x12, 0x42,
+	0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x2a,
+	0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x47, 0x65, 0x74, 0x52,
+	0x65, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x68, 0x61, 0x6e,
+	0x67, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x67,
+	0x65, 0x73, 0x22, 0x2d, 0x0a, 0x07, 0x54, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72, 0x12, 0x0b, 0x0a,
+	0x07, 0x53, 0x54, 0x41, 0x52, 0x54, 0x55, 0x50, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x52, 0x45,
+	0x51, 0x55, 0x45, 0x53, 0x54, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x46, 0x49, 0x4c, 0x45, 0x10,
+	0x02, 0x22, 0x32, 0x0a, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x0b, 0x0a, 0x07, 0x41,
+	0x50, 0x50, 0x4c, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x52, 0x45, 0x4a, 0x45,
+	0x43, 0x54, 0x45, 0x44, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x55, 0x4e, 0x43, 0x48, 0x41, 0x4e,
+	0x47, 0x45, 0x44, 0x10, 0x02, 0x22, 0x14, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x4c, 0x6f, 0x67, 0x4c,
+	0x65, 0x76, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xab, 0x01, 0x0a, 0x13,
+	0x47, 0x65, 0x74, 0x4c, 0x6f, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x43, 0x0a, 0x06, 0x74, 0x6f, 0x70,
+	0x69, 0x63, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2b, 0x2e, 0x67, 0x6f, 0x70, 0x6c,
+	0x61, 0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x47, 0x65, 0x74, 0x4c, 0x6f, 0x67, 0x4c, 0x65, 0x76,
+	0x65, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x54, 0x6f, 0x70, 0x69, 0x63,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x1a, 0x39,
+	0x0a, 0x0b, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x40, 0x0a, 0x12, 0x53, 0x65, 0x74,
+	0x4c, 0x6f, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x14, 0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x22, 0x40, 0x0a, 0x18, 0x43,
+	0x6c, 0x65, 0x61, 0x72, 0x44, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x4d, 0x61, 0x63,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6d,
+	0x61, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6d, 0x61, 0x63, 0x32, 0xb6, 0x07,
+	0x0a, 0x0a, 0x47, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x41, 0x70, 0x69, 0x12, 0x51, 0x0a, 0x11,
+	0x41, 0x64, 0x64, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72,
+	0x6b, 0x12, 0x24, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x41,
+	0x64, 0x64, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12,
+	0x57, 0x0a, 0x14, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c,
+	0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x12, 0x27, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e,
+	0x65, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x56, 0x69, 0x72, 0x74, 0x75,
+	0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x57, 0x0a, 0x14, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b,
+	0x12, 0x27, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x55, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f,
+	0x72, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x12, 0x65, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c,
+	0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x12, 0x25, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e,
+	0x65, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c,
+	0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x26,
+	0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74,
+	0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x12, 0x60, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x56,
+	0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x12, 0x24, 0x2e,
+	0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x47, 0x65, 0x74, 0x56, 0x69,
+	0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x25, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69,
+	0x2e, 0x47, 0x65, 0x74, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f,
+	0x72, 0x6b, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4b, 0x0a, 0x0a, 0x47, 0x65,
+	0x74, 0x46, 0x69, 0x62, 0x44, 0x69, 0x66, 0x66, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61,
+	0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x47, 0x65, 0x74, 0x46, 0x69, 0x62, 0x44, 0x69, 0x66, 0x66,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e,
+	0x65, 0x61, 0x70, 0x69, 0x2e, 0x47, 0x65, 0x74, 0x46, 0x69, 0x62, 0x44, 0x69, 0x66, 0x66, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x47, 0x0a, 0x0c, 0x52, 0x65, 0x6c, 0x6f, 0x61,
+	0x64, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x1f, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e,
+	0x65, 0x61, 0x70, 0x69, 0x2e, 0x52, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
+	0x12, 0x5a, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x52, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x22, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69,
+	0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x23, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e,
+	0x65, 0x61, 0x70, 0x69, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4e, 0x0a, 0x0b,
+	0x47, 0x65, 0x74, 0x4c, 0x6f, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x1e, 0x2e, 0x67, 0x6f,
+	0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x47, 0x65, 0x74, 0x4c, 0x6f, 0x67, 0x4c,
+	0x65, 0x76, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x67, 0x6f,
+	0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x47, 0x65, 0x74, 0x4c, 0x6f, 0x67, 0x4c,
+	0x65, 0x76, 0x65, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x45, 0x0a, 0x0b,
+	0x53, 0x65, 0x74, 0x4c, 0x6f, 0x67, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x1e, 0x2e, 0x67, 0x6f,
+	0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x53, 0x65, 0x74, 0x4c, 0x6f, 0x67, 0x4c,
+	0x65, 0x76, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x12, 0x51, 0x0a, 0x11, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x44, 0x75, 0x70, 0x6c,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x4d, 0x61, 0x63, 0x12, 0x24, 0x2e, 0x67, 0x6f, 0x70, 0x6c, 0x61,
+	0x6e, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x44, 0x75, 0x70, 0x6c, 0x69,
+	0x63, 0x61, 0x74, 0x65, 0x4d, 0x61, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
+	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x74, 0x73, 0x75, 0x62, 0x6f, 0x2f, 0x67, 0x6f, 0x70, 0x6c,
+	0x61, 0x6e, 0x65, 0x2f, 0x61, 0x70, 0x69, 0x3b, 0x67, 0x6f, 0x70, 0x6c, 0x61, 0x6e, 0x65, 0x61,
+	0x70, 0x69, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_goplane_proto_rawDescOnce sync.Once
+	file_goplane_proto_rawDescData = file_goplane_proto_rawDesc
+)
+
+func file_goplane_proto_rawDescGZIP() []byte {
+	file_goplane_proto_rawDescOnce.Do(func() {
+		file_goplane_proto_rawDescData = protoimpl.X.CompressGZIP(file_goplane_proto_rawDescData)
+	})
+	return file_goplane_proto_rawDescData
+}
+
+var file_goplane_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_goplane_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_goplane_proto_goTypes = []interface{}{
+	(VirtualNetworkState_Status)(0),      // 0: goplaneapi.VirtualNetworkState.Status
+	(FibDiff_Type)(0),                    // 1: goplaneapi.FibDiff.Type
+	(GetReloadStatusResponse_Trigger)(0), // 2: goplaneapi.GetReloadStatusResponse.Trigger
+	(GetReloadStatusResponse_Result)(0),  // 3: goplaneapi.GetReloadStatusResponse.Result
+	(*AddVirtualNetworkRequest)(nil),     // 4: goplaneapi.AddVirtualNetworkRequest
+	(*DeleteVirtualNetworkRequest)(nil),  // 5: goplaneapi.DeleteVirtualNetworkRequest
+	(*UpdateVirtualNetworkRequest)(nil),  // 6: goplaneapi.UpdateVirtualNetworkRequest
+	(*ListVirtualNetworkRequest)(nil),    // 7: goplaneapi.ListVirtualNetworkRequest
+	(*ListVirtualNetworkResponse)(nil),   // 8: goplaneapi.ListVirtualNetworkResponse
+	(*GetVirtualNetworkRequest)(nil),     // 9: goplaneapi.GetVirtualNetworkRequest
+	(*GetVirtualNetworkResponse)(nil),    // 10: goplaneapi.GetVirtualNetworkResponse
+	(*VirtualNetwork)(nil),               // 11: goplaneapi.VirtualNetwork
+	(*MacEntry)(nil),                     // 12: goplaneapi.MacEntry
+	(*FdbEntry)(nil),                     // 13: goplaneapi.FdbEntry
+	(*DuplicateMac)(nil),                 // 14: goplaneapi.DuplicateMac
+	(*VirtualNetworkState)(nil),          // 15: goplaneapi.VirtualNetworkState
+	(*GetFibDiffRequest)(nil),            // 16: goplaneapi.GetFibDiffRequest
+	(*GetFibDiffResponse)(nil),           // 17: goplaneapi.GetFibDiffResponse
+	(*FibDiff)(nil),                      // 18: goplaneapi.FibDiff
+	(*ReloadConfigRequest)(nil),          // 19: goplaneapi.ReloadConfigRequest
+	(*GetReloadStatusRequest)(nil),       // 20: goplaneapi.GetReloadStatusRequest
+	(*GetReloadStatusResponse)(nil),      // 21: goplaneapi.GetReloadStatusResponse
+	(*GetLogLevelRequest)(nil),           // 22: goplaneapi.GetLogLevelRequest
+	(*GetLogLevelResponse)(nil),          // 23: goplaneapi.GetLogLevelResponse
+	(*SetLogLevelRequest)(nil),           // 24: goplaneapi.SetLogLevelRequest
+	(*ClearDuplicateMacRequest)(nil),     // 25: goplaneapi.ClearDuplicateMacRequest
+	nil,                                  // 26: goplaneapi.GetLogLevelResponse.TopicsEntry
+	(*timestamppb.Timestamp)(nil),        // 27: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),                // 28: google.protobuf.Empty
+}
+var file_goplane_proto_depIdxs = []int32{
+	11, // 0: goplaneapi.AddVirtualNetworkRequest.virtual_network:type_name -> goplaneapi.VirtualNetwork
+	11, // 1: goplaneapi.UpdateVirtualNetworkRequest.virtual_network:type_name -> goplaneapi.VirtualNetwork
+	11, // 2: goplaneapi.ListVirtualNetworkResponse.virtual_network:type_name -> goplaneapi.VirtualNetwork
+	11, // 3: goplaneapi.GetVirtualNetworkResponse.virtual_network:type_name -> goplaneapi.VirtualNetwork
+	15, // 4: goplaneapi.GetVirtualNetworkResponse.state:type_name -> goplaneapi.VirtualNetworkState
+	27, // 5: goplaneapi.DuplicateMac.frozen:type_name -> google.protobuf.Timestamp
+	12, // 6: goplaneapi.VirtualNetworkState.local_macs:type_name -> goplaneapi.MacEntry
+	13, // 7: goplaneapi.VirtualNetworkState.remote_fdb:type_name -> goplaneapi.FdbEntry
+	0,  // 8: goplaneapi.VirtualNetworkState.status:type_name -> goplaneapi.VirtualNetworkState.Status
+	14, // 9: goplaneapi.VirtualNetworkState.duplicate_macs:type_name -> goplaneapi.DuplicateMac
+	18, // 10: goplaneapi.GetFibDiffResponse.diffs:type_name -> goplaneapi.FibDiff
+	1,  // 11: goplaneapi.FibDiff.type:type_name -> goplaneapi.FibDiff.Type
+	27, // 12: goplaneapi.GetReloadStatusResponse.time:type_name -> google.protobuf.Timestamp
+	2,  // 13: goplaneapi.GetReloadStatusResponse.trigger:type_name -> goplaneapi.GetReloadStatusResponse.Trigger
+	3,  // 14: goplaneapi.GetReloadStatusResponse.result:type_name -> goplaneapi.GetReloadStatusResponse.Result
+	26, // 15: goplaneapi.GetLogLevelResponse.topics:type_name -> goplaneapi.GetLogLevelResponse.TopicsEntry
+	4,  // 16: goplaneapi.GoplaneApi.AddVirtualNetwork:input_type -> goplaneapi.AddVirtualNetworkRequest
+	5,  // 17: goplaneapi.GoplaneApi.DeleteVirtualNetwork:input_type -> goplaneapi.DeleteVirtualNetworkRequest
+	6,  // 18: goplaneapi.GoplaneApi.UpdateVirtualNetwork:input_type -> goplaneapi.UpdateVirtualNetworkRequest
+	7,  // 19: goplaneapi.GoplaneApi.ListVirtualNetwork:input_type -> goplaneapi.ListVirtualNetworkRequest
+	9,  // 20: goplaneapi.GoplaneApi.GetVirtualNetwork:input_type -> goplaneapi.GetVirtualNetworkRequest
+	16, // 21: goplaneapi.GoplaneApi.GetFibDiff:input_type -> goplaneapi.GetFibDiffRequest
+	19, // 22: goplaneapi.GoplaneApi.ReloadConfig:input_type -> goplaneapi.ReloadConfigRequest
+	20, // 23: goplaneapi.GoplaneApi.GetReloadStatus:input_type -> goplaneapi.GetReloadStatusRequest
+	22, // 24: goplaneapi.GoplaneApi.GetLogLevel:input_type -> goplaneapi.GetLogLevelRequest
+	24, // 25: goplaneapi.GoplaneApi.SetLogLevel:input_type -> goplaneapi.SetLogLevelRequest
+	25, // 26: goplaneapi.GoplaneApi.ClearDuplicateMac:input_type -> goplaneapi.ClearDuplicateMacRequest
+	28, // 27: goplaneapi.GoplaneApi.AddVirtualNetwork:output_type -> google.protobuf.Empty
+	28, // 28: goplaneapi.GoplaneApi.DeleteVirtualNetwork:output_type -> google.protobuf.Empty
+	28, // 29: goplaneapi.GoplaneApi.UpdateVirtualNetwork:output_type -> google.protobuf.Empty
+	8,  // 30: goplaneapi.GoplaneApi.ListVirtualNetwork:output_type -> goplaneapi.ListVirtualNetworkResponse
+	10, // 31: goplaneapi.GoplaneApi.GetVirtualNetwork:output_type -> goplaneapi.GetVirtualNetworkResponse
+	17, // 32: goplaneapi.GoplaneApi.GetFibDiff:output_type -> goplaneapi.GetFibDiffResponse
+	28, // 33: goplaneapi.GoplaneApi.ReloadConfig:output_type -> google.protobuf.Empty
+	21, // 34: goplaneapi.GoplaneApi.GetReloadStatus:output_type -> goplaneapi.GetReloadStatusResponse
+	23, // 35: goplaneapi.GoplaneApi.GetLogLevel:output_type -> goplaneapi.GetLogLevelResponse
+	28, // 36: goplaneapi.GoplaneApi.SetLogLevel:output_type -> google.protobuf.Empty
+	28, // 37: goplaneapi.GoplaneApi.ClearDuplicateMac:output_type -> google.protobuf.Empty
+	27, // [27:38] is the sub-list for method output_type
+	16, // [16:27] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_goplane_proto_init() }
+func file_goplane_proto_init() {
+	if File_goplane_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_goplane_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AddVirtualNetworkRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteVirtualNetworkRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UpdateVirtualNetworkRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListVirtualNetworkRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListVirtualNetworkResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetVirtualNetworkRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetVirtualNetworkResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VirtualNetwork); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MacEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FdbEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DuplicateMac); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VirtualNetworkState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetFibDiffRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetFibDiffResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FibDiff); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReloadConfigRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetReloadStatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetReloadStatusResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetLogLevelRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetLogLevelResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SetLogLevelRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_goplane_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ClearDuplicateMacRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_goplane_proto_rawDesc,
+			NumEnums:      4,
+			NumMessages:   23,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_goplane_proto_goTypes,
+		DependencyIndexes: file_goplane_proto_depIdxs,
+		EnumInfos:         file_goplane_proto_enumTypes,
+		MessageInfos:      file_goplane_proto_msgTypes,
+	}.Build()
+	File_goplane_proto = out.File
+	file_goplane_proto_rawDesc = nil
+	file_goplane_proto_goTypes = nil
+	file_goplane_proto_depIdxs = nil
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// GoplaneApiClient is the client API for GoplaneApi service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type GoplaneApiClient interface {
+	AddVirtualNetwork(ctx context.Context, in *AddVirtualNetworkRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeleteVirtualNetwork(ctx context.Context, in *DeleteVirtualNetworkRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	UpdateVirtualNetwork(ctx context.Context, in *UpdateVirtualNetworkRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListVirtualNetwork(ctx context.Context, in *ListVirtualNetworkRequest, opts ...grpc.CallOption) (GoplaneApi_ListVirtualNetworkClient, error)
+	GetVirtualNetwork(ctx context.Context, in *GetVirtualNetworkRequest, opts ...grpc.CallOption) (*GetVirtualNetworkResponse, error)
+	GetFibDiff(ctx context.Context, in *GetFibDiffRequest, opts ...grpc.CallOption) (*GetFibDiffResponse, error)
+	ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetReloadStatus(ctx context.Context, in *GetReloadStatusRequest, opts ...grpc.CallOption) (*GetReloadStatusResponse, error)
+	GetLogLevel(ctx context.Context, in *GetLogLevelRequest, opts ...grpc.CallOption) (*GetLogLevelResponse, error)
+	SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ClearDuplicateMac(ctx context.Context, in *ClearDuplicateMacRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type goplaneApiClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGoplaneApiClient(cc grpc.ClientConnInterface) GoplaneApiClient {
+	return &goplaneApiClient{cc}
+}
+
+func (c *goplaneApiClient) AddVirtualNetwork(ctx context.Context, in *AddVirtualNetworkRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/goplaneapi.GoplaneApi/AddVirtualNetwork", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goplaneApiClient) DeleteVirtualNetwork(ctx context.Context, in *DeleteVirtualNetworkRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/goplaneapi.GoplaneApi/DeleteVirtualNetwork", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goplaneApiClient) UpdateVirtualNetwork(ctx context.Context, in *UpdateVirtualNetworkRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/goplaneapi.GoplaneApi/UpdateVirtualNetwork", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goplaneApiClient) ListVirtualNetwork(ctx context.Context, in *ListVirtualNetworkRequest, opts ...grpc.CallOption) (GoplaneApi_ListVirtualNetworkClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_GoplaneApi_serviceDesc.Streams[0], "/goplaneapi.GoplaneApi/ListVirtualNetwork", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &goplaneApiListVirtualNetworkClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GoplaneApi_ListVirtualNetworkClient interface {
+	Recv() (*ListVirtualNetworkResponse, error)
+	grpc.ClientStream
+}
+
+type goplaneApiListVirtualNetworkClient struct {
+	grpc.ClientStream
+}
+
+func (x *goplaneApiListVirtualNetworkClient) Recv() (*ListVirtualNetworkResponse, error) {
+	m := new(ListVirtualNetworkResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *goplaneApiClient) GetVirtualNetwork(ctx context.Context, in *GetVirtualNetworkRequest, opts ...grpc.CallOption) (*GetVirtualNetworkResponse, error) {
+	out := new(GetVirtualNetworkResponse)
+	err := c.cc.Invoke(ctx, "/goplaneapi.GoplaneApi/GetVirtualNetwork", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goplaneApiClient) GetFibDiff(ctx context.Context, in *GetFibDiffRequest, opts ...grpc.CallOption) (*GetFibDiffResponse, error) {
+	out := new(GetFibDiffResponse)
+	err := c.cc.Invoke(ctx, "/goplaneapi.GoplaneApi/GetFibDiff", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goplaneApiClient) ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/goplaneapi.GoplaneApi/ReloadConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goplaneApiClient) GetReloadStatus(ctx context.Context, in *GetReloadStatusRequest, opts ...grpc.CallOption) (*GetReloadStatusResponse, error) {
+	out := new(GetReloadStatusResponse)
+	err := c.cc.Invoke(ctx, "/goplaneapi.GoplaneApi/GetReloadStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goplaneApiClient) GetLogLevel(ctx context.Context, in *GetLogLevelRequest, opts ...grpc.CallOption) (*GetLogLevelResponse, error) {
+	out := new(GetLogLevelResponse)
+	err := c.cc.Invoke(ctx, "/goplaneapi.GoplaneApi/GetLogLevel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goplaneApiClient) SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/goplaneapi.GoplaneApi/SetLogLevel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goplaneApiClient) ClearDuplicateMac(ctx context.Context, in *ClearDuplicateMacRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/goplaneapi.GoplaneApi/ClearDuplicateMac", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GoplaneApiServer is the server API for GoplaneApi service.
+type GoplaneApiServer interface {
+	AddVirtualNetwork(context.Context, *AddVirtualNetworkRequest) (*emptypb.Empty, error)
+	DeleteVirtualNetwork(context.Context, *DeleteVirtualNetworkRequest) (*emptypb.Empty, error)
+	UpdateVirtualNetwork(context.Context, *UpdateVirtualNetworkRequest) (*emptypb.Empty, error)
+	ListVirtualNetwork(*ListVirtualNetworkRequest, GoplaneApi_ListVirtualNetworkServer) error
+	GetVirtualNetwork(context.Context, *GetVirtualNetworkRequest) (*GetVirtualNetworkResponse, error)
+	GetFibDiff(context.Context, *GetFibDiffRequest) (*GetFibDiffResponse, error)
+	ReloadConfig(context.Context, *ReloadConfigRequest) (*emptypb.Empty, error)
+	GetReloadStatus(context.Context, *GetReloadStatusRequest) (*GetReloadStatusResponse, error)
+	GetLogLevel(context.Context, *GetLogLevelRequest) (*GetLogLevelResponse, error)
+	SetLogLevel(context.Context, *SetLogLevelRequest) (*emptypb.Empty, error)
+	ClearDuplicateMac(context.Context, *ClearDuplicateMacRequest) (*emptypb.Empty, error)
+}
+
+// UnimplementedGoplaneApiServer can be embedded to have forward compatible implementations.
+type UnimplementedGoplaneApiServer struct {
+}
+
+func (*UnimplementedGoplaneApiServer) AddVirtualNetwork(context.Context, *AddVirtualNetworkRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddVirtualNetwork not implemented")
+}
+func (*UnimplementedGoplaneApiServer) DeleteVirtualNetwork(context.Context, *DeleteVirtualNetworkRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteVirtualNetwork not implemented")
+}
+func (*UnimplementedGoplaneApiServer) UpdateVirtualNetwork(context.Context, *UpdateVirtualNetworkRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateVirtualNetwork not implemented")
+}
+func (*UnimplementedGoplaneApiServer) ListVirtualNetwork(*ListVirtualNetworkRequest, GoplaneApi_ListVirtualNetworkServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListVirtualNetwork not implemented")
+}
+func (*UnimplementedGoplaneApiServer) GetVirtualNetwork(context.Context, *GetVirtualNetworkRequest) (*GetVirtualNetworkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVirtualNetwork not implemented")
+}
+func (*UnimplementedGoplaneApiServer) GetFibDiff(context.Context, *GetFibDiffRequest) (*GetFibDiffResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFibDiff not implemented")
+}
+func (*UnimplementedGoplaneApiServer) ReloadConfig(context.Context, *ReloadConfigRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReloadConfig not implemented")
+}
+func (*UnimplementedGoplaneApiServer) GetReloadStatus(context.Context, *GetReloadStatusRequest) (*GetReloadStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReloadStatus not implemented")
+}
+func (*UnimplementedGoplaneApiServer) GetLogLevel(context.Context, *GetLogLevelRequest) (*GetLogLevelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLogLevel not implemented")
+}
+func (*UnimplementedGoplaneApiServer) SetLogLevel(context.Context, *SetLogLevelRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
+}
+func (*UnimplementedGoplaneApiServer) ClearDuplicateMac(context.Context, *ClearDuplicateMacRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearDuplicateMac not implemented")
+}
+
+func RegisterGoplaneApiServer(s *grpc.Server, srv GoplaneApiServer) {
+	s.RegisterService(&_GoplaneApi_serviceDesc, srv)
+}
+
+func _GoplaneApi_AddVirtualNetwork_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddVirtualNetworkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoplaneApiServer).AddVirtualNetwork(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goplaneapi.GoplaneApi/AddVirtualNetwork",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoplaneApiServer).AddVirtualNetwork(ctx, req.(*AddVirtualNetworkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoplaneApi_DeleteVirtualNetwork_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteVirtualNetworkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoplaneApiServer).DeleteVirtualNetwork(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goplaneapi.GoplaneApi/DeleteVirtualNetwork",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoplaneApiServer).DeleteVirtualNetwork(ctx, req.(*DeleteVirtualNetworkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoplaneApi_UpdateVirtualNetwork_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateVirtualNetworkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoplaneApiServer).UpdateVirtualNetwork(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goplaneapi.GoplaneApi/UpdateVirtualNetwork",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoplaneApiServer).UpdateVirtualNetwork(ctx, req.(*UpdateVirtualNetworkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoplaneApi_ListVirtualNetwork_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListVirtualNetworkRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GoplaneApiServer).ListVirtualNetwork(m, &goplaneApiListVirtualNetworkServer{stream})
+}
+
+type GoplaneApi_ListVirtualNetworkServer interface {
+	Send(*ListVirtualNetworkResponse) error
+	grpc.ServerStream
+}
+
+type goplaneApiListVirtualNetworkServer struct {
+	grpc.ServerStream
+}
+
+func (x *goplaneApiListVirtualNetworkServer) Send(m *ListVirtualNetworkResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _GoplaneApi_GetVirtualNetwork_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVirtualNetworkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoplaneApiServer).GetVirtualNetwork(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goplaneapi.GoplaneApi/GetVirtualNetwork",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoplaneApiServer).GetVirtualNetwork(ctx, req.(*GetVirtualNetworkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoplaneApi_GetFibDiff_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFibDiffRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoplaneApiServer).GetFibDiff(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goplaneapi.GoplaneApi/GetFibDiff",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoplaneApiServer).GetFibDiff(ctx, req.(*GetFibDiffRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoplaneApi_ReloadConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoplaneApiServer).ReloadConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goplaneapi.GoplaneApi/ReloadConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoplaneApiServer).ReloadConfig(ctx, req.(*ReloadConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoplaneApi_GetReloadStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReloadStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoplaneApiServer).GetReloadStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goplaneapi.GoplaneApi/GetReloadStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoplaneApiServer).GetReloadStatus(ctx, req.(*GetReloadStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoplaneApi_GetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoplaneApiServer).GetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goplaneapi.GoplaneApi/GetLogLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoplaneApiServer).GetLogLevel(ctx, req.(*GetLogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoplaneApi_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoplaneApiServer).SetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goplaneapi.GoplaneApi/SetLogLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoplaneApiServer).SetLogLevel(ctx, req.(*SetLogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoplaneApi_ClearDuplicateMac_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearDuplicateMacRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoplaneApiServer).ClearDuplicateMac(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goplaneapi.GoplaneApi/ClearDuplicateMac",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoplaneApiServer).ClearDuplicateMac(ctx, req.(*ClearDuplicateMacRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _GoplaneApi_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "goplaneapi.GoplaneApi",
+	HandlerType: (*GoplaneApiServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddVirtualNetwork",
+			Handler:    _GoplaneApi_AddVirtualNetwork_Handler,
+		},
+		{
+			MethodName: "DeleteVirtualNetwork",
+			Handler:    _GoplaneApi_DeleteVirtualNetwork_Handler,
+		},
+		{
+			MethodName: "UpdateVirtualNetwork",
+			Handler:    _GoplaneApi_UpdateVirtualNetwork_Handler,
+		},
+		{
+			MethodName: "GetVirtualNetwork",
+			Handler:    _GoplaneApi_GetVirtualNetwork_Handler,
+		},
+		{
+			MethodName: "GetFibDiff",
+			Handler:    _GoplaneApi_GetFibDiff_Handler,
+		},
+		{
+			MethodName: "ReloadConfig",
+			Handler:    _GoplaneApi_ReloadConfig_Handler,
+		},
+		{
+			MethodName: "GetReloadStatus",
+			Handler:    _GoplaneApi_GetReloadStatus_Handler,
+		},
+		{
+			MethodName: "GetLogLevel",
+			Handler:    _GoplaneApi_GetLogLevel_Handler,
+		},
+		{
+			MethodName: "SetLogLevel",
+			Handler:    _GoplaneApi_SetLogLevel_Handler,
+		},
+		{
+			MethodName: "ClearDuplicateMac",
+			Handler:    _GoplaneApi_ClearDuplicateMac_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListVirtualNetwork",
+			Handler:       _GoplaneApi_ListVirtualNetwork_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "goplane.proto",
+}
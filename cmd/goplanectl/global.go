@@ -0,0 +1,165 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/spf13/cobra"
+
+	api "github.com/ttsubo/goplane/api"
+)
+
+func showFibDiff() error {
+	r, err := client.GetFibDiff(ctx, &api.GetFibDiffRequest{})
+	if err != nil {
+		return err
+	}
+	if globalOpts.Json {
+		printJSON(r.Diffs)
+		return nil
+	}
+	if len(r.Diffs) == 0 {
+		fmt.Println("BGP and kernel routes are in sync")
+		return nil
+	}
+	format := "%-8s %-20s %-30s %s\n"
+	fmt.Printf(format, "Type", "Prefix", "BGP Nexthops", "Kernel Nexthops")
+	for _, d := range r.Diffs {
+		fmt.Printf(format, strings.ToLower(d.Type.String()), d.Prefix, strings.Join(d.BgpNexthops, ","), strings.Join(d.KernelNexthops, ","))
+	}
+	return nil
+}
+
+func newFibCmd() *cobra.Command {
+	fibCmd := &cobra.Command{
+		Use:   "fib",
+		Short: "show kernel routes installed by goplane",
+	}
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "show differences between BGP best paths and kernel routes",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showFibDiff(); err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+	fibCmd.AddCommand(diffCmd)
+	return fibCmd
+}
+
+func showReloadStatus() error {
+	r, err := client.GetReloadStatus(ctx, &api.GetReloadStatusRequest{})
+	if err != nil {
+		return err
+	}
+	if globalOpts.Json {
+		printJSON(r)
+		return nil
+	}
+	t, err := ptypes.Timestamp(r.Time)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Last reload: %s (%s)\n", t.Local().Format(time.RFC3339), strings.ToLower(r.Trigger.String()))
+	fmt.Printf("Result:      %s\n", strings.ToLower(r.Result.String()))
+	if r.Error != "" {
+		fmt.Printf("Error:\n%s\n", r.Error)
+	}
+	if len(r.Changes) > 0 {
+		fmt.Println("Changes:")
+		for _, c := range r.Changes {
+			fmt.Printf("  %s\n", c)
+		}
+	}
+	return nil
+}
+
+func newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "manage the goplane config",
+	}
+	reloadCmd := &cobra.Command{
+		Use:   "reload",
+		Short: "reload the config file as SIGHUP does",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := client.ReloadConfig(ctx, &api.ReloadConfigRequest{}); err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "show the outcome of the last config reload",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showReloadStatus(); err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+	configCmd.AddCommand(reloadCmd, statusCmd)
+	return configCmd
+}
+
+func newLogLevelCmd() *cobra.Command {
+	var topic string
+	cmd := &cobra.Command{
+		Use:   "log-level [<level>]",
+		Short: "show or change the log level",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 1 {
+				if _, err := client.SetLogLevel(ctx, &api.SetLogLevelRequest{Level: args[0], Topic: topic}); err != nil {
+					exitWithError(err)
+				}
+				return
+			}
+			r, err := client.GetLogLevel(ctx, &api.GetLogLevelRequest{})
+			if err != nil {
+				exitWithError(err)
+			}
+			if globalOpts.Json {
+				printJSON(r)
+				return
+			}
+			if topic != "" {
+				if l, ok := r.Topics[strings.ToLower(topic)]; ok {
+					fmt.Println(l)
+				} else {
+					fmt.Println(r.Level)
+				}
+				return
+			}
+			fmt.Println(r.Level)
+			topics := make([]string, 0, len(r.Topics))
+			for t := range r.Topics {
+				topics = append(topics, t)
+			}
+			sort.Strings(topics)
+			for _, t := range topics {
+				fmt.Printf("%s: %s\n", t, r.Topics[t])
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&topic, "topic", "t", "", "topic of log entries, such as VirtualNetwork")
+	return cmd
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	api "github.com/ttsubo/goplane/api"
+	"github.com/ttsubo/goplane/config"
+	"github.com/ttsubo/goplane/dataplane"
+)
+
+var globalOpts struct {
+	Host string
+	Port int
+	Json bool
+	TLS  config.APIClient
+}
+
+var client api.GoplaneApiClient
+var ctx context.Context
+
+func newClient(ctx context.Context) (api.GoplaneApiClient, error) {
+	if globalOpts.TLS.Token == "" {
+		globalOpts.TLS.Token = os.Getenv("GOPLANE_API_TOKEN")
+	}
+	grpcOpts, err := dataplane.NewClientDialOptions(&globalOpts.TLS)
+	if err != nil {
+		return nil, err
+	}
+	grpcOpts = append(grpcOpts, grpc.WithBlock())
+	target := net.JoinHostPort(globalOpts.Host, strconv.Itoa(globalOpts.Port))
+	cc, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(cc, target, grpcOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return api.NewGoplaneApiClient(conn), nil
+}
+
+func exitWithError(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+func printJSON(v interface{}) {
+	j, _ := json.Marshal(v)
+	fmt.Println(string(j))
+}
+
+func newRootCmd() *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "goplanectl",
+		Short: "goplanectl shows and changes the state of the goplane dataplane",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			ctx = context.Background()
+			var err error
+			client, err = newClient(ctx)
+			if err != nil {
+				exitWithError(fmt.Errorf("failed to connect to goplane at %s:%d: %s", globalOpts.Host, globalOpts.Port, err))
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	rootCmd.PersistentFlags().StringVarP(&globalOpts.Host, "host", "u", "127.0.0.1", "host of the goplane API")
+	rootCmd.PersistentFlags().IntVarP(&globalOpts.Port, "port", "p", 50051, "port of the goplane API")
+	rootCmd.PersistentFlags().BoolVarP(&globalOpts.Json, "json", "j", false, "use json format to output")
+	rootCmd.PersistentFlags().BoolVar(&globalOpts.TLS.TLS, "tls", false, "connect to the goplane API with TLS")
+	rootCmd.PersistentFlags().StringVar(&globalOpts.TLS.CAFile, "tls-ca-file", "", "CA file to verify the goplane API with")
+	rootCmd.PersistentFlags().StringVar(&globalOpts.TLS.CertFile, "tls-cert-file", "", "client certificate file for mutual TLS")
+	rootCmd.PersistentFlags().StringVar(&globalOpts.TLS.KeyFile, "tls-key-file", "", "client key file for mutual TLS")
+	rootCmd.PersistentFlags().StringVar(&globalOpts.TLS.ServerName, "tls-server-name", "", "server name to verify the goplane API certificate with")
+	rootCmd.PersistentFlags().StringVar(&globalOpts.TLS.Token, "token", "", "bearer token of the goplane API ($GOPLANE_API_TOKEN if not specified)")
+
+	rootCmd.AddCommand(newVirtualNetworkCmd(), newFdbCmd(), newVtepCmd(), newFibCmd(), newConfigCmd(), newLogLevelCmd())
+	return rootCmd
+}
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
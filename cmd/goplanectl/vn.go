@@ -0,0 +1,308 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/spf13/cobra"
+
+	api "github.com/ttsubo/goplane/api"
+)
+
+func vnName(v *api.VirtualNetwork) string {
+	if v.Rd != "" {
+		return v.Rd
+	}
+	evi := v.Evi
+	if evi == 0 {
+		evi = v.Vni & 0xffff
+	}
+	return fmt.Sprintf("evi-%d", evi)
+}
+
+func listVirtualNetworks() ([]*api.VirtualNetwork, error) {
+	stream, err := client.ListVirtualNetwork(ctx, &api.ListVirtualNetworkRequest{})
+	if err != nil {
+		return nil, err
+	}
+	l := make([]*api.VirtualNetwork, 0)
+	for {
+		r, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		l = append(l, r.VirtualNetwork)
+	}
+	return l, nil
+}
+
+func getVirtualNetworks() ([]*api.GetVirtualNetworkResponse, error) {
+	l, err := listVirtualNetworks()
+	if err != nil {
+		return nil, err
+	}
+	rsps := make([]*api.GetVirtualNetworkResponse, 0, len(l))
+	for _, v := range l {
+		r, err := client.GetVirtualNetwork(ctx, &api.GetVirtualNetworkRequest{Name: vnName(v)})
+		if err != nil {
+			return nil, err
+		}
+		rsps = append(rsps, r)
+	}
+	return rsps, nil
+}
+
+func showVirtualNetworks() error {
+	l, err := listVirtualNetworks()
+	if err != nil {
+		return err
+	}
+	if globalOpts.Json {
+		printJSON(l)
+		return nil
+	}
+	format := "%-20s %-8s %-12s %-6s %-6s %s\n"
+	fmt.Printf(format, "Name", "VNI", "VTEP", "Port", "Etag", "Members")
+	for _, v := range l {
+		fmt.Printf(format, vnName(v), strconv.Itoa(int(v.Vni)), v.VtepInterface, strconv.Itoa(int(v.VxlanPort)), strconv.Itoa(int(v.Etag)), strings.Join(v.MemberInterfaces, ","))
+	}
+	return nil
+}
+
+func showVirtualNetwork(name string) error {
+	r, err := client.GetVirtualNetwork(ctx, &api.GetVirtualNetworkRequest{Name: name})
+	if err != nil {
+		return err
+	}
+	if globalOpts.Json {
+		printJSON(r)
+		return nil
+	}
+	v, s := r.VirtualNetwork, r.State
+	fmt.Printf("Virtual Network: %s\n", s.Name)
+	fmt.Printf("  Status:            %s\n", strings.ToLower(s.Status.String()))
+	if s.Restarts > 0 {
+		fmt.Printf("  Restarts:          %d\n", s.Restarts)
+		fmt.Printf("  Last Error:        %s\n", s.LastError)
+	}
+	fmt.Printf("  RD:                %s\n", s.Rd)
+	fmt.Printf("  VNI:               %d\n", v.Vni)
+	fmt.Printf("  Etag:              %d\n", v.Etag)
+	fmt.Printf("  VXLAN Port:        %d\n", v.VxlanPort)
+	fmt.Printf("  Bridge:            %s\n", s.Bridge)
+	fmt.Printf("  VTEP:              %s\n", s.Vtep)
+	if v.VtepMode != "" {
+		fmt.Printf("  VTEP Mode:         %s\n", v.VtepMode)
+	}
+	if v.Encap != "" {
+		fmt.Printf("  Encapsulation:     %s\n", v.Encap)
+	}
+	if v.MulticastGroup != "" {
+		fmt.Printf("  Multicast Group:   %s (%s)\n", v.MulticastGroup, v.MulticastInterface)
+	}
+	if len(v.GatewayAddresses) > 0 {
+		fmt.Printf("  Anycast Gateway:   %s (%s)\n", strings.Join(v.GatewayAddresses, ", "), v.GatewayMac)
+	}
+	fmt.Printf("  Import RT:         %s\n", strings.Join(v.ImportRt, ", "))
+	fmt.Printf("  Export RT:         %s\n", strings.Join(v.ExportRt, ", "))
+	fmt.Printf("  Member Interfaces: %s\n", strings.Join(v.MemberInterfaces, ", "))
+	fmt.Printf("  Sniff Interfaces:  %s\n", strings.Join(v.SniffInterfaces, ", "))
+	fmt.Printf("  Remote VTEPs:      %s\n", strings.Join(s.RemoteVteps, ", "))
+	fmt.Printf("  Local MACs:\n")
+	for _, e := range s.LocalMacs {
+		fmt.Printf("    %-17s %s\n", e.Mac, e.Ip)
+	}
+	fmt.Printf("  Remote FDB:\n")
+	for _, e := range s.RemoteFdb {
+		fmt.Printf("    %-17s %s\n", e.Mac, e.Vtep)
+	}
+	if len(s.DuplicateMacs) > 0 {
+		fmt.Printf("  Duplicate MACs:\n")
+		for _, e := range s.DuplicateMacs {
+			vtep := e.Vtep
+			if vtep == "" {
+				vtep = "local"
+			}
+			t, err := ptypes.Timestamp(e.Frozen)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("    %-17s %-15s %d moves, frozen at %s\n", e.Mac, vtep, e.Moves, t.Local().Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+func newVirtualNetworkCmd() *cobra.Command {
+	vnCmd := &cobra.Command{
+		Use:   "vn",
+		Short: "manage virtual networks",
+	}
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "list virtual networks",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showVirtualNetworks(); err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+	showCmd := &cobra.Command{
+		Use:   "show <rd>",
+		Short: "show the state of a virtual network",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showVirtualNetwork(args[0]); err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+	clearDuplicateCmd := &cobra.Command{
+		Use:   "clear-duplicate <rd> [<mac>]",
+		Short: "unfreeze a duplicate MAC, or all of them, of a virtual network",
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			r := &api.ClearDuplicateMacRequest{Name: args[0]}
+			if len(args) > 1 {
+				r.Mac = args[1]
+			}
+			if _, err := client.ClearDuplicateMac(ctx, r); err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+	vnCmd.AddCommand(listCmd, showCmd, clearDuplicateCmd)
+	return vnCmd
+}
+
+func showFdb(vni uint32) error {
+	l, err := getVirtualNetworks()
+	if err != nil {
+		return err
+	}
+	type fdbEntry struct {
+		Mac   string `json:"mac"`
+		Dest  string `json:"dest"`
+		Local bool   `json:"local"`
+	}
+	entries := make([]fdbEntry, 0)
+	found := false
+	for _, r := range l {
+		if r.VirtualNetwork.Vni != vni {
+			continue
+		}
+		found = true
+		for _, e := range r.State.LocalMacs {
+			entries = append(entries, fdbEntry{Mac: e.Mac, Dest: e.Ip, Local: true})
+		}
+		for _, e := range r.State.RemoteFdb {
+			entries = append(entries, fdbEntry{Mac: e.Mac, Dest: e.Vtep})
+		}
+	}
+	if !found {
+		return fmt.Errorf("virtual network with vni %d doesn't exist", vni)
+	}
+	if globalOpts.Json {
+		printJSON(entries)
+		return nil
+	}
+	format := "%-17s %-8s %s\n"
+	fmt.Printf(format, "MAC", "Type", "Destination")
+	for _, e := range entries {
+		typ := "remote"
+		if e.Local {
+			typ = "local"
+		}
+		fmt.Printf(format, e.Mac, typ, e.Dest)
+	}
+	return nil
+}
+
+func newFdbCmd() *cobra.Command {
+	fdbCmd := &cobra.Command{
+		Use:   "fdb",
+		Short: "show forwarding database entries",
+	}
+	showCmd := &cobra.Command{
+		Use:   "show <vni>",
+		Short: "show local and remote MAC addresses of a virtual network",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			vni, err := strconv.ParseUint(args[0], 10, 32)
+			if err != nil {
+				exitWithError(fmt.Errorf("invalid vni: %s", args[0]))
+			}
+			if err := showFdb(uint32(vni)); err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+	fdbCmd.AddCommand(showCmd)
+	return fdbCmd
+}
+
+func showVteps() error {
+	l, err := getVirtualNetworks()
+	if err != nil {
+		return err
+	}
+	vteps := make(map[string][]string)
+	order := make([]string, 0)
+	for _, r := range l {
+		for _, vtep := range r.State.RemoteVteps {
+			if _, ok := vteps[vtep]; !ok {
+				order = append(order, vtep)
+			}
+			vteps[vtep] = append(vteps[vtep], r.State.Name)
+		}
+	}
+	if globalOpts.Json {
+		printJSON(vteps)
+		return nil
+	}
+	format := "%-20s %s\n"
+	fmt.Printf(format, "VTEP", "Virtual Networks")
+	for _, vtep := range order {
+		fmt.Printf(format, vtep, strings.Join(vteps[vtep], ", "))
+	}
+	return nil
+}
+
+func newVtepCmd() *cobra.Command {
+	vtepCmd := &cobra.Command{
+		Use:   "vtep",
+		Short: "show remote VTEPs",
+	}
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "list remote VTEPs and the virtual networks they serve",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := showVteps(); err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+	vtepCmd.AddCommand(listCmd)
+	return vtepCmd
+}
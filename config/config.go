@@ -16,22 +16,388 @@
 package config
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	bgpconfig "github.com/ttsubo/goplane/internal/pkg/config"
 )
 
+// RouteTargetAuto can be listed in ImportRtList or ExportRtList to
+// request a route target auto-derived from the local AS and the VNI
+// as described in RFC 8365 section 5.1.2.1.
+const RouteTargetAuto = "auto"
+
 type VirtualNetwork struct {
-	RD               string   `mapstructure:"rd"`
+	// Route distinguisher. When empty, it is derived from the router
+	// ID and Evi (RFC 7432 section 7.9).
+	RD string `mapstructure:"rd"`
+	// EVI index used for RD auto-derivation. Defaults to VNI, which
+	// then has to fit in the 2 octets of the RD.
+	Evi              uint16   `mapstructure:"evi"`
 	VNI              uint32   `mapstructure:"vni"`
 	VxlanPort        uint16   `mapstructure:"vxlan-port"`
 	VtepInterface    string   `mapstructure:"vtep-interface"`
+	VtepMode         string   `mapstructure:"vtep-mode"`
+	Encap            string   `mapstructure:"encap"`
 	Etag             uint32   `mapstructure:"etag"`
 	SniffInterfaces  []string `mapstructure:"sniff-interfaces"`
 	MemberInterfaces []string `mapstructure:"member-interfaces"`
+	// Route targets. When both are empty, the RD is also used as the
+	// route target for backward compatibility.
+	ImportRtList []string `mapstructure:"import-rt-list"`
+	ExportRtList []string `mapstructure:"export-rt-list"`
+	// Underlay multicast group the BUM traffic is sent to, joined on
+	// MulticastInterface. Ingress replication is used when it is empty.
+	MulticastGroup     string `mapstructure:"multicast-group"`
+	MulticastInterface string `mapstructure:"multicast-interface"`
+	// Distributed anycast gateway set on the bridge of every host, with
+	// the addresses in CIDR notation and the MAC shared by the hosts.
+	GatewayAddresses []string `mapstructure:"gateway-addresses"`
+	GatewayMac       string   `mapstructure:"gateway-mac"`
+}
+
+// Key identifies a virtual network across config reloads. It is also
+// used as the name of the VRF backing the virtual network.
+func (v *VirtualNetwork) Key() string {
+	if v.RD != "" {
+		return v.RD
+	}
+	return fmt.Sprintf("evi-%d", v.EviIndex())
+}
+
+// The modes of the VTEP of a virtual network. VtepMode defaults to
+// VtepModeKernel.
+const (
+	// VtepModeKernel makes the VTEP a vxlan device of the kernel.
+	VtepModeKernel = "kernel"
+	// VtepModeUserspace makes the VTEP a TAP device whose frames are
+	// encapsulated and decapsulated by goplane, for the hosts where
+	// vxlan devices can't be created.
+	VtepModeUserspace = "userspace"
+)
+
+// The encapsulations of a virtual network, advertised in the BGP
+// Encapsulation extended community of RFC 8365. Encap defaults to
+// EncapVxlan.
+const (
+	EncapVxlan = "vxlan"
+	// EncapGeneve connects the bridge to each remote VTEP with a geneve
+	// device of the kernel.
+	EncapGeneve = "geneve"
+)
+
+// The UDP ports of VXLAN and Geneve assigned by IANA. They are used
+// when VxlanPort isn't set.
+const (
+	DefaultVxlanPort  = 4789
+	DefaultGenevePort = 6081
+)
+
+// Userspace reports whether the VTEP is run by goplane.
+func (v *VirtualNetwork) Userspace() bool {
+	return v.VtepMode == VtepModeUserspace
+}
+
+// Multicast reports whether the BUM traffic is sent to an underlay
+// multicast group instead of being replicated to each remote VTEP.
+func (v *VirtualNetwork) Multicast() bool {
+	return v.MulticastGroup != ""
+}
+
+// Gateway reports whether the hosts are the anycast gateway of the
+// virtual network.
+func (v *VirtualNetwork) Gateway() bool {
+	return len(v.GatewayAddresses) > 0
+}
+
+// Geneve reports whether the virtual network uses Geneve instead of
+// VXLAN.
+func (v *VirtualNetwork) Geneve() bool {
+	return v.Encap == EncapGeneve
+}
+
+// Port returns the UDP port of the tunnels of the userspace VTEP and
+// of Geneve.
+func (v *VirtualNetwork) Port() uint16 {
+	if v.VxlanPort != 0 {
+		return v.VxlanPort
+	}
+	if v.Geneve() {
+		return DefaultGenevePort
+	}
+	return DefaultVxlanPort
+}
+
+func (v *VirtualNetwork) EviIndex() uint16 {
+	if v.Evi != 0 {
+		return v.Evi
+	}
+	return uint16(v.VNI)
+}
+
+func equalStringList(lhs, rhs []string) bool {
+	if len(lhs) != len(rhs) {
+		return false
+	}
+	for idx, l := range lhs {
+		if l != rhs[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+func (lhs *VirtualNetwork) Equal(rhs *VirtualNetwork) bool {
+	if lhs == nil || rhs == nil {
+		return false
+	}
+	if lhs.RD != rhs.RD {
+		return false
+	}
+	if lhs.Evi != rhs.Evi {
+		return false
+	}
+	if lhs.VNI != rhs.VNI {
+		return false
+	}
+	if lhs.VxlanPort != rhs.VxlanPort {
+		return false
+	}
+	if lhs.VtepInterface != rhs.VtepInterface {
+		return false
+	}
+	if lhs.VtepMode != rhs.VtepMode {
+		return false
+	}
+	if lhs.Encap != rhs.Encap {
+		return false
+	}
+	if lhs.Etag != rhs.Etag {
+		return false
+	}
+	if !equalStringList(lhs.SniffInterfaces, rhs.SniffInterfaces) {
+		return false
+	}
+	if !equalStringList(lhs.MemberInterfaces, rhs.MemberInterfaces) {
+		return false
+	}
+	if !equalStringList(lhs.ImportRtList, rhs.ImportRtList) {
+		return false
+	}
+	if !equalStringList(lhs.ExportRtList, rhs.ExportRtList) {
+		return false
+	}
+	if lhs.MulticastGroup != rhs.MulticastGroup || lhs.MulticastInterface != rhs.MulticastInterface {
+		return false
+	}
+	if !equalStringList(lhs.GatewayAddresses, rhs.GatewayAddresses) || lhs.GatewayMac != rhs.GatewayMac {
+		return false
+	}
+	return true
+}
+
+// NeedsRecreate reports whether moving from lhs to rhs changes the
+// identity of the VTEP. Member and sniff interface changes can be
+// applied to a running virtual network; everything else can't.
+func (lhs *VirtualNetwork) NeedsRecreate(rhs *VirtualNetwork) bool {
+	if lhs.VNI != rhs.VNI || lhs.VxlanPort != rhs.VxlanPort || lhs.VtepInterface != rhs.VtepInterface || lhs.VtepMode != rhs.VtepMode || lhs.Encap != rhs.Encap || lhs.Etag != rhs.Etag || lhs.MulticastGroup != rhs.MulticastGroup || lhs.MulticastInterface != rhs.MulticastInterface {
+		return true
+	}
+	if !equalStringList(lhs.GatewayAddresses, rhs.GatewayAddresses) || lhs.GatewayMac != rhs.GatewayMac {
+		return true
+	}
+	return lhs.Evi != rhs.Evi || !equalStringList(lhs.ImportRtList, rhs.ImportRtList) || !equalStringList(lhs.ExportRtList, rhs.ExportRtList)
+}
+
+// DefaultStaleTime is the number of seconds stale kernel routes and FDB
+// entries are kept after a graceful restart when End-of-RIB doesn't
+// arrive from all peers.
+const DefaultStaleTime = 300
+
+type GracefulRestart struct {
+	StaleTime uint32 `mapstructure:"stale-time"`
+}
+
+// DefaultDuplicateMacMoves and DefaultDuplicateMacWindow are N and M of
+// the duplicate MAC detection of RFC 7432 section 15.1.
+const (
+	DefaultDuplicateMacMoves  = 5
+	DefaultDuplicateMacWindow = 180
+)
+
+// DuplicateMac configures the duplicate MAC detection of the virtual
+// networks. A MAC moving Moves times within Window seconds between the
+// local host and the remote VTEPs is frozen until it is cleared through
+// the API.
+type DuplicateMac struct {
+	Disabled bool   `mapstructure:"disabled"`
+	Moves    uint32 `mapstructure:"moves"`
+	Window   uint32 `mapstructure:"window"`
+}
+
+func (d *DuplicateMac) MaxMoves() int {
+	if d.Moves == 0 {
+		return DefaultDuplicateMacMoves
+	}
+	return int(d.Moves)
+}
+
+func (d *DuplicateMac) WindowDuration() time.Duration {
+	if d.Window == 0 {
+		return DefaultDuplicateMacWindow * time.Second
+	}
+	return time.Duration(d.Window) * time.Second
+}
+
+// DefaultNeighborRefreshInterval is the number of seconds between the
+// probes of the stale neighbors advertised as host routes.
+const DefaultNeighborRefreshInterval = 30
+
+// RedistributeNeighbor advertises the IPv4 and IPv6 neighbors on
+// Interfaces as /32 and /128 host routes, for the racks routing to each
+// host instead of bridging.
+type RedistributeNeighbor struct {
+	Interfaces []string `mapstructure:"interfaces"`
+	// Communities of the host routes, such as "65000:100" or
+	// "no-export".
+	Communities []string `mapstructure:"communities"`
+	// The stale neighbors are probed every RefreshInterval seconds so
+	// that the live hosts don't age out. Defaults to
+	// DefaultNeighborRefreshInterval.
+	RefreshInterval uint32 `mapstructure:"refresh-interval"`
+}
+
+func (r *RedistributeNeighbor) Enabled() bool {
+	return len(r.Interfaces) > 0
+}
+
+func (r *RedistributeNeighbor) RefreshDuration() time.Duration {
+	if r.RefreshInterval == 0 {
+		return DefaultNeighborRefreshInterval * time.Second
+	}
+	return time.Duration(r.RefreshInterval) * time.Second
+}
+
+// DefaultShutdownTimeout is the number of seconds goplane waits for
+// the shutdown to complete on SIGTERM or SIGINT.
+const DefaultShutdownTimeout = 10
+
+type Shutdown struct {
+	// Delete the bridges, VTEPs and kernel routes created by goplane.
+	// They are kept by default so that a graceful restart can reuse
+	// them.
+	FlushState bool   `mapstructure:"flush-state"`
+	Timeout    uint32 `mapstructure:"timeout"`
+}
+
+func (s *Shutdown) TimeoutDuration() time.Duration {
+	if s.Timeout == 0 {
+		return DefaultShutdownTimeout * time.Second
+	}
+	return time.Duration(s.Timeout) * time.Second
+}
+
+// DefaultZebraURL is the zserv socket of FRR.
+const DefaultZebraURL = "unix:/var/run/frr/zserv.api"
+
+// Zebra configures the connection of the zebra dataplane to FRR's
+// zebra. Only ZAPI version 6 of FRR 7.2 and 7.3 is supported.
+type Zebra struct {
+	// URL of zebra as "unix:<path>" or "tcp:<host>:<port>".
+	// Defaults to DefaultZebraURL.
+	URL string `mapstructure:"url"`
+	// Version of ZAPI. Defaults to 6.
+	Version uint8 `mapstructure:"version"`
+	// SoftwareName is "frr7.2" for FRR 7.2, and empty for FRR 7.3.
+	SoftwareName string `mapstructure:"software-name"`
+}
+
+// networkAddress splits url of the form "unix:<path>" or
+// "tcp:<host>:<port>" into the arguments of net.Dial.
+func networkAddress(url, defaultURL string) (string, string, error) {
+	if url == "" {
+		url = defaultURL
+	}
+	l := strings.SplitN(url, ":", 2)
+	if len(l) != 2 || (l[0] != "unix" && l[0] != "tcp") || l[1] == "" {
+		return "", "", fmt.Errorf("unsupported url: %s", url)
+	}
+	return l[0], l[1], nil
+}
+
+func (z *Zebra) NetworkAddress() (string, string, error) {
+	return networkAddress(z.URL, DefaultZebraURL)
+}
+
+func (z *Zebra) ZapiVersion() uint8 {
+	if z.Version == 0 {
+		return 6
+	}
+	return z.Version
+}
+
+const (
+	// DefaultOVSDBURL is the socket of the ovsdb-server of Open vSwitch.
+	DefaultOVSDBURL = "unix:/var/run/openvswitch/db.sock"
+	// DefaultOVSDBDatabase is the database of the hardware_vtep schema.
+	DefaultOVSDBDatabase = "hardware_vtep"
+)
+
+// OVSDB configures the connection of the ovsdb dataplane to the
+// ovsdb-server of a switch.
+type OVSDB struct {
+	// URL of the ovsdb-server as "unix:<path>" or "tcp:<host>:<port>".
+	// Defaults to DefaultOVSDBURL.
+	URL string `mapstructure:"url"`
+	// Database using the hardware_vtep schema. Defaults to
+	// DefaultOVSDBDatabase.
+	Database string `mapstructure:"database"`
+}
+
+func (o *OVSDB) NetworkAddress() (string, string, error) {
+	return networkAddress(o.URL, DefaultOVSDBURL)
+}
+
+func (o *OVSDB) DatabaseName() string {
+	if o.Database == "" {
+		return DefaultOVSDBDatabase
+	}
+	return o.Database
 }
 
 type Dataplane struct {
 	Type               string           `mapstructure:"type"`
+	GracefulRestart    GracefulRestart  `mapstructure:"graceful-restart"`
+	Shutdown           Shutdown         `mapstructure:"shutdown"`
+	DuplicateMac       DuplicateMac     `mapstructure:"duplicate-mac"`
+	Zebra              Zebra            `mapstructure:"zebra"`
+	OVSDB              OVSDB            `mapstructure:"ovsdb"`
 	VirtualNetworkList []VirtualNetwork `mapstructure:"virtual-network-list"`
+	// Host routes of the neighbors, for the racks without bridging.
+	RedistributeNeighbor RedistributeNeighbor `mapstructure:"redistribute-neighbor"`
+	// Protocol number marking the kernel routes installed by goplane.
+	// Defaults to DefaultRouteProtocol.
+	RouteProtocol uint8 `mapstructure:"route-protocol"`
+}
+
+// DefaultRouteProtocol is the protocol number of the kernel routes of
+// goplane. Unlike RTPROT_BGP (186), it isn't used by the other routing
+// daemons, so goplane never flushes or sweeps their routes.
+const DefaultRouteProtocol = 245
+
+func (d *Dataplane) RouteProtocolNumber() uint8 {
+	if d.RouteProtocol == 0 {
+		return DefaultRouteProtocol
+	}
+	return d.RouteProtocol
+}
+
+func (d *Dataplane) StaleTime() time.Duration {
+	if d.GracefulRestart.StaleTime == 0 {
+		return DefaultStaleTime * time.Second
+	}
+	return time.Duration(d.GracefulRestart.StaleTime) * time.Second
 }
 
 type Iptables struct {
@@ -39,8 +405,78 @@ type Iptables struct {
 	Chain   string `mapstructure:"chain"`
 }
 
+// APITLS enables TLS on the gRPC API listeners. Clients must present
+// a certificate signed by ClientCAFile when it is set.
+type APITLS struct {
+	CertFile     string `mapstructure:"cert-file"`
+	KeyFile      string `mapstructure:"key-file"`
+	ClientCAFile string `mapstructure:"client-ca-file"`
+}
+
+type APIToken struct {
+	Token string `mapstructure:"token"`
+	// Full gRPC method names the token may call, such as
+	// "/gobgpapi.GobgpApi/ListPeer". A trailing "*" matches any method
+	// with the prefix. All methods are allowed when empty.
+	Methods []string `mapstructure:"methods"`
+}
+
+// APIClient configures the connection of goplane to the gobgpd API.
+type APIClient struct {
+	TLS bool `mapstructure:"tls"`
+	// CA certificate to verify gobgpd with. The system roots are used
+	// when empty.
+	CAFile string `mapstructure:"ca-file"`
+	// Client certificate for mutual TLS.
+	CertFile   string `mapstructure:"cert-file"`
+	KeyFile    string `mapstructure:"key-file"`
+	ServerName string `mapstructure:"server-name"`
+	Token      string `mapstructure:"token"`
+}
+
+type API struct {
+	TLS APITLS `mapstructure:"tls"`
+	// Bearer tokens accepted by the API. Authorization is disabled
+	// when empty.
+	Tokens []APIToken `mapstructure:"tokens"`
+	Client APIClient  `mapstructure:"client"`
+}
+
+type LoggingSyslog struct {
+	// Network and address of the syslog daemon. The local daemon is
+	// used when empty.
+	Network  string `mapstructure:"network"`
+	Address  string `mapstructure:"address"`
+	Facility string `mapstructure:"facility"`
+	Tag      string `mapstructure:"tag"`
+}
+
+type LoggingFile struct {
+	Path string `mapstructure:"path"`
+	// The file is rotated when it grows over MaxSize megabytes, and
+	// MaxBackups rotated files are kept. It is never rotated when
+	// MaxSize is 0.
+	MaxSize    uint32 `mapstructure:"max-size"`
+	MaxBackups uint32 `mapstructure:"max-backups"`
+}
+
+type Logging struct {
+	Level string `mapstructure:"level"`
+	// Levels of the topics of log entries, overriding Level. Topic
+	// names are case insensitive.
+	Topics map[string]string `mapstructure:"topics"`
+	// json or text
+	Format string `mapstructure:"format"`
+	// stdout, syslog, journald, file or none
+	Output string        `mapstructure:"output"`
+	Syslog LoggingSyslog `mapstructure:"syslog"`
+	File   LoggingFile   `mapstructure:"file"`
+}
+
 type Config struct {
 	Dataplane Dataplane              `mapstructure:"dataplane"`
 	Iptables  Iptables               `mapstructure:"iptables"`
+	API       API                    `mapstructure:"api"`
+	Logging   Logging                `mapstructure:"logging"`
 	BGP       bgpconfig.BgpConfigSet `mapstructure:"bgp"`
 }
@@ -0,0 +1,153 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	bgpconfig "github.com/ttsubo/goplane/internal/pkg/config"
+)
+
+const (
+	// ReloadTriggerStartup is the first read of the config file.
+	ReloadTriggerStartup = "startup"
+	// ReloadTriggerRequest is a reload requested by SIGHUP or the API.
+	ReloadTriggerRequest = "request"
+	// ReloadTriggerFile is a reload caused by a change of the file.
+	ReloadTriggerFile = "file"
+)
+
+const (
+	// ReloadApplied means the config was sent to be applied.
+	ReloadApplied = "applied"
+	// ReloadRejected means the config couldn't be read or was invalid,
+	// and the running config was kept.
+	ReloadRejected = "rejected"
+	// ReloadUnchanged means the file changed but the config it holds
+	// didn't, so nothing was applied.
+	ReloadUnchanged = "unchanged"
+)
+
+// ReloadStatus is the outcome of a read of the config file.
+type ReloadStatus struct {
+	Time    time.Time
+	Trigger string
+	Result  string
+	Error   string
+	Changes []string
+}
+
+var (
+	reloadMu   sync.Mutex
+	lastReload *ReloadStatus
+)
+
+func setLastReload(s *ReloadStatus) {
+	s.Time = time.Now()
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	lastReload = s
+}
+
+// LastReload returns the outcome of the last read of the config file,
+// or nil when it hasn't been read yet.
+func LastReload() *ReloadStatus {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	if lastReload == nil {
+		return nil
+	}
+	s := *lastReload
+	s.Changes = append([]string{}, lastReload.Changes...)
+	return &s
+}
+
+// Changes describes the differences from a to b, such as "virtual
+// network 65000:10 added".
+func Changes(a, b *Config) []string {
+	l := []string{}
+	as, ds, us := UpdateConfig(&a.Dataplane, b.Dataplane)
+	for _, v := range as {
+		l = append(l, fmt.Sprintf("virtual network %s added", v.Key()))
+	}
+	for _, v := range ds {
+		l = append(l, fmt.Sprintf("virtual network %s deleted", v.Key()))
+	}
+	for _, v := range us {
+		l = append(l, fmt.Sprintf("virtual network %s updated", v.Key()))
+	}
+	da, db := a.Dataplane, b.Dataplane
+	da.VirtualNetworkList, db.VirtualNetworkList = nil, nil
+	if !reflect.DeepEqual(da, db) {
+		l = append(l, "dataplane changed")
+	}
+	l = append(l, bgpChanges(&a.BGP, &b.BGP)...)
+	if !reflect.DeepEqual(a.Iptables, b.Iptables) {
+		l = append(l, "iptables changed")
+	}
+	if !reflect.DeepEqual(a.API, b.API) {
+		l = append(l, "api changed")
+	}
+	if !reflect.DeepEqual(a.Logging, b.Logging) {
+		l = append(l, "logging changed")
+	}
+	return l
+}
+
+func bgpChanges(a, b *bgpconfig.BgpConfigSet) []string {
+	l := []string{}
+	if !a.Global.Equal(&b.Global) {
+		l = append(l, "bgp global changed")
+	}
+	added, deleted, updated := bgpconfig.UpdateNeighborConfig(a, b)
+	for _, n := range added {
+		l = append(l, fmt.Sprintf("bgp neighbor %s added", n.State.NeighborAddress))
+	}
+	for _, n := range deleted {
+		l = append(l, fmt.Sprintf("bgp neighbor %s deleted", n.State.NeighborAddress))
+	}
+	for _, n := range updated {
+		l = append(l, fmt.Sprintf("bgp neighbor %s updated", n.State.NeighborAddress))
+	}
+	addedPg, deletedPg, updatedPg := bgpconfig.UpdatePeerGroupConfig(a, b)
+	for _, pg := range addedPg {
+		l = append(l, fmt.Sprintf("bgp peer group %s added", pg.Config.PeerGroupName))
+	}
+	for _, pg := range deletedPg {
+		l = append(l, fmt.Sprintf("bgp peer group %s deleted", pg.Config.PeerGroupName))
+	}
+	for _, pg := range updatedPg {
+		l = append(l, fmt.Sprintf("bgp peer group %s updated", pg.Config.PeerGroupName))
+	}
+	if bgpconfig.CheckPolicyDifference(bgpconfig.ConfigSetToRoutingPolicy(a), bgpconfig.ConfigSetToRoutingPolicy(b)) {
+		l = append(l, "bgp policy changed")
+	}
+
+	// the other sections are reported as a whole
+	ra, rb := *a, *b
+	ra.Global, rb.Global = bgpconfig.Global{}, bgpconfig.Global{}
+	ra.Neighbors, rb.Neighbors = nil, nil
+	ra.PeerGroups, rb.PeerGroups = nil, nil
+	ra.DefinedSets, rb.DefinedSets = bgpconfig.DefinedSets{}, bgpconfig.DefinedSets{}
+	ra.PolicyDefinitions, rb.PolicyDefinitions = nil, nil
+	if !reflect.DeepEqual(ra, rb) {
+		l = append(l, "bgp changed")
+	}
+	return l
+}
@@ -16,47 +16,146 @@
 package config
 
 import (
+	"strings"
+	"time"
+
 	log "github.com/sirupsen/logrus"
 	bgpconfig "github.com/ttsubo/goplane/internal/pkg/config"
 	"github.com/spf13/viper"
 )
 
-func ReadConfigfileServe(path, format string, configCh chan *Config, bgpConfigCh chan *bgpconfig.BgpConfigSet, reloadCh chan bool) {
-	for {
-		<-reloadCh
-		c := &Config{}
-		v := viper.New()
-		v.SetConfigFile(path)
-		v.SetConfigType(format)
-		err := v.ReadInConfig()
-		if err != nil {
-			log.Fatal("can't read config file ", path, ", ", err)
+// ReadConfigfile reads the config file at path and fills the default
+// values of the BGP sections.
+func ReadConfigfile(path, format string) (*Config, error) {
+	c := &Config{}
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType(format)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	if err := v.UnmarshalExact(&c); err != nil {
+		return nil, err
+	}
+	if c.hasBGP() {
+		if err := bgpconfig.SetDefaultConfigValues(&c.BGP); err != nil {
+			return nil, err
 		}
-		err = v.UnmarshalExact(&c)
-		if err != nil {
-			log.Fatal("can't read config file ", path, ", ", err)
+	}
+	return c, nil
+}
+
+func (c *Config) hasBGP() bool {
+	emptyBGPGlobal := &bgpconfig.Global{}
+	return !emptyBGPGlobal.Equal(&c.BGP.Global)
+}
+
+// ReadConfigfileServe reads the config file on each reload request and
+// sends it to bgpConfigCh and configCh. A config failing to be read or
+// validated is rejected and the running one is kept, except on the
+// first read. When watchDelay isn't zero, the file is also reloaded
+// once it has stayed unchanged for watchDelay after a change. The
+// outcome of each read is available from LastReload.
+func ReadConfigfileServe(path, format string, configCh chan *Config, bgpConfigCh chan *bgpconfig.BgpConfigSet, reloadCh chan bool, validate func(*Config) error, watchDelay time.Duration) {
+	read := func() (*Config, error) {
+		c, err := ReadConfigfile(path, format)
+		if err == nil && validate != nil {
+			err = validate(c)
 		}
-		emptyBGPGlobal := &bgpconfig.Global{}
-		if !emptyBGPGlobal.Equal(&c.BGP.Global) {
-			err := bgpconfig.SetDefaultConfigValues(&c.BGP)
-			if err != nil {
-				log.Fatal(err)
-			}
+		return c, err
+	}
+	send := func(c *Config) {
+		if c.hasBGP() {
 			bgpConfigCh <- &c.BGP
 		}
 		configCh <- c
 	}
+
+	<-reloadCh
+	current, err := read()
+	if err != nil {
+		log.Fatalf("can't read config file %s:\n%s", path, err)
+	}
+	setLastReload(&ReloadStatus{
+		Trigger: ReloadTriggerStartup,
+		Result:  ReloadApplied,
+	})
+	send(current)
+
+	var watchCh chan struct{}
+	if watchDelay > 0 {
+		w, err := newFileWatcher(path, watchDelay)
+		if err != nil {
+			log.Fatalf("can't watch config file %s: %s", path, err)
+		}
+		defer w.Close()
+		watchCh = w.C
+	}
+
+	for {
+		var trigger string
+		select {
+		case <-reloadCh:
+			trigger = ReloadTriggerRequest
+		case <-watchCh:
+			trigger = ReloadTriggerFile
+		}
+		fields := log.Fields{
+			"Topic":   "Config",
+			"Trigger": trigger,
+		}
+		c, err := read()
+		if err != nil {
+			setLastReload(&ReloadStatus{
+				Trigger: trigger,
+				Result:  ReloadRejected,
+				Error:   err.Error(),
+			})
+			log.WithFields(fields).Errorf("reject config file %s and keep the running config:\n%s", path, err)
+			continue
+		}
+		s := &ReloadStatus{
+			Trigger: trigger,
+			Result:  ReloadApplied,
+			Changes: Changes(current, c),
+		}
+		// a reload request applies the config even when it is unchanged,
+		// for example to reopen the log file
+		if len(s.Changes) == 0 && trigger == ReloadTriggerFile {
+			s.Result = ReloadUnchanged
+			setLastReload(s)
+			log.WithFields(fields).Debugf("config file %s is unchanged", path)
+			continue
+		}
+		setLastReload(s)
+		if len(s.Changes) == 0 {
+			log.WithFields(fields).Infof("apply config file %s: no changes", path)
+		} else {
+			log.WithFields(fields).Infof("apply config file %s: %s", path, strings.Join(s.Changes, ", "))
+		}
+		current = c
+		send(c)
+	}
 }
 
-func UpdateConfig(curC *Dataplane, newC Dataplane) ([]VirtualNetwork, []VirtualNetwork) {
+func UpdateConfig(curC *Dataplane, newC Dataplane) ([]VirtualNetwork, []VirtualNetwork, []VirtualNetwork) {
 	added := []VirtualNetwork{}
 	deleted := []VirtualNetwork{}
+	updated := []VirtualNetwork{}
 	if curC == nil {
-		return newC.VirtualNetworkList, deleted
+		return newC.VirtualNetworkList, deleted, updated
 	}
 	for _, n := range newC.VirtualNetworkList {
-		if inSlice(n, curC.VirtualNetworkList) < 0 {
+		if idx := inSlice(n, curC.VirtualNetworkList); idx < 0 {
 			added = append(added, n)
+		} else if !n.Equal(&curC.VirtualNetworkList[idx]) {
+			log.WithFields(log.Fields{
+				"Topic": "Config",
+			}).Debugf("Current virtual network config:%v", curC.VirtualNetworkList[idx])
+			log.WithFields(log.Fields{
+				"Topic": "Config",
+			}).Debugf("New virtual network config:%v", n)
+			updated = append(updated, n)
 		}
 	}
 
@@ -65,12 +164,12 @@ func UpdateConfig(curC *Dataplane, newC Dataplane) ([]VirtualNetwork, []VirtualN
 			deleted = append(deleted, n)
 		}
 	}
-	return added, deleted
+	return added, deleted, updated
 }
 
 func inSlice(one VirtualNetwork, list []VirtualNetwork) int {
 	for idx, vn := range list {
-		if vn.RD == one.RD {
+		if vn.Key() == one.Key() {
 			return idx
 		}
 	}
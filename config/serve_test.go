@@ -0,0 +1,61 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	vn1 := VirtualNetwork{
+		RD:               "65000:10",
+		VNI:              10,
+		VtepInterface:    "vtep10",
+		MemberInterfaces: []string{"eth1"},
+	}
+	vn2 := VirtualNetwork{
+		RD:            "65000:20",
+		VNI:           20,
+		VtepInterface: "vtep20",
+	}
+
+	added, deleted, updated := UpdateConfig(nil, Dataplane{VirtualNetworkList: []VirtualNetwork{vn1}})
+	assert.Equal([]VirtualNetwork{vn1}, added)
+	assert.Empty(deleted)
+	assert.Empty(updated)
+
+	cur := &Dataplane{VirtualNetworkList: []VirtualNetwork{vn1}}
+	vn1m := vn1
+	vn1m.MemberInterfaces = []string{"eth1", "eth2"}
+	added, deleted, updated = UpdateConfig(cur, Dataplane{VirtualNetworkList: []VirtualNetwork{vn1m, vn2}})
+	assert.Equal([]VirtualNetwork{vn2}, added)
+	assert.Empty(deleted)
+	assert.Equal([]VirtualNetwork{vn1m}, updated)
+	assert.False(vn1.NeedsRecreate(&vn1m))
+
+	cur = &Dataplane{VirtualNetworkList: []VirtualNetwork{vn1, vn2}}
+	vn2m := vn2
+	vn2m.VNI = 21
+	added, deleted, updated = UpdateConfig(cur, Dataplane{VirtualNetworkList: []VirtualNetwork{vn2m}})
+	assert.Empty(added)
+	assert.Equal([]VirtualNetwork{vn1}, deleted)
+	assert.Equal([]VirtualNetwork{vn2m}, updated)
+	assert.True(vn2.NeedsRecreate(&vn2m))
+}
@@ -0,0 +1,296 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	bgpconfig "github.com/ttsubo/goplane/internal/pkg/config"
+	"github.com/ttsubo/goplane/internal/pkg/table"
+)
+
+// ValidationError is a problem found at Path of the config, such as
+// "dataplane.virtual-network-list[0].rd".
+type ValidationError struct {
+	Path string
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+// ValidationErrors lists all the problems found in a config.
+type ValidationErrors []*ValidationError
+
+func (l ValidationErrors) Error() string {
+	s := make([]string, 0, len(l))
+	for _, e := range l {
+		s = append(s, e.Error())
+	}
+	return strings.Join(s, "\n")
+}
+
+func (l *ValidationErrors) add(path string, format string, args ...interface{}) {
+	*l = append(*l, &ValidationError{
+		Path: path,
+		Err:  fmt.Errorf(format, args...),
+	})
+}
+
+// Validate checks c without touching the system. The dataplane type is
+// checked by the caller, which knows the registered backends. When
+// linkExists isn't nil, it is used to check that the interfaces of the
+// virtual networks exist. It returns ValidationErrors listing all the
+// problems.
+func Validate(c *Config, linkExists func(string) bool) error {
+	var l ValidationErrors
+	validateDataplane(&l, &c.Dataplane, linkExists)
+	validateBGP(&l, &c.BGP)
+	if len(l) > 0 {
+		return l
+	}
+	return nil
+}
+
+// maxVNI is the largest 24-bit VXLAN network identifier. Ethernet tags
+// carry the VNI, so they are limited the same way.
+const maxVNI = 1<<24 - 1
+
+// maxEvi is the largest EVI index, which is the 2-octet assigned number
+// of the auto-derived RD.
+const maxEvi = 1<<16 - 1
+
+func validateRouteTargets(l *ValidationErrors, path string, rts []string, allowAuto bool) {
+	for i, s := range rts {
+		if s == RouteTargetAuto && allowAuto {
+			continue
+		}
+		if _, err := bgp.ParseRouteTarget(s); err != nil {
+			l.add(fmt.Sprintf("%s[%d]", path, i), "invalid route target %q: %s", s, err)
+		}
+	}
+}
+
+func validateZebra(l *ValidationErrors, z *Zebra) {
+	if _, _, err := z.NetworkAddress(); err != nil {
+		l.add("dataplane.zebra.url", "%s", err)
+	}
+	if z.ZapiVersion() != 6 {
+		l.add("dataplane.zebra.version", "unsupported version %d. only 6 is supported", z.Version)
+	}
+	if z.SoftwareName != "" && z.SoftwareName != "frr7.2" {
+		l.add("dataplane.zebra.software-name", "unsupported software name %q", z.SoftwareName)
+	}
+}
+
+func validateDataplane(l *ValidationErrors, d *Dataplane, linkExists func(string) bool) {
+	switch d.Type {
+	case "zebra":
+		validateZebra(l, &d.Zebra)
+	case "ovsdb":
+		if _, _, err := d.OVSDB.NetworkAddress(); err != nil {
+			l.add("dataplane.ovsdb.url", "%s", err)
+		}
+	}
+	// the kernel interprets the protocols up to RTPROT_STATIC
+	if d.RouteProtocol != 0 && d.RouteProtocol <= 4 {
+		l.add("dataplane.route-protocol", "route protocol %d is reserved by the kernel", d.RouteProtocol)
+	}
+	keys := map[string]int{}
+	vnis := map[uint32]int{}
+	vteps := map[string]int{}
+	for i, v := range d.VirtualNetworkList {
+		path := fmt.Sprintf("dataplane.virtual-network-list[%d]", i)
+		if v.RD != "" {
+			if _, err := bgp.ParseRouteDistinguisher(v.RD); err != nil {
+				l.add(path+".rd", "invalid rd %q: %s", v.RD, err)
+			}
+		} else if v.Evi == 0 && v.VNI > maxEvi {
+			l.add(path+".evi", "vni %d doesn't fit in the auto-derived rd. set rd or evi", v.VNI)
+		}
+		if j, ok := keys[v.Key()]; ok {
+			l.add(path, "duplicate virtual network %s, also at index %d", v.Key(), j)
+		} else {
+			keys[v.Key()] = i
+		}
+		if v.VNI == 0 || v.VNI > maxVNI {
+			l.add(path+".vni", "invalid vni %d", v.VNI)
+		} else if j, ok := vnis[v.VNI]; ok {
+			l.add(path+".vni", "duplicate vni %d, also at index %d", v.VNI, j)
+		} else {
+			vnis[v.VNI] = i
+		}
+		if v.Etag > maxVNI {
+			l.add(path+".etag", "invalid etag %d", v.Etag)
+		}
+		if v.VtepInterface == "" {
+			l.add(path+".vtep-interface", "vtep interface is not specified")
+		} else if j, ok := vteps[v.VtepInterface]; ok {
+			l.add(path+".vtep-interface", "duplicate vtep interface %s, also at index %d", v.VtepInterface, j)
+		} else {
+			vteps[v.VtepInterface] = i
+		}
+		switch v.VtepMode {
+		case "", VtepModeKernel:
+		case VtepModeUserspace:
+			if d.Type == "zebra" || d.Type == "ovsdb" {
+				l.add(path+".vtep-mode", "userspace VTEP is not supported by the %s dataplane", d.Type)
+			}
+		default:
+			l.add(path+".vtep-mode", "invalid vtep mode %q", v.VtepMode)
+		}
+		switch v.Encap {
+		case "", EncapVxlan:
+		case EncapGeneve:
+			if d.Type == "zebra" || d.Type == "ovsdb" {
+				l.add(path+".encap", "geneve is not supported by the %s dataplane", d.Type)
+			} else if v.Userspace() {
+				l.add(path+".encap", "geneve is not supported by the userspace VTEP")
+			}
+		default:
+			l.add(path+".encap", "invalid encap %q", v.Encap)
+		}
+		if v.MulticastGroup != "" {
+			if ip := net.ParseIP(v.MulticastGroup); ip == nil || ip.To4() == nil || !ip.IsMulticast() {
+				l.add(path+".multicast-group", "invalid ipv4 multicast group %q", v.MulticastGroup)
+			} else if d.Type == "zebra" || d.Type == "ovsdb" {
+				l.add(path+".multicast-group", "multicast underlay is not supported by the %s dataplane", d.Type)
+			} else if v.Userspace() || v.Geneve() {
+				l.add(path+".multicast-group", "multicast underlay is only supported by the kernel vxlan VTEP")
+			}
+			if v.MulticastInterface == "" {
+				l.add(path+".multicast-interface", "multicast interface is not specified")
+			}
+		} else if v.MulticastInterface != "" {
+			l.add(path+".multicast-interface", "multicast interface is set without multicast group")
+		}
+		for j, addr := range v.GatewayAddresses {
+			if _, _, err := net.ParseCIDR(addr); err != nil {
+				l.add(fmt.Sprintf("%s.gateway-addresses[%d]", path, j), "invalid gateway address %q", addr)
+			}
+		}
+		if v.GatewayMac != "" {
+			if mac, err := net.ParseMAC(v.GatewayMac); err != nil || len(mac) != 6 || mac[0]&1 != 0 {
+				l.add(path+".gateway-mac", "invalid gateway mac %q", v.GatewayMac)
+			}
+		} else if v.Gateway() {
+			l.add(path+".gateway-mac", "gateway mac is not specified")
+		}
+		if v.Gateway() && (d.Type == "zebra" || d.Type == "ovsdb") {
+			l.add(path+".gateway-addresses", "anycast gateway is not supported by the %s dataplane", d.Type)
+		}
+		validateRouteTargets(l, path+".import-rt-list", v.ImportRtList, true)
+		validateRouteTargets(l, path+".export-rt-list", v.ExportRtList, true)
+		if d.Type == "zebra" || d.Type == "ovsdb" {
+			// zebra or the switch learns the local MACs on the ports
+			// set up outside of goplane
+			if len(v.MemberInterfaces) > 0 {
+				l.add(path+".member-interfaces", "member interfaces are not supported by the %s dataplane", d.Type)
+			}
+			if len(v.SniffInterfaces) > 0 {
+				l.add(path+".sniff-interfaces", "sniff interfaces are not supported by the %s dataplane", d.Type)
+			}
+		}
+		if linkExists == nil {
+			continue
+		}
+		for j, ifname := range v.MemberInterfaces {
+			if !linkExists(ifname) {
+				l.add(fmt.Sprintf("%s.member-interfaces[%d]", path, j), "interface %s doesn't exist", ifname)
+			}
+		}
+		for j, ifname := range v.SniffInterfaces {
+			if !linkExists(ifname) {
+				l.add(fmt.Sprintf("%s.sniff-interfaces[%d]", path, j), "interface %s doesn't exist", ifname)
+			}
+		}
+		if v.MulticastInterface != "" && !linkExists(v.MulticastInterface) {
+			l.add(path+".multicast-interface", "interface %s doesn't exist", v.MulticastInterface)
+		}
+	}
+	validateRedistributeNeighbor(l, d, linkExists)
+}
+
+func validateRedistributeNeighbor(l *ValidationErrors, d *Dataplane, linkExists func(string) bool) {
+	r := &d.RedistributeNeighbor
+	if !r.Enabled() {
+		return
+	}
+	if d.Type == "zebra" || d.Type == "ovsdb" {
+		l.add("dataplane.redistribute-neighbor", "redistribute neighbor is not supported by the %s dataplane", d.Type)
+	}
+	if linkExists != nil {
+		for i, ifname := range r.Interfaces {
+			if !linkExists(ifname) {
+				l.add(fmt.Sprintf("dataplane.redistribute-neighbor.interfaces[%d]", i), "interface %s doesn't exist", ifname)
+			}
+		}
+	}
+	for i, s := range r.Communities {
+		if _, err := table.ParseCommunity(s); err != nil {
+			l.add(fmt.Sprintf("dataplane.redistribute-neighbor.communities[%d]", i), "invalid community %q", s)
+		}
+	}
+}
+
+func validateBGP(l *ValidationErrors, c *bgpconfig.BgpConfigSet) {
+	names := map[string]int{}
+	for i, vrf := range c.Vrfs {
+		path := fmt.Sprintf("bgp.vrfs[%d].config", i)
+		if vrf.Config.Name == "" {
+			l.add(path+".name", "vrf name is not specified")
+		} else if j, ok := names[vrf.Config.Name]; ok {
+			l.add(path+".name", "duplicate vrf %s, also at index %d", vrf.Config.Name, j)
+		} else {
+			names[vrf.Config.Name] = i
+		}
+		if _, err := bgp.ParseRouteDistinguisher(vrf.Config.Rd); err != nil {
+			l.add(path+".rd", "invalid rd %q: %s", vrf.Config.Rd, err)
+		}
+		validateRouteTargets(l, path+".import-rt-list", vrf.Config.ImportRtList, false)
+		validateRouteTargets(l, path+".export-rt-list", vrf.Config.ExportRtList, false)
+	}
+
+	rp := bgpconfig.ConfigSetToRoutingPolicy(c)
+	if err := table.NewRoutingPolicy().Reset(rp, nil); err != nil {
+		l.add("bgp.policy-definitions", "%s", err)
+	}
+
+	defined := map[string]bool{}
+	for _, p := range c.PolicyDefinitions {
+		defined[p.Name] = true
+	}
+	validateApplyPolicy := func(path string, a *bgpconfig.ApplyPolicyConfig) {
+		for i, name := range a.ImportPolicyList {
+			if !defined[name] {
+				l.add(fmt.Sprintf("%s.import-policy-list[%d]", path, i), "policy %s isn't defined", name)
+			}
+		}
+		for i, name := range a.ExportPolicyList {
+			if !defined[name] {
+				l.add(fmt.Sprintf("%s.export-policy-list[%d]", path, i), "policy %s isn't defined", name)
+			}
+		}
+	}
+	validateApplyPolicy("bgp.global.apply-policy.config", &c.Global.ApplyPolicy.Config)
+	for i := range c.Neighbors {
+		validateApplyPolicy(fmt.Sprintf("bgp.neighbors[%d].apply-policy.config", i), &c.Neighbors[i].ApplyPolicy.Config)
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	bgpconfig "github.com/ttsubo/goplane/internal/pkg/config"
+)
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	c := &Config{
+		Dataplane: Dataplane{
+			Type: "netlink",
+			VirtualNetworkList: []VirtualNetwork{
+				{
+					RD:               "65000:10",
+					VNI:              10,
+					VtepInterface:    "vtep10",
+					MemberInterfaces: []string{"eth1"},
+					ImportRtList:     []string{"auto"},
+					Encap:            EncapGeneve,
+				},
+				{
+					RD:            "65000:20",
+					VNI:           20,
+					VtepInterface: "vtep20",
+					VtepMode:      VtepModeUserspace,
+				},
+				{
+					RD:                 "65000:30",
+					VNI:                30,
+					VtepInterface:      "vtep30",
+					MulticastGroup:     "239.1.1.30",
+					MulticastInterface: "eth1",
+					GatewayAddresses:   []string{"192.168.30.1/24", "2001:db8:30::1/64"},
+					GatewayMac:         "00:00:5e:00:01:01",
+				},
+			},
+			RedistributeNeighbor: RedistributeNeighbor{
+				Interfaces:  []string{"eth1"},
+				Communities: []string{"65000:100", "no-export"},
+			},
+		},
+	}
+	linkExists := func(name string) bool {
+		return name == "eth1"
+	}
+	assert.Nil(Validate(c, linkExists))
+
+	c.Dataplane.VirtualNetworkList = append(c.Dataplane.VirtualNetworkList, VirtualNetwork{
+		RD:               "65000:10",
+		VNI:              20,
+		Etag:             1 << 24,
+		VtepInterface:    "vtep10",
+		MemberInterfaces: []string{"eth2"},
+		ExportRtList:     []string{"invalid"},
+		MulticastGroup:   "10.0.0.1",
+		GatewayAddresses: []string{"192.168.20.1"},
+	}, VirtualNetwork{
+		RD:                 "invalid",
+		VNI:                40,
+		VtepInterface:      "vtep40",
+		VtepMode:           "invalid",
+		Encap:              "invalid",
+		MulticastInterface: "eth1",
+		GatewayMac:         "01:00:5e:00:01:01",
+	})
+	c.Dataplane.RouteProtocol = 4
+	c.Dataplane.RedistributeNeighbor = RedistributeNeighbor{
+		Interfaces:  []string{"eth1", "eth2"},
+		Communities: []string{"65000:100", "invalid"},
+	}
+	c.BGP.Vrfs = []bgpconfig.Vrf{
+		{
+			Config: bgpconfig.VrfConfig{
+				Name:         "red",
+				Rd:           "65000:100",
+				ImportRtList: []string{"auto"},
+			},
+		},
+	}
+	c.BGP.Global.ApplyPolicy.Config.ImportPolicyList = []string{"undefined"}
+
+	err := Validate(c, linkExists)
+	l, ok := err.(ValidationErrors)
+	assert.True(ok)
+	paths := make([]string, 0, len(l))
+	for _, e := range l {
+		paths = append(paths, e.Path)
+	}
+	assert.Equal([]string{
+		"dataplane.route-protocol",
+		"dataplane.virtual-network-list[3]",
+		"dataplane.virtual-network-list[3].vni",
+		"dataplane.virtual-network-list[3].etag",
+		"dataplane.virtual-network-list[3].vtep-interface",
+		"dataplane.virtual-network-list[3].multicast-group",
+		"dataplane.virtual-network-list[3].multicast-interface",
+		"dataplane.virtual-network-list[3].gateway-addresses[0]",
+		"dataplane.virtual-network-list[3].gateway-mac",
+		"dataplane.virtual-network-list[3].export-rt-list[0]",
+		"dataplane.virtual-network-list[3].member-interfaces[0]",
+		"dataplane.virtual-network-list[4].rd",
+		"dataplane.virtual-network-list[4].vtep-mode",
+		"dataplane.virtual-network-list[4].encap",
+		"dataplane.virtual-network-list[4].multicast-interface",
+		"dataplane.virtual-network-list[4].gateway-mac",
+		"dataplane.redistribute-neighbor.interfaces[1]",
+		"dataplane.redistribute-neighbor.communities[1]",
+		"bgp.vrfs[0].config.import-rt-list[0]",
+		"bgp.global.apply-policy.config.import-policy-list[0]",
+	}, paths)
+}
+
+func TestValidateAutoRd(t *testing.T) {
+	assert := assert.New(t)
+
+	c := &Config{
+		Dataplane: Dataplane{
+			VirtualNetworkList: []VirtualNetwork{
+				{
+					VNI:           10,
+					VtepInterface: "vtep10",
+				},
+				{
+					VNI:           1<<16 | 10,
+					Evi:           20,
+					VtepInterface: "vtep20",
+				},
+			},
+		},
+	}
+	assert.Nil(Validate(c, nil))
+
+	// the VNI would be truncated in the RD, colliding with VNI 10
+	c.Dataplane.VirtualNetworkList[1].Evi = 0
+	err := Validate(c, nil)
+	l, ok := err.(ValidationErrors)
+	assert.True(ok)
+	paths := make([]string, 0, len(l))
+	for _, e := range l {
+		paths = append(paths, e.Path)
+	}
+	assert.Equal([]string{
+		"dataplane.virtual-network-list[1].evi",
+		"dataplane.virtual-network-list[1]",
+	}, paths)
+}
+
+func TestValidateZebra(t *testing.T) {
+	assert := assert.New(t)
+
+	c := &Config{
+		Dataplane: Dataplane{
+			Type: "zebra",
+			VirtualNetworkList: []VirtualNetwork{
+				{
+					RD:            "65000:10",
+					VNI:           10,
+					VtepInterface: "vxlan10",
+				},
+			},
+		},
+	}
+	assert.Nil(Validate(c, nil))
+
+	c.Dataplane.Zebra = Zebra{
+		URL:          "udp:127.0.0.1:2600",
+		Version:      5,
+		SoftwareName: "frr7",
+	}
+	c.Dataplane.VirtualNetworkList[0].MemberInterfaces = []string{"eth1"}
+	c.Dataplane.RedistributeNeighbor.Interfaces = []string{"eth1"}
+	err := Validate(c, nil)
+	l, ok := err.(ValidationErrors)
+	assert.True(ok)
+	paths := make([]string, 0, len(l))
+	for _, e := range l {
+		paths = append(paths, e.Path)
+	}
+	assert.Equal([]string{
+		"dataplane.zebra.url",
+		"dataplane.zebra.version",
+		"dataplane.zebra.software-name",
+		"dataplane.virtual-network-list[0].member-interfaces",
+		"dataplane.redistribute-neighbor",
+	}, paths)
+}
+
+func TestValidateOVSDB(t *testing.T) {
+	assert := assert.New(t)
+
+	c := &Config{
+		Dataplane: Dataplane{
+			Type: "ovsdb",
+			OVSDB: OVSDB{
+				URL: "tcp:127.0.0.1:6640",
+			},
+			VirtualNetworkList: []VirtualNetwork{
+				{
+					RD:              "65000:10",
+					VNI:             10,
+					VtepInterface:   "vxlan10",
+					SniffInterfaces: []string{"eth1"},
+				},
+			},
+		},
+	}
+	err := Validate(c, nil)
+	l, ok := err.(ValidationErrors)
+	assert.True(ok)
+	assert.Len(l, 1)
+	assert.Equal("dataplane.virtual-network-list[0].sniff-interfaces", l[0].Path)
+
+	c.Dataplane.OVSDB.URL = "ssl:127.0.0.1:6640"
+	c.Dataplane.VirtualNetworkList[0].SniffInterfaces = nil
+	err = Validate(c, nil)
+	l, ok = err.(ValidationErrors)
+	assert.True(ok)
+	assert.Len(l, 1)
+	assert.Equal("dataplane.ovsdb.url", l[0].Path)
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+)
+
+// fileWatcher sends to C when the file at path has changed and then
+// stayed unchanged for delay. The directory of the file is watched
+// rather than the file itself, so that files replaced by a rename, as
+// editors and config management tools do, and symlinks swapped to a
+// new target, as Kubernetes does for ConfigMap volumes, are followed.
+type fileWatcher struct {
+	C        chan struct{}
+	w        *fsnotify.Watcher
+	path     string
+	realPath string
+	delay    time.Duration
+}
+
+func newFileWatcher(path string, delay time.Duration) (*fileWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	fw := &fileWatcher{
+		C:     make(chan struct{}, 1),
+		w:     w,
+		path:  filepath.Clean(path),
+		delay: delay,
+	}
+	if err := w.Add(filepath.Dir(fw.path)); err != nil {
+		w.Close()
+		return nil, err
+	}
+	fw.followSymlink()
+	go fw.loop()
+	return fw, nil
+}
+
+// followSymlink watches the directory of the file the path points to
+// and reports whether it has changed.
+func (fw *fileWatcher) followSymlink() bool {
+	realPath, err := filepath.EvalSymlinks(fw.path)
+	if err != nil || realPath == fw.realPath {
+		return false
+	}
+	fw.realPath = realPath
+	if dir := filepath.Dir(realPath); dir != filepath.Dir(fw.path) {
+		if err := fw.w.Add(dir); err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Config",
+				"Key":   dir,
+			}).Warnf("can't watch the directory of config file %s: %s", fw.path, err)
+		}
+	}
+	return true
+}
+
+func (fw *fileWatcher) changed(e fsnotify.Event) bool {
+	if e.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+		name := filepath.Clean(e.Name)
+		if name == fw.path || name == fw.realPath {
+			return true
+		}
+	}
+	return fw.followSymlink()
+}
+
+func (fw *fileWatcher) loop() {
+	var timer <-chan time.Time
+	for {
+		select {
+		case e, ok := <-fw.w.Events:
+			if !ok {
+				return
+			}
+			if fw.changed(e) {
+				timer = time.After(fw.delay)
+			}
+		case err, ok := <-fw.w.Errors:
+			if !ok {
+				return
+			}
+			log.WithFields(log.Fields{
+				"Topic": "Config",
+				"Key":   fw.path,
+			}).Warnf("config file watch error: %s", err)
+		case <-timer:
+			timer = nil
+			select {
+			case fw.C <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// Close stops watching the file.
+func (fw *fileWatcher) Close() error {
+	return fw.w.Close()
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileWatcher(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "goplane-config")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"a", "b"} {
+		assert.Nil(os.Mkdir(filepath.Join(dir, name), 0755))
+		assert.Nil(ioutil.WriteFile(filepath.Join(dir, name, "goplane.conf"), []byte(name), 0644))
+	}
+	path := filepath.Join(dir, "goplane.conf")
+	assert.Nil(os.Symlink(filepath.Join(dir, "a", "goplane.conf"), path))
+
+	delay := 100 * time.Millisecond
+	w, err := newFileWatcher(path, delay)
+	assert.Nil(err)
+	defer w.Close()
+
+	notified := func() bool {
+		select {
+		case <-w.C:
+			return true
+		case <-time.After(5 * delay):
+			return false
+		}
+	}
+
+	// writes in a row are notified once
+	for i := 0; i < 3; i++ {
+		assert.Nil(ioutil.WriteFile(filepath.Join(dir, "a", "goplane.conf"), []byte("a"), 0644))
+		time.Sleep(delay / 4)
+	}
+	assert.True(notified())
+	assert.False(notified())
+
+	// other files are ignored
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "other.conf"), []byte("other"), 0644))
+	assert.False(notified())
+
+	// the symlink is swapped to a new target
+	tmp := filepath.Join(dir, "goplane.conf.tmp")
+	assert.Nil(os.Symlink(filepath.Join(dir, "b", "goplane.conf"), tmp))
+	assert.Nil(os.Rename(tmp, path))
+	assert.True(notified())
+
+	// the file is replaced by a rename
+	tmp = filepath.Join(dir, "b", "goplane.conf.tmp")
+	assert.Nil(ioutil.WriteFile(tmp, []byte("b"), 0644))
+	assert.Nil(os.Rename(tmp, filepath.Join(dir, "b", "goplane.conf")))
+	assert.True(notified())
+}
@@ -0,0 +1,229 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataplane
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/ttsubo/goplane/config"
+)
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificate found in %s", path)
+	}
+	return pool, nil
+}
+
+// NewServerCredentials returns the TLS credentials of the API
+// listeners, or nil when TLS isn't configured.
+func NewServerCredentials(c *config.APITLS) (credentials.TransportCredentials, error) {
+	if c.CertFile == "" && c.KeyFile == "" {
+		if c.ClientCAFile != "" {
+			return nil, fmt.Errorf("client CA requires a server certificate and key")
+		}
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %s", err)
+	}
+	tc := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.ClientCAFile != "" {
+		pool, err := loadCertPool(c.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client CA: %s", err)
+		}
+		tc.ClientCAs = pool
+		tc.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return credentials.NewTLS(tc), nil
+}
+
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (t *tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+func (t *tokenCredentials) RequireTransportSecurity() bool {
+	return t.secure
+}
+
+// NewClientDialOptions returns the dial options matching the TLS and
+// token settings of an API server.
+func NewClientDialOptions(c *config.APIClient) ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+	if c.TLS {
+		tc := &tls.Config{
+			ServerName: c.ServerName,
+			MinVersion: tls.VersionTLS12,
+		}
+		if c.CAFile != "" {
+			pool, err := loadCertPool(c.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load CA: %s", err)
+			}
+			tc.RootCAs = pool
+		}
+		if c.CertFile != "" || c.KeyFile != "" {
+			cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load client certificate: %s", err)
+			}
+			tc.Certificates = []tls.Certificate{cert}
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tc)))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	if c.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(&tokenCredentials{
+			token:  c.Token,
+			secure: c.TLS,
+		}))
+	}
+	return opts, nil
+}
+
+// Authorizer checks the bearer token of each API call against the
+// configured tokens and the methods they may call. Every call is
+// allowed while no token is configured.
+type Authorizer struct {
+	mu     sync.RWMutex
+	tokens []config.APIToken
+}
+
+func NewAuthorizer() *Authorizer {
+	return &Authorizer{}
+}
+
+// SetTokens replaces the accepted tokens. It can be called on a config
+// reload.
+func (a *Authorizer) SetTokens(tokens []config.APIToken) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tokens = tokens
+}
+
+func matchMethod(patterns []string, method string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if strings.HasSuffix(p, "*") {
+			if strings.HasPrefix(method, strings.TrimSuffix(p, "*")) {
+				return true
+			}
+		} else if p == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Authorizer) authorize(ctx context.Context, method string) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if len(a.tokens) == 0 {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	var token string
+	for _, v := range md.Get("authorization") {
+		if strings.HasPrefix(v, "Bearer ") {
+			token = strings.TrimPrefix(v, "Bearer ")
+			break
+		}
+	}
+	if token == "" {
+		return status.Error(codes.Unauthenticated, "bearer token is required")
+	}
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) != 1 {
+			continue
+		}
+		if !matchMethod(t.Methods, method) {
+			break
+		}
+		return nil
+	}
+	log.WithFields(log.Fields{
+		"Topic":  "grpc",
+		"Method": method,
+	}).Warn("permission denied")
+	return status.Error(codes.PermissionDenied, "permission denied")
+}
+
+func (a *Authorizer) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := a.authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (a *Authorizer) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// ServerOptions returns the options of an API server enforcing c and
+// the tokens of a.
+func (a *Authorizer) ServerOptions(c *config.APITLS) ([]grpc.ServerOption, error) {
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(a.UnaryInterceptor()),
+		grpc.StreamInterceptor(a.StreamInterceptor()),
+	}
+	creds, err := NewServerCredentials(c)
+	if err != nil {
+		return nil, err
+	}
+	if creds != nil {
+		opts = append(opts, grpc.Creds(creds))
+	}
+	return opts, nil
+}
@@ -0,0 +1,57 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataplane
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/ttsubo/goplane/config"
+)
+
+func TestAuthorizer(t *testing.T) {
+	assert := assert.New(t)
+
+	withToken := func(token string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	}
+	code := func(err error) codes.Code {
+		return status.Code(err)
+	}
+
+	a := NewAuthorizer()
+	assert.Nil(a.authorize(context.Background(), "/gobgpapi.GobgpApi/AddPath"))
+
+	a.SetTokens([]config.APIToken{
+		{Token: "admin"},
+		{Token: "viewer", Methods: []string{"/gobgpapi.GobgpApi/List*", "/goplaneapi.GoplaneApi/GetFibDiff"}},
+	})
+	assert.Equal(codes.Unauthenticated, code(a.authorize(context.Background(), "/gobgpapi.GobgpApi/AddPath")))
+	assert.Equal(codes.PermissionDenied, code(a.authorize(withToken("unknown"), "/gobgpapi.GobgpApi/ListPeer")))
+	assert.Nil(a.authorize(withToken("admin"), "/gobgpapi.GobgpApi/AddPath"))
+	assert.Nil(a.authorize(withToken("viewer"), "/gobgpapi.GobgpApi/ListPeer"))
+	assert.Nil(a.authorize(withToken("viewer"), "/goplaneapi.GoplaneApi/GetFibDiff"))
+	assert.Equal(codes.PermissionDenied, code(a.authorize(withToken("viewer"), "/gobgpapi.GobgpApi/AddPath")))
+	assert.Equal(codes.PermissionDenied, code(a.authorize(withToken("viewer"), "/goplaneapi.GoplaneApi/GetFibDiffs")))
+
+	a.SetTokens(nil)
+	assert.Nil(a.authorize(context.Background(), "/gobgpapi.GobgpApi/AddPath"))
+}
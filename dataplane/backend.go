@@ -0,0 +1,86 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataplane
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ttsubo/goplane/config"
+	bgpserver "github.com/ttsubo/goplane/pkg/server"
+)
+
+// Options are the settings a backend takes from the command line.
+type Options struct {
+	// GrpcHost is the address of the gobgpd API.
+	GrpcHost string
+	// BgpServer is the embedded BGP server. It is nil in remote mode.
+	BgpServer *bgpserver.BgpServer
+	// Restarting is true on a graceful restart.
+	Restarting bool
+}
+
+// Backend creates the Dataplaner of a dataplane type.
+type Backend func(c *config.Config, o *Options) (Dataplaner, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]Backend{}
+)
+
+// RegisterBackend makes b available as the dataplane type name. It is
+// called from the init function of the package implementing the
+// backend, and panics when name is registered twice.
+func RegisterBackend(name string, b Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if _, ok := backends[name]; ok {
+		panic(fmt.Sprintf("dataplane backend %s is registered twice", name))
+	}
+	backends[name] = b
+}
+
+// Backends returns the registered dataplane types.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	l := make([]string, 0, len(backends))
+	for name := range backends {
+		l = append(l, name)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// HasBackend reports whether the dataplane type name is registered.
+func HasBackend(name string) bool {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	_, ok := backends[name]
+	return ok
+}
+
+// New creates the Dataplaner of the dataplane type in c.
+func New(c *config.Config, o *Options) (Dataplaner, error) {
+	backendsMu.RLock()
+	b, ok := backends[c.Dataplane.Type]
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("invalid dataplane type %q. available types: %v", c.Dataplane.Type, Backends())
+	}
+	return b(c, o)
+}
@@ -0,0 +1,140 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataplane
+
+import (
+	"net"
+	"time"
+
+	"github.com/ttsubo/goplane/config"
+)
+
+type Dataplaner interface {
+	Serve() error
+	AddVirtualNetwork(config.VirtualNetwork) error
+	DeleteVirtualNetwork(config.VirtualNetwork) error
+	UpdateVirtualNetwork(config.VirtualNetwork) error
+	ListVirtualNetwork() ([]config.VirtualNetwork, error)
+	GetVirtualNetwork(name string) (*VirtualNetworkState, error)
+	GetFibDiff() ([]FibDiff, error)
+	GetStatistics() (*Statistics, error)
+	// ClearDuplicateMac unfreezes mac in the virtual network name, or
+	// all its frozen MACs when mac is nil.
+	ClearDuplicateMac(name string, mac net.HardwareAddr) error
+	// Shutdown stops all the virtual networks. It stops installing
+	// the best paths first, so that the withdrawals caused by stopping
+	// BGP don't remove the forwarding state. When flush is true, the
+	// routes of the virtual networks are withdrawn and the devices and
+	// kernel routes created by the dataplane are deleted. Otherwise
+	// they are kept for a graceful restart.
+	Shutdown(flush bool) error
+}
+
+// MacEntry is a MAC address learned on a local sniff interface.
+type MacEntry struct {
+	Mac net.HardwareAddr
+	IP  net.IP
+}
+
+// FdbEntry is a remote MAC address installed toward a VTEP.
+type FdbEntry struct {
+	Mac  net.HardwareAddr
+	Vtep net.IP
+}
+
+// DuplicateMac is a MAC frozen by the duplicate MAC detection. Vtep is
+// where the MAC was last seen, or nil for the local host.
+type DuplicateMac struct {
+	Mac    net.HardwareAddr
+	Vtep   net.IP
+	Moves  int
+	Frozen time.Time
+}
+
+type VirtualNetworkStatus int
+
+const (
+	VN_STATUS_STARTING VirtualNetworkStatus = iota
+	VN_STATUS_RUNNING
+	VN_STATUS_FAILED
+	VN_STATUS_STOPPED
+)
+
+func (s VirtualNetworkStatus) String() string {
+	switch s {
+	case VN_STATUS_STARTING:
+		return "starting"
+	case VN_STATUS_RUNNING:
+		return "running"
+	case VN_STATUS_FAILED:
+		return "failed"
+	case VN_STATUS_STOPPED:
+		return "stopped"
+	}
+	return "unknown"
+}
+
+type VirtualNetworkState struct {
+	Config      config.VirtualNetwork
+	RD          string
+	Bridge      string
+	Vtep        string
+	RemoteVteps []string
+	LocalMacs   []MacEntry
+	RemoteFdb   []FdbEntry
+	Status      VirtualNetworkStatus
+	// LastError is the error the virtual network last failed with.
+	// It is kept after the virtual network recovers.
+	LastError string
+	Restarts  uint32
+	// DuplicateMacs are the MACs frozen by the duplicate MAC detection.
+	DuplicateMacs []DuplicateMac
+}
+
+type FibDiffType int
+
+const (
+	FIB_DIFF_MISSING FibDiffType = iota
+	FIB_DIFF_STALE
+	FIB_DIFF_MISMATCH
+)
+
+type FibDiff struct {
+	Type           FibDiffType
+	Prefix         string
+	BgpNexthops    []net.IP
+	KernelNexthops []net.IP
+}
+
+// VirtualNetworkStatistics holds counters of a virtual network.
+// QueueDepths maps the name of an internal channel to the number of
+// queued events.
+type VirtualNetworkStatistics struct {
+	Name         string
+	VNI          uint32
+	FdbEntries   int
+	RemoteVteps  int
+	FloodPackets uint64
+	FloodBytes   uint64
+	QueueDepths  map[string]int
+}
+
+type Statistics struct {
+	RoutesInstalled int
+	RibErrors       uint64
+	QueueDepths     map[string]int
+	VirtualNetworks []VirtualNetworkStatistics
+}
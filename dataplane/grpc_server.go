@@ -0,0 +1,471 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataplane
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	api "github.com/ttsubo/goplane/api"
+	"github.com/ttsubo/goplane/config"
+	"github.com/ttsubo/goplane/logging"
+)
+
+type Server struct {
+	mu        sync.RWMutex
+	dataplane Dataplaner
+	reload    func() error
+	validate  func([]config.VirtualNetwork) error
+	// the keys of the virtual networks of the config file, which are
+	// changed only by reloading it
+	configVNs map[string]bool
+}
+
+func NewServer() *Server {
+	return &Server{}
+}
+
+// Register adds the goplane API to g. It can be used to share the
+// gobgp API listener.
+func (s *Server) Register(g *grpc.Server) {
+	api.RegisterGoplaneApiServer(g, s)
+}
+
+func (s *Server) SetDataplane(d Dataplaner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dataplane = d
+}
+
+// SetReloadFunc sets the function called to reload the config file.
+func (s *Server) SetReloadFunc(f func() error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reload = f
+}
+
+// SetValidateFunc sets the function checking the virtual networks of
+// the dataplane as they would be after a change through the API.
+func (s *Server) SetValidateFunc(f func([]config.VirtualNetwork) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.validate = f
+}
+
+// SetConfigVirtualNetworks sets the virtual networks of the config
+// file. The API doesn't update or delete them, so that the next reload
+// doesn't undo the change.
+func (s *Server) SetConfigVirtualNetworks(l []config.VirtualNetwork) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configVNs = make(map[string]bool, len(l))
+	for _, c := range l {
+		s.configVNs[c.Key()] = true
+	}
+}
+
+func (s *Server) isConfigVirtualNetwork(key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.configVNs[key]
+}
+
+// validateVirtualNetwork checks c with the other virtual networks of d.
+func (s *Server) validateVirtualNetwork(d Dataplaner, c config.VirtualNetwork) error {
+	s.mu.RLock()
+	validate := s.validate
+	s.mu.RUnlock()
+	if validate == nil {
+		return nil
+	}
+	l, err := d.ListVirtualNetwork()
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	vns := make([]config.VirtualNetwork, 0, len(l)+1)
+	for _, v := range l {
+		if v.Key() != c.Key() {
+			vns = append(vns, v)
+		}
+	}
+	if err := validate(append(vns, c)); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
+
+func (s *Server) getDataplane() (Dataplaner, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.dataplane == nil {
+		return nil, status.Error(codes.Unavailable, "dataplane is not running")
+	}
+	return s.dataplane, nil
+}
+
+// Serve runs the goplane API on its own listeners.
+func (s *Server) Serve(hosts string, opts []grpc.ServerOption) error {
+	g := grpc.NewServer(opts...)
+	s.Register(g)
+
+	var wg sync.WaitGroup
+	l := []net.Listener{}
+	var err error
+	for _, host := range strings.Split(hosts, ",") {
+		var lis net.Listener
+		lis, err = net.Listen("tcp", host)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "grpc",
+				"Key":   host,
+				"Error": err,
+			}).Warn("listen failed")
+			break
+		}
+		l = append(l, lis)
+	}
+	if err != nil {
+		for _, lis := range l {
+			lis.Close()
+		}
+		return err
+	}
+
+	wg.Add(len(l))
+	serve := func(lis net.Listener) {
+		defer wg.Done()
+		err := g.Serve(lis)
+		log.WithFields(log.Fields{
+			"Topic": "grpc",
+			"Key":   lis,
+			"Error": err,
+		}).Warn("accept failed")
+	}
+
+	for _, lis := range l {
+		go serve(lis)
+	}
+	wg.Wait()
+	return nil
+}
+
+func NewVirtualNetworkFromAPIStruct(a *api.VirtualNetwork) (config.VirtualNetwork, error) {
+	if a == nil {
+		return config.VirtualNetwork{}, fmt.Errorf("virtual network is not specified")
+	}
+	if a.Evi > 0xffff {
+		return config.VirtualNetwork{}, fmt.Errorf("invalid evi: %d", a.Evi)
+	}
+	if a.VxlanPort > 0xffff {
+		return config.VirtualNetwork{}, fmt.Errorf("invalid vxlan port: %d", a.VxlanPort)
+	}
+	if a.VtepInterface == "" {
+		return config.VirtualNetwork{}, fmt.Errorf("vtep interface is not specified")
+	}
+	return config.VirtualNetwork{
+		RD:                 a.Rd,
+		Evi:                uint16(a.Evi),
+		VNI:                a.Vni,
+		VxlanPort:          uint16(a.VxlanPort),
+		VtepInterface:      a.VtepInterface,
+		VtepMode:           a.VtepMode,
+		Encap:              a.Encap,
+		Etag:               a.Etag,
+		SniffInterfaces:    a.SniffInterfaces,
+		MemberInterfaces:   a.MemberInterfaces,
+		ImportRtList:       a.ImportRt,
+		ExportRtList:       a.ExportRt,
+		MulticastGroup:     a.MulticastGroup,
+		MulticastInterface: a.MulticastInterface,
+		GatewayAddresses:   a.GatewayAddresses,
+		GatewayMac:         a.GatewayMac,
+	}, nil
+}
+
+func NewAPIVirtualNetworkFromConfigStruct(c *config.VirtualNetwork) *api.VirtualNetwork {
+	return &api.VirtualNetwork{
+		Rd:                 c.RD,
+		Evi:                uint32(c.Evi),
+		Vni:                c.VNI,
+		VxlanPort:          uint32(c.VxlanPort),
+		VtepInterface:      c.VtepInterface,
+		VtepMode:           c.VtepMode,
+		Encap:              c.Encap,
+		Etag:               c.Etag,
+		SniffInterfaces:    c.SniffInterfaces,
+		MemberInterfaces:   c.MemberInterfaces,
+		ImportRt:           c.ImportRtList,
+		ExportRt:           c.ExportRtList,
+		MulticastGroup:     c.MulticastGroup,
+		MulticastInterface: c.MulticastInterface,
+		GatewayAddresses:   c.GatewayAddresses,
+		GatewayMac:         c.GatewayMac,
+	}
+}
+
+func NewAPIVirtualNetworkStateFromStruct(s *VirtualNetworkState) *api.VirtualNetworkState {
+	localMacs := make([]*api.MacEntry, 0, len(s.LocalMacs))
+	for _, e := range s.LocalMacs {
+		m := &api.MacEntry{
+			Mac: e.Mac.String(),
+		}
+		if e.IP != nil {
+			m.Ip = e.IP.String()
+		}
+		localMacs = append(localMacs, m)
+	}
+	remoteFdb := make([]*api.FdbEntry, 0, len(s.RemoteFdb))
+	for _, e := range s.RemoteFdb {
+		remoteFdb = append(remoteFdb, &api.FdbEntry{
+			Mac:  e.Mac.String(),
+			Vtep: e.Vtep.String(),
+		})
+	}
+	duplicateMacs := make([]*api.DuplicateMac, 0, len(s.DuplicateMacs))
+	for _, e := range s.DuplicateMacs {
+		m := &api.DuplicateMac{
+			Mac:   e.Mac.String(),
+			Moves: uint32(e.Moves),
+		}
+		if e.Vtep != nil {
+			m.Vtep = e.Vtep.String()
+		}
+		m.Frozen, _ = ptypes.TimestampProto(e.Frozen)
+		duplicateMacs = append(duplicateMacs, m)
+	}
+	return &api.VirtualNetworkState{
+		Name:          s.Config.Key(),
+		Rd:            s.RD,
+		Bridge:        s.Bridge,
+		Vtep:          s.Vtep,
+		RemoteVteps:   s.RemoteVteps,
+		LocalMacs:     localMacs,
+		RemoteFdb:     remoteFdb,
+		Status:        api.VirtualNetworkState_Status(s.Status),
+		LastError:     s.LastError,
+		Restarts:      s.Restarts,
+		DuplicateMacs: duplicateMacs,
+	}
+}
+
+func (s *Server) AddVirtualNetwork(ctx context.Context, r *api.AddVirtualNetworkRequest) (*empty.Empty, error) {
+	d, err := s.getDataplane()
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewVirtualNetworkFromAPIStruct(r.VirtualNetwork)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if _, err := d.GetVirtualNetwork(c.Key()); err == nil {
+		return nil, status.Errorf(codes.AlreadyExists, "VirtualNetwork %s already exists", c.Key())
+	}
+	if err := s.validateVirtualNetwork(d, c); err != nil {
+		return nil, err
+	}
+	if err := d.AddVirtualNetwork(c); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &empty.Empty{}, nil
+}
+
+func (s *Server) DeleteVirtualNetwork(ctx context.Context, r *api.DeleteVirtualNetworkRequest) (*empty.Empty, error) {
+	d, err := s.getDataplane()
+	if err != nil {
+		return nil, err
+	}
+	st, err := d.GetVirtualNetwork(r.Name)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if s.isConfigVirtualNetwork(r.Name) {
+		return nil, status.Errorf(codes.FailedPrecondition, "VirtualNetwork %s is in the config file. delete it there", r.Name)
+	}
+	if err := d.DeleteVirtualNetwork(st.Config); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &empty.Empty{}, nil
+}
+
+func (s *Server) UpdateVirtualNetwork(ctx context.Context, r *api.UpdateVirtualNetworkRequest) (*empty.Empty, error) {
+	d, err := s.getDataplane()
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewVirtualNetworkFromAPIStruct(r.VirtualNetwork)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if _, err := d.GetVirtualNetwork(c.Key()); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if s.isConfigVirtualNetwork(c.Key()) {
+		return nil, status.Errorf(codes.FailedPrecondition, "VirtualNetwork %s is in the config file. update it there", c.Key())
+	}
+	if err := s.validateVirtualNetwork(d, c); err != nil {
+		return nil, err
+	}
+	if err := d.UpdateVirtualNetwork(c); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &empty.Empty{}, nil
+}
+
+func (s *Server) ListVirtualNetwork(r *api.ListVirtualNetworkRequest, stream api.GoplaneApi_ListVirtualNetworkServer) error {
+	d, err := s.getDataplane()
+	if err != nil {
+		return err
+	}
+	l, err := d.ListVirtualNetwork()
+	if err != nil {
+		return err
+	}
+	for _, c := range l {
+		if err := stream.Send(&api.ListVirtualNetworkResponse{VirtualNetwork: NewAPIVirtualNetworkFromConfigStruct(&c)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) GetVirtualNetwork(ctx context.Context, r *api.GetVirtualNetworkRequest) (*api.GetVirtualNetworkResponse, error) {
+	d, err := s.getDataplane()
+	if err != nil {
+		return nil, err
+	}
+	st, err := d.GetVirtualNetwork(r.Name)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &api.GetVirtualNetworkResponse{
+		VirtualNetwork: NewAPIVirtualNetworkFromConfigStruct(&st.Config),
+		State:          NewAPIVirtualNetworkStateFromStruct(st),
+	}, nil
+}
+
+func (s *Server) ClearDuplicateMac(ctx context.Context, r *api.ClearDuplicateMacRequest) (*empty.Empty, error) {
+	d, err := s.getDataplane()
+	if err != nil {
+		return nil, err
+	}
+	var mac net.HardwareAddr
+	if r.Mac != "" {
+		mac, err = net.ParseMAC(r.Mac)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	if _, err := d.GetVirtualNetwork(r.Name); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &empty.Empty{}, d.ClearDuplicateMac(r.Name, mac)
+}
+
+func ipsToStrings(l []net.IP) []string {
+	s := make([]string, 0, len(l))
+	for _, ip := range l {
+		s = append(s, ip.String())
+	}
+	return s
+}
+
+func (s *Server) GetFibDiff(ctx context.Context, r *api.GetFibDiffRequest) (*api.GetFibDiffResponse, error) {
+	d, err := s.getDataplane()
+	if err != nil {
+		return nil, err
+	}
+	l, err := d.GetFibDiff()
+	if err != nil {
+		return nil, err
+	}
+	diffs := make([]*api.FibDiff, 0, len(l))
+	for _, diff := range l {
+		diffs = append(diffs, &api.FibDiff{
+			Type:           api.FibDiff_Type(diff.Type),
+			Prefix:         diff.Prefix,
+			BgpNexthops:    ipsToStrings(diff.BgpNexthops),
+			KernelNexthops: ipsToStrings(diff.KernelNexthops),
+		})
+	}
+	return &api.GetFibDiffResponse{Diffs: diffs}, nil
+}
+
+func (s *Server) ReloadConfig(ctx context.Context, r *api.ReloadConfigRequest) (*empty.Empty, error) {
+	s.mu.RLock()
+	reload := s.reload
+	s.mu.RUnlock()
+	if reload == nil {
+		return nil, status.Error(codes.Unimplemented, "config reload is not supported")
+	}
+	return &empty.Empty{}, reload()
+}
+
+var reloadTriggers = map[string]api.GetReloadStatusResponse_Trigger{
+	config.ReloadTriggerStartup: api.GetReloadStatusResponse_STARTUP,
+	config.ReloadTriggerRequest: api.GetReloadStatusResponse_REQUEST,
+	config.ReloadTriggerFile:    api.GetReloadStatusResponse_FILE,
+}
+
+var reloadResults = map[string]api.GetReloadStatusResponse_Result{
+	config.ReloadApplied:   api.GetReloadStatusResponse_APPLIED,
+	config.ReloadRejected:  api.GetReloadStatusResponse_REJECTED,
+	config.ReloadUnchanged: api.GetReloadStatusResponse_UNCHANGED,
+}
+
+func (s *Server) GetReloadStatus(ctx context.Context, r *api.GetReloadStatusRequest) (*api.GetReloadStatusResponse, error) {
+	st := config.LastReload()
+	if st == nil {
+		return nil, status.Error(codes.Unavailable, "config file is not read yet")
+	}
+	t, err := ptypes.TimestampProto(st.Time)
+	if err != nil {
+		return nil, err
+	}
+	return &api.GetReloadStatusResponse{
+		Time:    t,
+		Trigger: reloadTriggers[st.Trigger],
+		Result:  reloadResults[st.Result],
+		Error:   st.Error,
+		Changes: st.Changes,
+	}, nil
+}
+
+func (s *Server) GetLogLevel(ctx context.Context, r *api.GetLogLevelRequest) (*api.GetLogLevelResponse, error) {
+	level, topics := logging.Levels()
+	return &api.GetLogLevelResponse{Level: level, Topics: topics}, nil
+}
+
+func (s *Server) SetLogLevel(ctx context.Context, r *api.SetLogLevelRequest) (*empty.Empty, error) {
+	if err := logging.SetLevel(r.Topic, r.Level); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if r.Topic == "" {
+		log.Infof("log level is changed to %s", r.Level)
+	} else {
+		log.Infof("log level of %s is changed to %s", r.Topic, r.Level)
+	}
+	return &empty.Empty{}, nil
+}
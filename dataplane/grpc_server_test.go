@@ -0,0 +1,159 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataplane
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	api "github.com/ttsubo/goplane/api"
+	"github.com/ttsubo/goplane/config"
+)
+
+// fakeDataplane keeps the virtual networks in a map.
+type fakeDataplane struct {
+	Dataplaner
+	vns map[string]config.VirtualNetwork
+}
+
+func newFakeDataplane() *fakeDataplane {
+	return &fakeDataplane{vns: map[string]config.VirtualNetwork{}}
+}
+
+func (d *fakeDataplane) AddVirtualNetwork(c config.VirtualNetwork) error {
+	if _, ok := d.vns[c.Key()]; ok {
+		return fmt.Errorf("VirtualNetwork %s already exists", c.Key())
+	}
+	d.vns[c.Key()] = c
+	return nil
+}
+
+func (d *fakeDataplane) DeleteVirtualNetwork(c config.VirtualNetwork) error {
+	delete(d.vns, c.Key())
+	return nil
+}
+
+func (d *fakeDataplane) UpdateVirtualNetwork(c config.VirtualNetwork) error {
+	d.vns[c.Key()] = c
+	return nil
+}
+
+func (d *fakeDataplane) ListVirtualNetwork() ([]config.VirtualNetwork, error) {
+	l := make([]config.VirtualNetwork, 0, len(d.vns))
+	for _, c := range d.vns {
+		l = append(l, c)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Key() < l[j].Key()
+	})
+	return l, nil
+}
+
+func (d *fakeDataplane) GetVirtualNetwork(name string) (*VirtualNetworkState, error) {
+	c, ok := d.vns[name]
+	if !ok {
+		return nil, fmt.Errorf("VirtualNetwork %s doesn't exist", name)
+	}
+	return &VirtualNetworkState{Config: c}, nil
+}
+
+func TestVirtualNetworkAPIStruct(t *testing.T) {
+	assert := assert.New(t)
+
+	c := config.VirtualNetwork{
+		RD:               "65000:10",
+		VNI:              10,
+		VxlanPort:        4789,
+		VtepInterface:    "vtep10",
+		Etag:             10,
+		SniffInterfaces:  []string{"eth1"},
+		MemberInterfaces: []string{"eth1"},
+		ImportRtList:     []string{"auto"},
+		ExportRtList:     []string{"65000:10"},
+	}
+	a := NewAPIVirtualNetworkFromConfigStruct(&c)
+	n, err := NewVirtualNetworkFromAPIStruct(a)
+	assert.Nil(err)
+	assert.True(c.Equal(&n))
+
+	_, err = NewVirtualNetworkFromAPIStruct(&api.VirtualNetwork{VtepInterface: "vtep10", VxlanPort: 1 << 16})
+	assert.NotNil(err)
+	_, err = NewVirtualNetworkFromAPIStruct(&api.VirtualNetwork{Vni: 10})
+	assert.NotNil(err)
+	_, err = NewVirtualNetworkFromAPIStruct(nil)
+	assert.NotNil(err)
+}
+
+func TestVirtualNetworkService(t *testing.T) {
+	assert := assert.New(t)
+
+	s := NewServer()
+	ctx := context.Background()
+	vn := &api.VirtualNetwork{Rd: "65000:10", Vni: 10, VtepInterface: "vtep10"}
+	_, err := s.AddVirtualNetwork(ctx, &api.AddVirtualNetworkRequest{VirtualNetwork: vn})
+	assert.Equal(codes.Unavailable, status.Code(err))
+
+	d := newFakeDataplane()
+	s.SetDataplane(d)
+	s.SetValidateFunc(func(l []config.VirtualNetwork) error {
+		c := &config.Config{}
+		c.Dataplane.VirtualNetworkList = l
+		return config.Validate(c, nil)
+	})
+	_, err = s.AddVirtualNetwork(ctx, &api.AddVirtualNetworkRequest{VirtualNetwork: vn})
+	assert.Nil(err)
+	_, err = s.AddVirtualNetwork(ctx, &api.AddVirtualNetworkRequest{VirtualNetwork: vn})
+	assert.Equal(codes.AlreadyExists, status.Code(err))
+
+	// the new virtual network is validated with the others
+	for _, v := range []*api.VirtualNetwork{
+		{Rd: "invalid", Vni: 20, VtepInterface: "vtep20"},
+		{Rd: "65000:20", Vni: 10, VtepInterface: "vtep20"},
+		{Vni: 1 << 16, VtepInterface: "vtep20"},
+	} {
+		_, err = s.AddVirtualNetwork(ctx, &api.AddVirtualNetworkRequest{VirtualNetwork: v})
+		assert.Equal(codes.InvalidArgument, status.Code(err))
+	}
+	assert.Len(d.vns, 1)
+
+	_, err = s.UpdateVirtualNetwork(ctx, &api.UpdateVirtualNetworkRequest{VirtualNetwork: &api.VirtualNetwork{Rd: "65000:20", Vni: 20, VtepInterface: "vtep20"}})
+	assert.Equal(codes.NotFound, status.Code(err))
+	_, err = s.UpdateVirtualNetwork(ctx, &api.UpdateVirtualNetworkRequest{VirtualNetwork: &api.VirtualNetwork{Rd: "65000:10", Vni: 1 << 24, VtepInterface: "vtep10"}})
+	assert.Equal(codes.InvalidArgument, status.Code(err))
+	_, err = s.UpdateVirtualNetwork(ctx, &api.UpdateVirtualNetworkRequest{VirtualNetwork: &api.VirtualNetwork{Rd: "65000:10", Vni: 11, VtepInterface: "vtep10"}})
+	assert.Nil(err)
+	assert.Equal(uint32(11), d.vns["65000:10"].VNI)
+
+	// the virtual networks of the config file are left to it
+	s.SetConfigVirtualNetworks([]config.VirtualNetwork{d.vns["65000:10"]})
+	_, err = s.UpdateVirtualNetwork(ctx, &api.UpdateVirtualNetworkRequest{VirtualNetwork: &api.VirtualNetwork{Rd: "65000:10", Vni: 12, VtepInterface: "vtep10"}})
+	assert.Equal(codes.FailedPrecondition, status.Code(err))
+	_, err = s.DeleteVirtualNetwork(ctx, &api.DeleteVirtualNetworkRequest{Name: "65000:10"})
+	assert.Equal(codes.FailedPrecondition, status.Code(err))
+
+	s.SetConfigVirtualNetworks(nil)
+	_, err = s.DeleteVirtualNetwork(ctx, &api.DeleteVirtualNetworkRequest{Name: "65000:10"})
+	assert.Nil(err)
+	assert.Len(d.vns, 0)
+	_, err = s.DeleteVirtualNetwork(ctx, &api.DeleteVirtualNetworkRequest{Name: "65000:10"})
+	assert.Equal(codes.NotFound, status.Code(err))
+}
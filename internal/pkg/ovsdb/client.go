@@ -0,0 +1,262 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// message is a JSON-RPC 1.0 request, response or notification.
+type message struct {
+	ID     interface{}     `json:"id"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  interface{}     `json:"error,omitempty"`
+}
+
+type response struct {
+	result json.RawMessage
+	err    error
+}
+
+// Client is a connection to an ovsdb-server.
+type Client struct {
+	conn    net.Conn
+	encMu   sync.Mutex
+	enc     *json.Encoder
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan *response
+	queueCh chan TableUpdates
+	updates chan TableUpdates
+	done    chan struct{}
+	err     error
+}
+
+// Dial connects to the ovsdb-server at address.
+func Dial(network, address string) (*Client, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient returns a client talking over conn.
+func NewClient(conn net.Conn) *Client {
+	c := &Client{
+		conn:    conn,
+		enc:     json.NewEncoder(conn),
+		pending: make(map[uint64]chan *response),
+		queueCh: make(chan TableUpdates),
+		updates: make(chan TableUpdates),
+		done:    make(chan struct{}),
+	}
+	go c.receive()
+	go c.deliver()
+	return c
+}
+
+// Close closes the connection. Done is closed once the receive loop has
+// finished.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Done is closed when the connection is lost.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Updates returns the channel of the changes of the monitored tables.
+// They are queued, so a slow reader doesn't block the responses to its
+// own transactions.
+func (c *Client) Updates() <-chan TableUpdates {
+	return c.updates
+}
+
+func (c *Client) send(m *message) error {
+	c.encMu.Lock()
+	defer c.encMu.Unlock()
+	return c.enc.Encode(m)
+}
+
+func (c *Client) call(method string, params ...interface{}) (json.RawMessage, error) {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan *response, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	id := c.nextID
+	c.nextID++
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.send(&message{ID: id, Method: method, Params: b}); err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return nil, err
+	}
+	r := <-ch
+	return r.result, r.err
+}
+
+// Transact runs ops on db as a transaction. When an operation fails,
+// the whole transaction is aborted and a TransactError is returned.
+func (c *Client) Transact(db string, ops ...Operation) ([]OperationResult, error) {
+	params := make([]interface{}, 0, len(ops)+1)
+	params = append(params, db)
+	for _, op := range ops {
+		params = append(params, op)
+	}
+	b, err := c.call("transact", params...)
+	if err != nil {
+		return nil, err
+	}
+	var results []OperationResult
+	if err := json.Unmarshal(b, &results); err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		if r.Error != "" {
+			return results, TransactError(results)
+		}
+	}
+	if len(results) < len(ops) {
+		return results, fmt.Errorf("%d results for %d operations", len(results), len(ops))
+	}
+	return results, nil
+}
+
+// Monitor starts monitoring the tables of db in requests. It returns
+// the current rows, and the later changes are sent to Updates.
+func (c *Client) Monitor(db string, requests map[string]MonitorRequest) (TableUpdates, error) {
+	b, err := c.call("monitor", db, nil, requests)
+	if err != nil {
+		return nil, err
+	}
+	var updates TableUpdates
+	if err := json.Unmarshal(b, &updates); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+func (c *Client) receive() {
+	dec := json.NewDecoder(c.conn)
+	var err error
+	for {
+		m := &message{}
+		if err = dec.Decode(m); err != nil {
+			break
+		}
+		switch m.Method {
+		case "":
+			c.handleResponse(m)
+		case "echo":
+			// the server checks that the client is alive
+			err = c.send(&message{ID: m.ID, Result: m.Params})
+		case "update":
+			var params []json.RawMessage
+			var updates TableUpdates
+			if e := json.Unmarshal(m.Params, &params); e != nil || len(params) != 2 {
+				log.WithFields(log.Fields{
+					"Topic": "OVSDB",
+				}).Warnf("invalid update: %s", m.Params)
+				continue
+			}
+			if e := json.Unmarshal(params[1], &updates); e != nil {
+				log.WithFields(log.Fields{
+					"Topic": "OVSDB",
+				}).Warnf("invalid update: %s", e)
+				continue
+			}
+			select {
+			case c.queueCh <- updates:
+			case <-c.done:
+			}
+		default:
+			log.WithFields(log.Fields{
+				"Topic": "OVSDB",
+			}).Debugf("ignore %s request", m.Method)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	c.mu.Lock()
+	c.err = fmt.Errorf("connection to ovsdb-server is lost: %s", err)
+	for id, ch := range c.pending {
+		ch <- &response{err: c.err}
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+	c.conn.Close()
+	close(c.done)
+}
+
+func (c *Client) handleResponse(m *message) {
+	id, ok := m.ID.(float64)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	ch, ok := c.pending[uint64(id)]
+	delete(c.pending, uint64(id))
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+	if m.Error != nil {
+		ch <- &response{err: fmt.Errorf("%v", m.Error)}
+		return
+	}
+	ch <- &response{result: m.Result}
+}
+
+// deliver passes the updates to Updates in order without blocking the
+// receive loop.
+func (c *Client) deliver() {
+	var queue []TableUpdates
+	for {
+		var out chan TableUpdates
+		var next TableUpdates
+		if len(queue) > 0 {
+			out, next = c.updates, queue[0]
+		}
+		select {
+		case u := <-c.queueCh:
+			queue = append(queue, u)
+		case out <- next:
+			queue = queue[1:]
+		case <-c.done:
+			return
+		}
+	}
+}
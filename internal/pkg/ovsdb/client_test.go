@@ -0,0 +1,157 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsdb
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperationJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	b, err := json.Marshal([]Operation{
+		Insert("Physical_Locator_Set", Row{
+			"locators": Set{NamedUUID("loc0"), UUID("1234")},
+		}, "set0"),
+		Delete("Ucast_Macs_Remote", Equal("MAC", "aa:bb:cc:00:00:01"), Equal("logical_switch", UUID("5678"))),
+	})
+	assert.Nil(err)
+	assert.JSONEq(`[
+		{"op": "insert", "table": "Physical_Locator_Set", "uuid-name": "set0",
+		 "row": {"locators": ["set", [["named-uuid", "loc0"], ["uuid", "1234"]]]}},
+		{"op": "delete", "table": "Ucast_Macs_Remote",
+		 "where": [["MAC", "==", "aa:bb:cc:00:00:01"], ["logical_switch", "==", ["uuid", "5678"]]]}
+	]`, string(b))
+
+	var r Row
+	assert.Nil(json.Unmarshal([]byte(`{"MAC": "aa:bb:cc:00:00:01", "logical_switch": ["uuid", "5678"], "ipaddr": ["set", []]}`), &r))
+	assert.Equal("aa:bb:cc:00:00:01", r.String("MAC"))
+	assert.Equal(UUID("5678"), r.UUID("logical_switch"))
+	assert.Equal("", r.String("ipaddr"))
+	assert.Equal(UUID(""), r.UUID("locator"))
+}
+
+// serve answers the requests read from conn with handler until conn is
+// closed.
+func serve(conn net.Conn, handler func(m *message) interface{}) {
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	for {
+		m := &message{}
+		if err := dec.Decode(m); err != nil {
+			return
+		}
+		if m.Method == "" {
+			continue
+		}
+		result, _ := json.Marshal(handler(m))
+		enc.Encode(&message{ID: m.ID, Result: result})
+	}
+}
+
+func TestClient(t *testing.T) {
+	assert := assert.New(t)
+
+	client, server := net.Pipe()
+	c := NewClient(client)
+	go serve(server, func(m *message) interface{} {
+		switch m.Method {
+		case "transact":
+			var params []json.RawMessage
+			json.Unmarshal(m.Params, &params)
+			if len(params) == 3 {
+				return []interface{}{
+					map[string]interface{}{"uuid": []string{"uuid", "1234"}},
+					map[string]interface{}{"error": "constraint violation", "details": "duplicate"},
+				}
+			}
+			return []interface{}{
+				map[string]interface{}{"uuid": []string{"uuid", "1234"}},
+			}
+		case "monitor":
+			return map[string]interface{}{
+				"Ucast_Macs_Local": map[string]interface{}{
+					"5678": map[string]interface{}{
+						"new": map[string]interface{}{"MAC": "aa:bb:cc:00:00:01"},
+					},
+				},
+			}
+		}
+		return nil
+	})
+
+	results, err := c.Transact("hardware_vtep", Insert("Logical_Switch", Row{"name": "ls10"}, ""))
+	assert.Nil(err)
+	assert.Equal(UUID("1234"), results[0].UUID)
+
+	_, err = c.Transact("hardware_vtep",
+		Insert("Logical_Switch", Row{"name": "ls10"}, ""),
+		Insert("Logical_Switch", Row{"name": "ls10"}, ""))
+	assert.IsType(TransactError{}, err)
+	assert.Equal("constraint violation: duplicate", err.Error())
+
+	updates, err := c.Monitor("hardware_vtep", map[string]MonitorRequest{
+		"Ucast_Macs_Local": {Columns: []string{"MAC"}},
+	})
+	assert.Nil(err)
+	assert.Equal("aa:bb:cc:00:00:01", updates["Ucast_Macs_Local"]["5678"].New.String("MAC"))
+
+	server.Close()
+	select {
+	case <-c.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the lost connection isn't detected")
+	}
+	_, err = c.Transact("hardware_vtep")
+	assert.NotNil(err)
+}
+
+func TestClientNotification(t *testing.T) {
+	assert := assert.New(t)
+
+	client, server := net.Pipe()
+	defer server.Close()
+	c := NewClient(client)
+	dec := json.NewDecoder(server)
+	enc := json.NewEncoder(server)
+
+	// echo requests are answered
+	params := json.RawMessage(`[]`)
+	assert.Nil(enc.Encode(&message{ID: "echo", Method: "echo", Params: params}))
+	m := &message{}
+	assert.Nil(dec.Decode(m))
+	assert.Equal("echo", m.ID)
+	assert.Equal(`[]`, string(m.Result))
+
+	// updates are queued in order until they are read
+	for _, mac := range []string{"aa:bb:cc:00:00:01", "aa:bb:cc:00:00:02"} {
+		params := json.RawMessage(`[null, {"Ucast_Macs_Local": {"5678": {"new": {"MAC": "` + mac + `"}}}}]`)
+		assert.Nil(enc.Encode(&message{Method: "update", Params: params}))
+	}
+	for _, mac := range []string{"aa:bb:cc:00:00:01", "aa:bb:cc:00:00:02"} {
+		select {
+		case u := <-c.Updates():
+			assert.Equal(mac, u["Ucast_Macs_Local"]["5678"].New.String("MAC"))
+		case <-time.After(5 * time.Second):
+			t.Fatal("no update")
+		}
+	}
+}
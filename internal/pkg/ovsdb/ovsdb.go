@@ -0,0 +1,191 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ovsdb implements the part of the OVSDB management protocol
+// (RFC 7047) used by the ovsdb dataplane: transactions and a monitor of
+// the tables.
+package ovsdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// UUID is the UUID of a row.
+type UUID string
+
+func (u UUID) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]string{"uuid", string(u)})
+}
+
+func (u *UUID) UnmarshalJSON(b []byte) error {
+	var l []string
+	if err := json.Unmarshal(b, &l); err != nil {
+		return err
+	}
+	if len(l) != 2 || l[0] != "uuid" {
+		return fmt.Errorf("invalid uuid: %s", b)
+	}
+	*u = UUID(l[1])
+	return nil
+}
+
+// NamedUUID refers to a row inserted by an earlier operation of the
+// same transaction with UUIDName.
+type NamedUUID string
+
+func (u NamedUUID) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]string{"named-uuid", string(u)})
+}
+
+// Set is a set of atoms.
+type Set []interface{}
+
+func (s Set) MarshalJSON() ([]byte, error) {
+	l := []interface{}(s)
+	if l == nil {
+		l = []interface{}{}
+	}
+	return json.Marshal([]interface{}{"set", l})
+}
+
+// Row maps the column names to their values. The values of the rows
+// received from the server are decoded by encoding/json, and read with
+// the methods of Row.
+type Row map[string]interface{}
+
+// String returns the value of a string column. An empty optional
+// column is an empty set, for which "" is returned.
+func (r Row) String(column string) string {
+	s, _ := r[column].(string)
+	return s
+}
+
+// UUID returns the value of a column referring to another row, or ""
+// when the column is empty.
+func (r Row) UUID(column string) UUID {
+	l, ok := r[column].([]interface{})
+	if !ok || len(l) != 2 || l[0] != "uuid" {
+		return ""
+	}
+	s, _ := l[1].(string)
+	return UUID(s)
+}
+
+// Condition is a clause of the where member of an operation.
+type Condition [3]interface{}
+
+// Equal returns the condition matching the rows whose column is value.
+func Equal(column string, value interface{}) Condition {
+	return Condition{column, "==", value}
+}
+
+// Operation is an operation of a transaction.
+type Operation struct {
+	Op       string      `json:"op"`
+	Table    string      `json:"table"`
+	Row      Row         `json:"row,omitempty"`
+	Where    []Condition `json:"where,omitempty"`
+	Columns  []string    `json:"columns,omitempty"`
+	UUIDName string      `json:"uuid-name,omitempty"`
+}
+
+// Insert returns the operation inserting row into table. name can be
+// referred by the later operations of the transaction with NamedUUID.
+func Insert(table string, row Row, name string) Operation {
+	return Operation{
+		Op:       "insert",
+		Table:    table,
+		Row:      row,
+		UUIDName: name,
+	}
+}
+
+// Select returns the operation selecting columns of the rows of table
+// matching where.
+func Select(table string, columns []string, where ...Condition) Operation {
+	return Operation{
+		Op:      "select",
+		Table:   table,
+		Where:   where,
+		Columns: columns,
+	}
+}
+
+// Update returns the operation updating the rows of table matching
+// where with row.
+func Update(table string, row Row, where ...Condition) Operation {
+	return Operation{
+		Op:    "update",
+		Table: table,
+		Row:   row,
+		Where: where,
+	}
+}
+
+// Delete returns the operation deleting the rows of table matching
+// where.
+func Delete(table string, where ...Condition) Operation {
+	return Operation{
+		Op:    "delete",
+		Table: table,
+		Where: where,
+	}
+}
+
+// OperationResult is the result of an operation.
+type OperationResult struct {
+	UUID    UUID   `json:"uuid,omitempty"`
+	Count   int    `json:"count,omitempty"`
+	Rows    []Row  `json:"rows,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Details string `json:"details,omitempty"`
+}
+
+// TransactError is returned when operations of a transaction failed.
+// The transaction is aborted as a whole.
+type TransactError []OperationResult
+
+func (e TransactError) Error() string {
+	l := make([]string, 0, len(e))
+	for _, r := range e {
+		if r.Error == "" {
+			continue
+		}
+		if r.Details != "" {
+			l = append(l, fmt.Sprintf("%s: %s", r.Error, r.Details))
+		} else {
+			l = append(l, r.Error)
+		}
+	}
+	return strings.Join(l, ", ")
+}
+
+// MonitorRequest selects the columns of a table to be monitored.
+type MonitorRequest struct {
+	Columns []string `json:"columns,omitempty"`
+}
+
+// RowUpdate is the change of a row. Old is nil for an inserted row and
+// New is nil for a deleted one. Old only has the modified columns of a
+// modified row.
+type RowUpdate struct {
+	Old Row `json:"old,omitempty"`
+	New Row `json:"new,omitempty"`
+}
+
+// TableUpdates maps the table names to the changes of their rows.
+type TableUpdates map[string]map[UUID]RowUpdate
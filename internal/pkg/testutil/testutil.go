@@ -0,0 +1,191 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testutil provides the fixtures shared by the tests of the
+// dataplanes.
+package testutil
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	api "github.com/osrg/gobgp/api"
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	"github.com/ttsubo/goplane/internal/pkg/apiutil"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// GobgpClient is a fake gobgpd API client. It records the VRFs and the
+// paths added through it, lists the peers passed to AddPeer, and
+// streams the best paths passed to SendBest to the monitor of their
+// AFI.
+type GobgpClient struct {
+	api.GobgpApiClient
+	mu     sync.Mutex
+	vrfs   map[string]*api.Vrf
+	paths  []*api.Path
+	peers  []*api.Peer
+	bestCh map[api.Family_Afi]chan *api.Path
+}
+
+// NewGobgpClient returns a GobgpClient without VRFs and paths.
+func NewGobgpClient() *GobgpClient {
+	return &GobgpClient{
+		vrfs: map[string]*api.Vrf{},
+		bestCh: map[api.Family_Afi]chan *api.Path{
+			api.Family_AFI_IP:    make(chan *api.Path),
+			api.Family_AFI_L2VPN: make(chan *api.Path),
+		},
+	}
+}
+
+func (c *GobgpClient) AddVrf(ctx context.Context, r *api.AddVrfRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.vrfs[r.Vrf.Name] = r.Vrf
+	return &empty.Empty{}, nil
+}
+
+func (c *GobgpClient) DeleteVrf(ctx context.Context, r *api.DeleteVrfRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.vrfs, r.Name)
+	return &empty.Empty{}, nil
+}
+
+func (c *GobgpClient) AddPath(ctx context.Context, r *api.AddPathRequest, opts ...grpc.CallOption) (*api.AddPathResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paths = append(c.paths, r.Path)
+	return &api.AddPathResponse{}, nil
+}
+
+func (c *GobgpClient) MonitorTable(ctx context.Context, r *api.MonitorTableRequest, opts ...grpc.CallOption) (api.GobgpApi_MonitorTableClient, error) {
+	return &monitorTableClient{ctx: ctx, ch: c.bestCh[r.Family.Afi]}, nil
+}
+
+func (c *GobgpClient) ListPeer(ctx context.Context, r *api.ListPeerRequest, opts ...grpc.CallOption) (api.GobgpApi_ListPeerClient, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &listPeerClient{peers: append([]*api.Peer(nil), c.peers...)}, nil
+}
+
+// AddPeer adds p to the peers listed by ListPeer.
+func (c *GobgpClient) AddPeer(p *api.Peer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.peers = append(c.peers, p)
+}
+
+// HasVrf reports whether the VRF name is added.
+func (c *GobgpClient) HasVrf(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.vrfs[name]
+	return ok
+}
+
+// Paths returns the paths added so far.
+func (c *GobgpClient) Paths() []*api.Path {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*api.Path(nil), c.paths...)
+}
+
+// EvpnPaths returns the EVPN paths of routeType added so far.
+func (c *GobgpClient) EvpnPaths(routeType uint8) []bgp.EVPNRouteTypeInterface {
+	var l []bgp.EVPNRouteTypeInterface
+	for _, p := range c.Paths() {
+		nlri, _ := apiutil.GetNativeNlri(p)
+		if e, ok := nlri.(*bgp.EVPNNLRI); ok && e.RouteType == routeType {
+			l = append(l, e.RouteTypeData)
+		}
+	}
+	return l
+}
+
+// SendBest sends p as a best path to the monitor of its AFI. It blocks
+// until the monitor receives it.
+func (c *GobgpClient) SendBest(p *api.Path) {
+	c.bestCh[p.Family.Afi] <- p
+}
+
+type monitorTableClient struct {
+	grpc.ClientStream
+	ctx context.Context
+	ch  chan *api.Path
+}
+
+func (s *monitorTableClient) Recv() (*api.MonitorTableResponse, error) {
+	select {
+	case p := <-s.ch:
+		return &api.MonitorTableResponse{Path: p}, nil
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+type listPeerClient struct {
+	grpc.ClientStream
+	peers []*api.Peer
+}
+
+func (s *listPeerClient) Recv() (*api.ListPeerResponse, error) {
+	if len(s.peers) == 0 {
+		return nil, io.EOF
+	}
+	p := s.peers[0]
+	s.peers = s.peers[1:]
+	return &api.ListPeerResponse{Peer: p}, nil
+}
+
+// NewEvpnPath returns a Type-2 route of mac or a Type-3 route with the
+// RD 65000:10 and the VNI 10, advertised from nexthop.
+func NewEvpnPath(routeType uint8, mac, nexthop string, withdraw bool, attrs ...bgp.PathAttributeInterface) *api.Path {
+	rd, _ := bgp.ParseRouteDistinguisher("65000:10")
+	var nlri *bgp.EVPNNLRI
+	switch routeType {
+	case bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT:
+		hw, _ := net.ParseMAC(mac)
+		nlri = bgp.NewEVPNNLRI(routeType, &bgp.EVPNMacIPAdvertisementRoute{
+			RD: rd,
+			ESI: bgp.EthernetSegmentIdentifier{
+				Type: bgp.ESI_ARBITRARY,
+			},
+			MacAddressLength: 48,
+			MacAddress:       hw,
+			Labels:           []uint32{10},
+		})
+	case bgp.EVPN_INCLUSIVE_MULTICAST_ETHERNET_TAG:
+		nlri = bgp.NewEVPNNLRI(routeType, &bgp.EVPNMulticastEthernetTagRoute{
+			RD:              rd,
+			IPAddressLength: 32,
+			IPAddress:       net.ParseIP(nexthop),
+		})
+	}
+	attrs = append([]bgp.PathAttributeInterface{
+		bgp.NewPathAttributeOrigin(bgp.BGP_ORIGIN_ATTR_TYPE_IGP),
+		bgp.NewPathAttributeMpReachNLRI(nexthop, []bgp.AddrPrefixInterface{nlri}),
+	}, attrs...)
+	return &api.Path{
+		Nlri:       apiutil.MarshalNLRI(nlri),
+		Pattrs:     apiutil.MarshalPathAttributes(attrs),
+		IsWithdraw: withdraw,
+		Family:     &api.Family{Afi: api.Family_Afi(nlri.AFI()), Safi: api.Family_Safi(nlri.SAFI())},
+	}
+}
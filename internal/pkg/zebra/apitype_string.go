@@ -68,21 +68,21 @@ func _() {
 	_ = x[_advertiseDefaultGW-57]
 	_ = x[_advertiseSviMACIP-58]
 	_ = x[_advertiseSubnet-59]
-	_ = x[_advertiseAllVNI-60]
+	_ = x[advertiseAllVNI-60]
 	_ = x[_localESAdd-61]
 	_ = x[_localESDel-62]
-	_ = x[_vniAdd-63]
-	_ = x[_vniDel-64]
+	_ = x[VniAdd-63]
+	_ = x[VniDel-64]
 	_ = x[_l3VNIAdd-65]
 	_ = x[_l3VNIDel-66]
-	_ = x[_remoteVTEPAdd-67]
-	_ = x[_remoteVTEPDel-68]
-	_ = x[_macIPAdd-69]
-	_ = x[_macIPDel-70]
+	_ = x[remoteVTEPAdd-67]
+	_ = x[remoteVTEPDel-68]
+	_ = x[MacIPAdd-69]
+	_ = x[MacIPDel-70]
 	_ = x[_ipPrefixRouteAdd-71]
 	_ = x[_ipPrefixRouteDel-72]
-	_ = x[_remoteMACIPAdd-73]
-	_ = x[_remoteMACIPDel-74]
+	_ = x[remoteMACIPAdd-73]
+	_ = x[remoteMACIPDel-74]
 	_ = x[_duplicateAddrDetection-75]
 	_ = x[_pwAdd-76]
 	_ = x[_pwDelete-77]
@@ -126,6 +126,15 @@ func _() {
 	_ = x[zapi6Frr7dot2LabelManagerConnectAsync-50]
 	_ = x[zapi6Frr7dot2GetLabelChunk-51]
 	_ = x[zapi6Frr7dot2ReleaseLabelChunk-52]
+	_ = x[zapi6Frr7dot2AdvertiseAllVNI-59]
+	_ = x[zapi6Frr7dot2VniAdd-62]
+	_ = x[zapi6Frr7dot2VniDel-63]
+	_ = x[zapi6Frr7dot2RemoteVTEPAdd-66]
+	_ = x[zapi6Frr7dot2RemoteVTEPDel-67]
+	_ = x[zapi6Frr7dot2MacIPAdd-68]
+	_ = x[zapi6Frr7dot2MacIPDel-69]
+	_ = x[zapi6Frr7dot2RemoteMACIPAdd-72]
+	_ = x[zapi6Frr7dot2RemoteMACIPDel-73]
 	_ = x[zapi6Frr7RouteAdd-7]
 	_ = x[zapi6Frr7RouteDelete-8]
 	_ = x[zapi6Frr7RedistributAdd-10]
@@ -212,9 +221,9 @@ func _() {
 	_ = x[zapi3NexthopUpdate-29]
 }
 
-const _APIType_name = "interfaceAddinterfaceDeleteinterfaceAddressAddinterfaceAddressDeleteinterfaceUpinterfaceDown_interfaceSetMaster_interfaceSetProtoDownRouteAddRouteDelete_routeNotifyOwnerredistributeAdd_redistributeDelete_redistributeDefaultAdd_redistributeDefaultDeleterouterIDAdd_routerIDDeleterouterIDUpdatehello_capabilitiesnexthopRegisternexthopUnregisternexthopUpdate_interfaceNBRAddressAdd_interfaceNBRAddressDelete_interfaceBFDDestUpdate_importRouteRegister_importRouteUnregister_importCheckUpdate_bfdDestRegister_bfdDestDeregister_bfdDestUpdate_bfdDestReplayredistributeRouteAddredistributeRouteDel_vrfUnregister_vrfAdd_vrfDeletevrfLabel_interfaceVRFUpdate_bfdClientRegister_bfdClientDeregister_interfaceEnableRADV_interfaceDisableRADVipv4NexthopLookupMRIB_interfaceLinkParams_mplsLabelsAdd_mplsLabelsDelete_mplsLabelsReplace_ipmrRouteStatslabelManagerConnectlabelManagerConnectAsyncgetLabelChunkreleaseLabelChunk_fecRegister_fecUnregister_fecUpdate_advertiseDefaultGW_advertiseSviMACIP_advertiseSubnet_advertiseAllVNI_localESAdd_localESDel_vniAdd_vniDel_l3VNIAdd_l3VNIDel_remoteVTEPAdd_remoteVTEPDel_macIPAdd_macIPDel_ipPrefixRouteAdd_ipPrefixRouteDel_remoteMACIPAdd_remoteMACIPDel_duplicateAddrDetection_pwAdd_pwDelete_pwSet_pwUnset_pwStatusUpdate_ruleAdd_ruleDelete_ruleNotifyOwner_tableManagerConnect_getTableChunk_releaseTableChunk_ipSetCreate_ipSetDestroy_ipSetEntryAdd_ipSetEntryDelete_ipSetNotifyOwner_ipSetEntryNotifyOwner_ipTableAdd_ipTableDelete_ipTableNotifyOwner_vxlanFloodControl_vxlanSgAdd_vxlanSgDel_vxlanSgReplay_mlagProcessUp_mlagProcessDown_mlagClientRegister_mlagClientUnregister_mlagClientForwardMsgzebraError_clientCapabilitiesBackwardIPv6RouteAddBackwardIPv6RouteDelete"
+const _APIType_name = "interfaceAddinterfaceDeleteinterfaceAddressAddinterfaceAddressDeleteinterfaceUpinterfaceDown_interfaceSetMaster_interfaceSetProtoDownRouteAddRouteDelete_routeNotifyOwnerredistributeAdd_redistributeDelete_redistributeDefaultAdd_redistributeDefaultDeleterouterIDAdd_routerIDDeleterouterIDUpdatehello_capabilitiesnexthopRegisternexthopUnregisternexthopUpdate_interfaceNBRAddressAdd_interfaceNBRAddressDelete_interfaceBFDDestUpdate_importRouteRegister_importRouteUnregister_importCheckUpdate_bfdDestRegister_bfdDestDeregister_bfdDestUpdate_bfdDestReplayredistributeRouteAddredistributeRouteDel_vrfUnregister_vrfAdd_vrfDeletevrfLabel_interfaceVRFUpdate_bfdClientRegister_bfdClientDeregister_interfaceEnableRADV_interfaceDisableRADVipv4NexthopLookupMRIB_interfaceLinkParams_mplsLabelsAdd_mplsLabelsDelete_mplsLabelsReplace_ipmrRouteStatslabelManagerConnectlabelManagerConnectAsyncgetLabelChunkreleaseLabelChunk_fecRegister_fecUnregister_fecUpdate_advertiseDefaultGW_advertiseSviMACIP_advertiseSubnetadvertiseAllVNI_localESAdd_localESDelVniAddVniDel_l3VNIAdd_l3VNIDelremoteVTEPAddremoteVTEPDelMacIPAddMacIPDel_ipPrefixRouteAdd_ipPrefixRouteDelremoteMACIPAddremoteMACIPDel_duplicateAddrDetection_pwAdd_pwDelete_pwSet_pwUnset_pwStatusUpdate_ruleAdd_ruleDelete_ruleNotifyOwner_tableManagerConnect_getTableChunk_releaseTableChunk_ipSetCreate_ipSetDestroy_ipSetEntryAdd_ipSetEntryDelete_ipSetNotifyOwner_ipSetEntryNotifyOwner_ipTableAdd_ipTableDelete_ipTableNotifyOwner_vxlanFloodControl_vxlanSgAdd_vxlanSgDel_vxlanSgReplay_mlagProcessUp_mlagProcessDown_mlagClientRegister_mlagClientUnregister_mlagClientForwardMsgzebraError_clientCapabilitiesBackwardIPv6RouteAddBackwardIPv6RouteDelete"
 
-var _APIType_index = [...]uint16{0, 12, 27, 46, 68, 79, 92, 111, 133, 141, 152, 169, 184, 203, 226, 252, 263, 278, 292, 297, 310, 325, 342, 355, 378, 404, 427, 447, 469, 487, 503, 521, 535, 549, 569, 589, 603, 610, 620, 628, 647, 665, 685, 705, 726, 747, 767, 781, 798, 816, 831, 850, 874, 887, 904, 916, 930, 940, 959, 977, 993, 1009, 1020, 1031, 1038, 1045, 1054, 1063, 1077, 1091, 1100, 1109, 1126, 1143, 1158, 1173, 1196, 1202, 1211, 1217, 1225, 1240, 1248, 1259, 1275, 1295, 1309, 1327, 1339, 1352, 1366, 1383, 1400, 1422, 1433, 1447, 1466, 1484, 1495, 1506, 1520, 1534, 1550, 1569, 1590, 1611, 1621, 1640, 1660, 1683}
+var _APIType_index = [...]uint16{0, 12, 27, 46, 68, 79, 92, 111, 133, 141, 152, 169, 184, 203, 226, 252, 263, 278, 292, 297, 310, 325, 342, 355, 378, 404, 427, 447, 469, 487, 503, 521, 535, 549, 569, 589, 603, 610, 620, 628, 647, 665, 685, 705, 726, 747, 767, 781, 798, 816, 831, 850, 874, 887, 904, 916, 930, 940, 959, 977, 993, 1008, 1019, 1030, 1036, 1042, 1051, 1060, 1073, 1086, 1094, 1102, 1119, 1136, 1150, 1164, 1187, 1193, 1202, 1208, 1216, 1231, 1239, 1250, 1266, 1286, 1300, 1318, 1330, 1343, 1357, 1374, 1391, 1413, 1424, 1438, 1457, 1475, 1486, 1497, 1511, 1525, 1541, 1560, 1581, 1602, 1612, 1631, 1651, 1674}
 
 func (i APIType) String() string {
 	if i >= APIType(len(_APIType_index)-1) {
@@ -329,21 +329,21 @@ const (
 	_advertiseDefaultGW
 	_advertiseSviMACIP // add in frr7.1
 	_advertiseSubnet
-	_advertiseAllVNI // 60
+	advertiseAllVNI // 60
 	_localESAdd
 	_localESDel
-	_vniAdd
-	_vniDel
+	VniAdd // VniAdd is referred in the zebra dataplane
+	VniDel // VniDel is referred in the zebra dataplane
 	_l3VNIAdd
 	_l3VNIDel
-	_remoteVTEPAdd
-	_remoteVTEPDel
-	_macIPAdd
-	_macIPDel // 70
+	remoteVTEPAdd
+	remoteVTEPDel
+	MacIPAdd // MacIPAdd is referred in the zebra dataplane
+	MacIPDel // 70 // MacIPDel is referred in the zebra dataplane
 	_ipPrefixRouteAdd
 	_ipPrefixRouteDel
-	_remoteMACIPAdd
-	_remoteMACIPDel
+	remoteMACIPAdd
+	remoteMACIPDel
 	_duplicateAddrDetection
 	_pwAdd
 	_pwDelete
@@ -410,11 +410,32 @@ const (
 	zapi6Frr7dot2ReleaseLabelChunk        APIType = 52 // difference from frr7.3
 )
 
+const ( // frr7.2 doesn't have MPLS_LABELS_REPLACE
+	zapi6Frr7dot2AdvertiseAllVNI APIType = 59
+	zapi6Frr7dot2VniAdd          APIType = 62
+	zapi6Frr7dot2VniDel          APIType = 63
+	zapi6Frr7dot2RemoteVTEPAdd   APIType = 66
+	zapi6Frr7dot2RemoteVTEPDel   APIType = 67
+	zapi6Frr7dot2MacIPAdd        APIType = 68
+	zapi6Frr7dot2MacIPDel        APIType = 69
+	zapi6Frr7dot2RemoteMACIPAdd  APIType = 72
+	zapi6Frr7dot2RemoteMACIPDel  APIType = 73
+)
+
 var apiTypeZapi6Frr7dot2Map = map[APIType]APIType{
 	labelManagerConnect:      zapi6Frr7dot2LabelManagerConnect,
 	labelManagerConnectAsync: zapi6Frr7dot2LabelManagerConnectAsync,
 	getLabelChunk:            zapi6Frr7dot2GetLabelChunk,
 	releaseLabelChunk:        zapi6Frr7dot2ReleaseLabelChunk,
+	advertiseAllVNI:          zapi6Frr7dot2AdvertiseAllVNI,
+	VniAdd:                   zapi6Frr7dot2VniAdd,
+	VniDel:                   zapi6Frr7dot2VniDel,
+	remoteVTEPAdd:            zapi6Frr7dot2RemoteVTEPAdd,
+	remoteVTEPDel:            zapi6Frr7dot2RemoteVTEPDel,
+	MacIPAdd:                 zapi6Frr7dot2MacIPAdd,
+	MacIPDel:                 zapi6Frr7dot2MacIPDel,
+	remoteMACIPAdd:           zapi6Frr7dot2RemoteMACIPAdd,
+	remoteMACIPDel:           zapi6Frr7dot2RemoteMACIPDel,
 }
 
 const ( // frr7.0, 7.1
@@ -1526,6 +1547,68 @@ func (c *Client) SendVrfLabel(label uint32, vrfID uint32) error {
 	return c.sendCommand(vrfLabel, vrfID, body)
 }
 
+// SupportEvpn returns true when the EVPN messages can be exchanged with
+// zebra. They are implemented for FRR7.2 and 7.3 (ZAPI6).
+func (c *Client) SupportEvpn() bool {
+	return c.Version == 6 && (c.SoftwareName == "" || c.SoftwareName == "frr7.2")
+}
+
+// SendAdvertiseAllVNI sends ADVERTISE_ALL_VNI message to zebra daemon.
+// zebra then sends VNI_ADD and MACIP_ADD messages for the local VNIs
+// and MACs.
+func (c *Client) SendAdvertiseAllVNI(advertise bool, floodControl VxlanFloodControl) error {
+	if !c.SupportEvpn() {
+		return fmt.Errorf("AdvertiseAllVNI is not supported in zebra API version: %d software: %s", c.Version, c.SoftwareName)
+	}
+	body := &advertiseAllVNIBody{
+		advertise:    advertise,
+		floodControl: floodControl,
+	}
+	return c.sendCommand(advertiseAllVNI, DefaultVrf, body)
+}
+
+// SendRemoteVTEP sends REMOTE_VTEP_ADD or REMOTE_VTEP_DEL message to
+// zebra daemon to update the flood list of vni.
+func (c *Client) SendRemoteVTEP(vni uint32, vtepIP net.IP, floodControl VxlanFloodControl, isWithdraw bool) error {
+	if !c.SupportEvpn() {
+		return fmt.Errorf("RemoteVTEP is not supported in zebra API version: %d software: %s", c.Version, c.SoftwareName)
+	}
+	command := remoteVTEPAdd
+	if isWithdraw {
+		command = remoteVTEPDel
+	}
+	body := &remoteVTEPBody{
+		api:          command,
+		vni:          vni,
+		vtepIP:       vtepIP,
+		floodControl: floodControl,
+	}
+	return c.sendCommand(command, DefaultVrf, body)
+}
+
+// SendRemoteMACIP sends REMOTE_MACIP_ADD or REMOTE_MACIP_DEL message to
+// zebra daemon to install or remove a remote MAC of vni. ip is nil for
+// MAC only routes.
+func (c *Client) SendRemoteMACIP(vni uint32, mac net.HardwareAddr, ip net.IP, vtepIP net.IP, flags MacIPFlag, seq uint32, isWithdraw bool) error {
+	if !c.SupportEvpn() {
+		return fmt.Errorf("RemoteMACIP is not supported in zebra API version: %d software: %s", c.Version, c.SoftwareName)
+	}
+	command := remoteMACIPAdd
+	if isWithdraw {
+		command = remoteMACIPDel
+	}
+	body := &remoteMACIPBody{
+		api:    command,
+		vni:    vni,
+		mac:    mac,
+		ip:     ip,
+		vtepIP: vtepIP,
+		flags:  flags,
+		seq:    seq,
+	}
+	return c.sendCommand(command, DefaultVrf, body)
+}
+
 // for avoiding double close
 func closeChannel(ch chan *Message) bool {
 	select {
@@ -1544,6 +1627,12 @@ func (c *Client) close() error {
 	return c.conn.Close()
 }
 
+// Close closes the connection to zebra daemon. The channel returned by
+// Receive is closed once the receive loop has finished.
+func (c *Client) Close() error {
+	return c.close()
+}
+
 // SetLabelFlag is referred in zclient, this func sets label flag
 func (c Client) SetLabelFlag(msgFlags *MessageFlag, nexthop *Nexthop) {
 	if c.Version == 6 && c.SoftwareName == "" {
@@ -3088,6 +3177,296 @@ func (b *vrfLabelBody) string(version uint8, softwareName string) string {
 		b.label, b.afi, b.labelType)
 }
 
+// VxlanFloodControl is how zebra floods BUM traffic of the VNIs.
+type VxlanFloodControl uint8
+
+// For FRRouting version 6 and over.
+const (
+	// VxlanFloodHeadEndRepl replicates BUM traffic to the remote VTEPs.
+	VxlanFloodHeadEndRepl VxlanFloodControl = iota
+	// VxlanFloodDisabled doesn't flood BUM traffic.
+	VxlanFloodDisabled
+	// VxlanFloodPIMSM floods BUM traffic to the multicast group of the VNI.
+	VxlanFloodPIMSM
+)
+
+// MacIPFlag is a flag of a local or a remote MAC.
+type MacIPFlag uint8
+
+// For FRRouting version 6 and over.
+const (
+	MacIPFlagSticky   MacIPFlag = 0x01
+	MacIPFlagGateway  MacIPFlag = 0x02
+	MacIPFlagRouter   MacIPFlag = 0x04
+	MacIPFlagOverride MacIPFlag = 0x08
+)
+
+// decodeMacIP decodes the VNI, the MAC and the optional IP address at
+// the head of a MACIP message. It returns the number of bytes read.
+func decodeMacIP(data []byte) (uint32, net.HardwareAddr, net.IP, int, error) {
+	if len(data) < 14 {
+		return 0, nil, nil, 0, fmt.Errorf("invalid message length for MACIP message: %d<14", len(data))
+	}
+	vni := binary.BigEndian.Uint32(data[0:4])
+	mac := net.HardwareAddr(append([]byte{}, data[4:10]...))
+	ipLen := int(binary.BigEndian.Uint32(data[10:14]))
+	if ipLen != 0 && ipLen != net.IPv4len && ipLen != net.IPv6len {
+		return 0, nil, nil, 0, fmt.Errorf("invalid IP address length for MACIP message: %d", ipLen)
+	}
+	if len(data) < 14+ipLen {
+		return 0, nil, nil, 0, fmt.Errorf("invalid message length for MACIP message: %d<%d", len(data), 14+ipLen)
+	}
+	var ip net.IP
+	if ipLen > 0 {
+		ip = net.IP(append([]byte{}, data[14:14+ipLen]...))
+	}
+	return vni, mac, ip, 14 + ipLen, nil
+}
+
+func serializeMacIP(vni uint32, mac net.HardwareAddr, ip net.IP) []byte {
+	buf := make([]byte, 14, 30)
+	binary.BigEndian.PutUint32(buf[0:4], vni)
+	copy(buf[4:10], mac)
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	binary.BigEndian.PutUint32(buf[10:14], uint32(len(ip)))
+	return append(buf, ip...)
+}
+
+type advertiseAllVNIBody struct {
+	advertise    bool
+	floodControl VxlanFloodControl
+}
+
+// Ref: bgp_zebra_advertise_all_vni in bgpd/bgp_zebra.c of FRR7.2&7.3 (ZAPI6)
+func (b *advertiseAllVNIBody) serialize(version uint8, softwareName string) ([]byte, error) {
+	buf := make([]byte, 2)
+	if b.advertise {
+		buf[0] = 1
+	}
+	buf[1] = uint8(b.floodControl)
+	return buf, nil
+}
+
+// Ref: zebra_vxlan_advertise_all_vni in zebra/zebra_vxlan.c of FRR7.2&7.3 (ZAPI6)
+func (b *advertiseAllVNIBody) decodeFromBytes(data []byte, version uint8, softwareName string) error {
+	if len(data) < 2 {
+		return fmt.Errorf("invalid message length for AdvertiseAllVNI message: %d<2", len(data))
+	}
+	b.advertise = data[0] != 0
+	b.floodControl = VxlanFloodControl(data[1])
+	return nil
+}
+
+func (b *advertiseAllVNIBody) string(version uint8, softwareName string) string {
+	return fmt.Sprintf("advertise: %t, flood control: %d", b.advertise, b.floodControl)
+}
+
+// VniUpdateBody is the body of VNI_ADD and VNI_DEL messages sent by
+// zebra for the VNIs of the local vxlan interfaces.
+type VniUpdateBody struct {
+	API        APIType
+	Vni        uint32
+	VtepIP     net.IP
+	McastGroup net.IP
+}
+
+// Ref: zvni_send_add_to_client in zebra/zebra_vxlan.c of FRR7.2&7.3 (ZAPI6)
+func (b *VniUpdateBody) serialize(version uint8, softwareName string) ([]byte, error) {
+	buf := make([]byte, 4, 16)
+	binary.BigEndian.PutUint32(buf[0:4], b.Vni)
+	if b.API == VniDel {
+		return buf, nil
+	}
+	buf = append(buf, b.VtepIP.To4()...)
+	// the tenant VRF ID isn't used by the dataplane
+	buf = append(buf, 0, 0, 0, 0)
+	group := b.McastGroup.To4()
+	if group == nil {
+		group = net.IPv4zero.To4()
+	}
+	return append(buf, group...), nil
+}
+
+// Ref: bgp_zebra_process_local_vni in bgpd/bgp_zebra.c of FRR7.2&7.3 (ZAPI6)
+func (b *VniUpdateBody) decodeFromBytes(data []byte, version uint8, softwareName string) error {
+	if len(data) < 4 {
+		return fmt.Errorf("invalid message length for VNI message: %d<4", len(data))
+	}
+	b.Vni = binary.BigEndian.Uint32(data[0:4])
+	if b.API == VniDel {
+		return nil
+	}
+	if len(data) < 12 {
+		return fmt.Errorf("invalid message length for VNI message: %d<12", len(data))
+	}
+	b.VtepIP = net.IP(append([]byte{}, data[4:8]...))
+	// data[8:12] is the tenant VRF ID in the byte order of the zebra
+	// host. The multicast group is sent by FRR7.2 and over.
+	if len(data) >= 16 {
+		b.McastGroup = net.IP(append([]byte{}, data[12:16]...))
+	}
+	return nil
+}
+
+func (b *VniUpdateBody) string(version uint8, softwareName string) string {
+	return fmt.Sprintf("vni: %d, vtep: %s, mcast group: %s", b.Vni, b.VtepIP, b.McastGroup)
+}
+
+// MacIPUpdateBody is the body of MACIP_ADD and MACIP_DEL messages sent
+// by zebra for the MACs learned on the local bridges.
+type MacIPUpdateBody struct {
+	API   APIType
+	Vni   uint32
+	Mac   net.HardwareAddr
+	IP    net.IP
+	Flags MacIPFlag
+	Seq   uint32
+	State uint32
+}
+
+// Ref: zvni_macip_send_msg_to_client in zebra/zebra_vxlan.c of FRR7.2&7.3 (ZAPI6)
+func (b *MacIPUpdateBody) serialize(version uint8, softwareName string) ([]byte, error) {
+	buf := serializeMacIP(b.Vni, b.Mac, b.IP)
+	if b.API == MacIPDel {
+		tmp := make([]byte, 4)
+		binary.BigEndian.PutUint32(tmp, b.State)
+		return append(buf, tmp...), nil
+	}
+	tmp := make([]byte, 5)
+	tmp[0] = uint8(b.Flags)
+	binary.BigEndian.PutUint32(tmp[1:5], b.Seq)
+	return append(buf, tmp...), nil
+}
+
+// Ref: bgp_zebra_process_local_macip in bgpd/bgp_zebra.c of FRR7.2&7.3 (ZAPI6)
+func (b *MacIPUpdateBody) decodeFromBytes(data []byte, version uint8, softwareName string) error {
+	var n int
+	var err error
+	b.Vni, b.Mac, b.IP, n, err = decodeMacIP(data)
+	if err != nil {
+		return err
+	}
+	data = data[n:]
+	if b.API == MacIPDel {
+		if len(data) < 4 {
+			return fmt.Errorf("invalid message length for MACIP message: %d<4", len(data))
+		}
+		b.State = binary.BigEndian.Uint32(data[0:4])
+		return nil
+	}
+	if len(data) < 5 {
+		return fmt.Errorf("invalid message length for MACIP message: %d<5", len(data))
+	}
+	b.Flags = MacIPFlag(data[0])
+	b.Seq = binary.BigEndian.Uint32(data[1:5])
+	return nil
+}
+
+func (b *MacIPUpdateBody) string(version uint8, softwareName string) string {
+	return fmt.Sprintf("vni: %d, mac: %s, ip: %s, flags: %d, seq: %d", b.Vni, b.Mac, b.IP, b.Flags, b.Seq)
+}
+
+type remoteVTEPBody struct {
+	api          APIType
+	vni          uint32
+	vtepIP       net.IP
+	floodControl VxlanFloodControl
+}
+
+// Ref: bgp_zebra_send_remote_vtep in bgpd/bgp_evpn.c of FRR7.2&7.3 (ZAPI6)
+func (b *remoteVTEPBody) serialize(version uint8, softwareName string) ([]byte, error) {
+	vtep := b.vtepIP.To4()
+	if vtep == nil {
+		return nil, fmt.Errorf("remote VTEP must be IPv4: %s", b.vtepIP)
+	}
+	buf := make([]byte, 8, 12)
+	binary.BigEndian.PutUint32(buf[0:4], b.vni)
+	copy(buf[4:8], vtep)
+	if b.api == remoteVTEPDel {
+		return buf, nil
+	}
+	tmp := make([]byte, 4)
+	binary.BigEndian.PutUint32(tmp, uint32(b.floodControl))
+	return append(buf, tmp...), nil
+}
+
+// Ref: zebra_vxlan_remote_vtep_add in zebra/zebra_vxlan.c of FRR7.2&7.3 (ZAPI6)
+func (b *remoteVTEPBody) decodeFromBytes(data []byte, version uint8, softwareName string) error {
+	if len(data) < 8 {
+		return fmt.Errorf("invalid message length for RemoteVTEP message: %d<8", len(data))
+	}
+	b.vni = binary.BigEndian.Uint32(data[0:4])
+	b.vtepIP = net.IP(append([]byte{}, data[4:8]...))
+	if b.api == remoteVTEPDel {
+		return nil
+	}
+	if len(data) < 12 {
+		return fmt.Errorf("invalid message length for RemoteVTEP message: %d<12", len(data))
+	}
+	b.floodControl = VxlanFloodControl(binary.BigEndian.Uint32(data[8:12]))
+	return nil
+}
+
+func (b *remoteVTEPBody) string(version uint8, softwareName string) string {
+	return fmt.Sprintf("vni: %d, vtep: %s, flood control: %d", b.vni, b.vtepIP, b.floodControl)
+}
+
+type remoteMACIPBody struct {
+	api    APIType
+	vni    uint32
+	mac    net.HardwareAddr
+	ip     net.IP
+	vtepIP net.IP
+	flags  MacIPFlag
+	seq    uint32
+}
+
+// Ref: bgp_zebra_send_remote_macip in bgpd/bgp_evpn.c of FRR7.2&7.3 (ZAPI6)
+func (b *remoteMACIPBody) serialize(version uint8, softwareName string) ([]byte, error) {
+	vtep := b.vtepIP.To4()
+	if vtep == nil {
+		return nil, fmt.Errorf("remote VTEP must be IPv4: %s", b.vtepIP)
+	}
+	buf := append(serializeMacIP(b.vni, b.mac, b.ip), vtep...)
+	if b.api == remoteMACIPDel {
+		return buf, nil
+	}
+	tmp := make([]byte, 5)
+	tmp[0] = uint8(b.flags)
+	binary.BigEndian.PutUint32(tmp[1:5], b.seq)
+	return append(buf, tmp...), nil
+}
+
+// Ref: zebra_vxlan_remote_macip_add in zebra/zebra_vxlan.c of FRR7.2&7.3 (ZAPI6)
+func (b *remoteMACIPBody) decodeFromBytes(data []byte, version uint8, softwareName string) error {
+	var n int
+	var err error
+	b.vni, b.mac, b.ip, n, err = decodeMacIP(data)
+	if err != nil {
+		return err
+	}
+	data = data[n:]
+	if len(data) < 4 {
+		return fmt.Errorf("invalid message length for RemoteMACIP message: %d<4", len(data))
+	}
+	b.vtepIP = net.IP(append([]byte{}, data[0:4]...))
+	if b.api == remoteMACIPDel {
+		return nil
+	}
+	if len(data) < 9 {
+		return fmt.Errorf("invalid message length for RemoteMACIP message: %d<9", len(data))
+	}
+	b.flags = MacIPFlag(data[4])
+	b.seq = binary.BigEndian.Uint32(data[5:9])
+	return nil
+}
+
+func (b *remoteMACIPBody) string(version uint8, softwareName string) string {
+	return fmt.Sprintf("vni: %d, mac: %s, ip: %s, vtep: %s, flags: %d, seq: %d", b.vni, b.mac, b.ip, b.vtepIP, b.flags, b.seq)
+}
+
 // Message is referred in zclient
 type Message struct {
 	Header Header
@@ -3142,6 +3521,16 @@ func parseMessage(hdr *Header, data []byte, software string) (m *Message, err er
 		m.Body = &IPRouteBody{API: m.Header.Command}
 	case ipv4NexthopLookupMRIB:
 		m.Body = &lookupBody{api: m.Header.Command}
+	case advertiseAllVNI:
+		m.Body = &advertiseAllVNIBody{}
+	case VniAdd, VniDel:
+		m.Body = &VniUpdateBody{API: command}
+	case MacIPAdd, MacIPDel:
+		m.Body = &MacIPUpdateBody{API: command}
+	case remoteVTEPAdd, remoteVTEPDel:
+		m.Body = &remoteVTEPBody{api: command}
+	case remoteMACIPAdd, remoteMACIPDel:
+		m.Body = &remoteMACIPBody{api: command}
 	default:
 		m.Body = &unknownBody{}
 		if m.Header.Version == 4 {
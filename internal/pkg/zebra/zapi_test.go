@@ -1053,3 +1053,169 @@ func Test_vrfLabelBody(t *testing.T) {
 		assert.Equal(bufIn, bufOut)
 	}
 }
+
+func Test_advertiseAllVNIBody(t *testing.T) {
+	assert := assert.New(t)
+	//decodeFromBytes
+	bufIn := []byte{1, byte(VxlanFloodHeadEndRepl)}
+	b := &advertiseAllVNIBody{}
+	err := b.decodeFromBytes(bufIn, 6, "")
+	assert.Equal(nil, err)
+	assert.True(b.advertise)
+	//serialize
+	bufOut, err := b.serialize(6, "")
+	assert.Equal(nil, err)
+	assert.Equal(bufIn, bufOut)
+	// too short
+	err = b.decodeFromBytes(bufIn[:1], 6, "")
+	assert.NotEqual(nil, err)
+}
+
+func Test_VniUpdateBody(t *testing.T) {
+	assert := assert.New(t)
+	//decodeFromBytes
+	bufIn := make([]byte, 16)
+	binary.BigEndian.PutUint32(bufIn[0:], 10) //vni
+	copy(bufIn[4:], net.ParseIP("10.0.0.1").To4())
+	copy(bufIn[12:], net.ParseIP("239.1.1.1").To4())
+	b := &VniUpdateBody{API: VniAdd}
+	err := b.decodeFromBytes(bufIn, 6, "")
+	assert.Equal(nil, err)
+	assert.Equal(uint32(10), b.Vni)
+	assert.Equal("10.0.0.1", b.VtepIP.String())
+	assert.Equal("239.1.1.1", b.McastGroup.String())
+	//serialize
+	bufOut, err := b.serialize(6, "")
+	assert.Equal(nil, err)
+	assert.Equal(bufIn, bufOut)
+
+	// frr7.0 and 7.1 don't send the multicast group
+	b = &VniUpdateBody{API: VniAdd}
+	err = b.decodeFromBytes(bufIn[:12], 6, "")
+	assert.Equal(nil, err)
+	assert.Nil(b.McastGroup)
+
+	// VNI_DEL has only the VNI
+	b = &VniUpdateBody{API: VniDel}
+	err = b.decodeFromBytes(bufIn[:4], 6, "")
+	assert.Equal(nil, err)
+	bufOut, err = b.serialize(6, "")
+	assert.Equal(nil, err)
+	assert.Equal(bufIn[:4], bufOut)
+}
+
+func Test_MacIPUpdateBody(t *testing.T) {
+	assert := assert.New(t)
+	mac, _ := net.ParseMAC("aa:bb:cc:00:00:01")
+	for _, ip := range []net.IP{nil, net.ParseIP("192.168.0.1").To4(), net.ParseIP("2001:db8::1")} {
+		//decodeFromBytes
+		bufIn := make([]byte, 14)
+		binary.BigEndian.PutUint32(bufIn[0:], 10) //vni
+		copy(bufIn[4:], mac)
+		binary.BigEndian.PutUint32(bufIn[10:], uint32(len(ip)))
+		bufIn = append(bufIn, ip...)
+		bufIn = append(bufIn, byte(MacIPFlagSticky), 0, 0, 0, 3) //flags, seq
+		b := &MacIPUpdateBody{API: MacIPAdd}
+		err := b.decodeFromBytes(bufIn, 6, "")
+		assert.Equal(nil, err)
+		assert.Equal(mac, b.Mac)
+		assert.Equal(ip, b.IP)
+		assert.Equal(MacIPFlagSticky, b.Flags)
+		assert.Equal(uint32(3), b.Seq)
+		//serialize
+		bufOut, err := b.serialize(6, "")
+		assert.Equal(nil, err)
+		assert.Equal(bufIn, bufOut)
+	}
+
+	// invalid IP address length
+	bufIn := make([]byte, 18)
+	binary.BigEndian.PutUint32(bufIn[10:], 5)
+	b := &MacIPUpdateBody{API: MacIPAdd}
+	err := b.decodeFromBytes(bufIn, 6, "")
+	assert.NotEqual(nil, err)
+
+	// MACIP_DEL has the state instead of the flags and the sequence
+	bufIn = make([]byte, 18)
+	binary.BigEndian.PutUint32(bufIn[0:], 10) //vni
+	copy(bufIn[4:], mac)
+	binary.BigEndian.PutUint32(bufIn[14:], 1) //state
+	b = &MacIPUpdateBody{API: MacIPDel}
+	err = b.decodeFromBytes(bufIn, 6, "")
+	assert.Equal(nil, err)
+	assert.Equal(uint32(1), b.State)
+	bufOut, err := b.serialize(6, "")
+	assert.Equal(nil, err)
+	assert.Equal(bufIn, bufOut)
+}
+
+func Test_remoteVTEPBody(t *testing.T) {
+	assert := assert.New(t)
+	//decodeFromBytes
+	bufIn := make([]byte, 12)
+	binary.BigEndian.PutUint32(bufIn[0:], 10) //vni
+	copy(bufIn[4:], net.ParseIP("10.0.0.2").To4())
+	binary.BigEndian.PutUint32(bufIn[8:], uint32(VxlanFloodPIMSM))
+	b := &remoteVTEPBody{api: remoteVTEPAdd}
+	err := b.decodeFromBytes(bufIn, 6, "")
+	assert.Equal(nil, err)
+	assert.Equal(VxlanFloodPIMSM, b.floodControl)
+	//serialize
+	bufOut, err := b.serialize(6, "")
+	assert.Equal(nil, err)
+	assert.Equal(bufIn, bufOut)
+
+	// REMOTE_VTEP_DEL has no flood control
+	b.api = remoteVTEPDel
+	bufOut, err = b.serialize(6, "")
+	assert.Equal(nil, err)
+	assert.Equal(bufIn[:8], bufOut)
+
+	// IPv6 VTEPs aren't supported
+	b.vtepIP = net.ParseIP("2001:db8::1")
+	_, err = b.serialize(6, "")
+	assert.NotEqual(nil, err)
+}
+
+func Test_remoteMACIPBody(t *testing.T) {
+	assert := assert.New(t)
+	mac, _ := net.ParseMAC("aa:bb:cc:00:00:01")
+	//decodeFromBytes
+	bufIn := make([]byte, 14)
+	binary.BigEndian.PutUint32(bufIn[0:], 10) //vni
+	copy(bufIn[4:], mac)
+	binary.BigEndian.PutUint32(bufIn[10:], 4)
+	bufIn = append(bufIn, net.ParseIP("192.168.0.1").To4()...)
+	bufIn = append(bufIn, net.ParseIP("10.0.0.2").To4()...)
+	bufIn = append(bufIn, byte(MacIPFlagGateway), 0, 0, 0, 1) //flags, seq
+	b := &remoteMACIPBody{api: remoteMACIPAdd}
+	err := b.decodeFromBytes(bufIn, 6, "")
+	assert.Equal(nil, err)
+	assert.Equal("192.168.0.1", b.ip.String())
+	assert.Equal("10.0.0.2", b.vtepIP.String())
+	assert.Equal(MacIPFlagGateway, b.flags)
+	//serialize
+	bufOut, err := b.serialize(6, "")
+	assert.Equal(nil, err)
+	assert.Equal(bufIn, bufOut)
+
+	// REMOTE_MACIP_DEL has no flags and sequence
+	b.api = remoteMACIPDel
+	bufOut, err = b.serialize(6, "")
+	assert.Equal(nil, err)
+	assert.Equal(bufIn[:len(bufIn)-5], bufOut)
+}
+
+func Test_EvpnAPIType(t *testing.T) {
+	assert := assert.New(t)
+	for _, software := range []string{"", "frr7.2"} {
+		for _, command := range []APIType{advertiseAllVNI, VniAdd, VniDel, remoteVTEPAdd, remoteVTEPDel, MacIPAdd, MacIPDel, remoteMACIPAdd, remoteMACIPDel} {
+			backward := command.ToEach(6, software)
+			assert.NotEqual(zebraError, backward)
+			assert.Equal(command, backward.toCommon(6, software))
+		}
+	}
+	assert.Equal(APIType(63), VniAdd.ToEach(6, ""))
+	assert.Equal(APIType(62), VniAdd.ToEach(6, "frr7.2"))
+	assert.Equal(zebraError, VniAdd.ToEach(6, "frr7"))
+}
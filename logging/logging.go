@@ -0,0 +1,215 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logging routes the entries of the standard logrus logger to
+// the configured output, filtering them with the level of their Topic
+// field.
+package logging
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/ttsubo/goplane/config"
+)
+
+// hook writes the entries enabled by their topic level to out. The
+// standard logger itself runs at the most verbose level in use and
+// discards its output.
+type hook struct {
+	mu        sync.RWMutex
+	level     log.Level
+	topics    map[string]log.Level
+	formatter log.Formatter
+	out       output
+}
+
+func (h *hook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *hook) levelOf(e *log.Entry) log.Level {
+	if topic, ok := e.Data["Topic"].(string); ok {
+		if l, ok := h.topics[strings.ToLower(topic)]; ok {
+			return l
+		}
+	}
+	return h.level
+}
+
+func (h *hook) Fire(e *log.Entry) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if e.Level > h.levelOf(e) {
+		return nil
+	}
+	b, err := h.formatter.Format(e)
+	if err != nil {
+		return err
+	}
+	return h.out.Write(e, b)
+}
+
+// maxLevel returns the most verbose level in use.
+func (h *hook) maxLevel() log.Level {
+	l := h.level
+	for _, t := range h.topics {
+		if t > l {
+			l = t
+		}
+	}
+	return l
+}
+
+var (
+	mu      sync.Mutex
+	current *hook
+)
+
+func newFormatter(format string) (log.Formatter, error) {
+	switch format {
+	case "", "json":
+		return &log.JSONFormatter{}, nil
+	case "text":
+		return &log.TextFormatter{DisableColors: true}, nil
+	}
+	return nil, fmt.Errorf("invalid log format: %s", format)
+}
+
+func parseLevels(c *config.Logging) (log.Level, map[string]log.Level, error) {
+	level := log.InfoLevel
+	if c.Level != "" {
+		l, err := log.ParseLevel(c.Level)
+		if err != nil {
+			return 0, nil, err
+		}
+		level = l
+	}
+	topics := make(map[string]log.Level, len(c.Topics))
+	for topic, s := range c.Topics {
+		l, err := log.ParseLevel(s)
+		if err != nil {
+			return 0, nil, fmt.Errorf("topic %s: %s", topic, err)
+		}
+		topics[strings.ToLower(topic)] = l
+	}
+	return level, topics, nil
+}
+
+// Validate checks c without applying it.
+func Validate(c *config.Logging) error {
+	if _, _, err := parseLevels(c); err != nil {
+		return err
+	}
+	if _, err := newFormatter(c.Format); err != nil {
+		return err
+	}
+	switch c.Output {
+	case "", "stdout", "none", "journald":
+	case "syslog":
+		if _, err := syslogFacility(c.Syslog.Facility); err != nil {
+			return err
+		}
+	case "file":
+		if c.File.Path == "" {
+			return fmt.Errorf("log file path is not specified")
+		}
+	default:
+		return fmt.Errorf("invalid log output: %s", c.Output)
+	}
+	return nil
+}
+
+// Configure replaces the levels, the format and the output of the
+// standard logger. The previous output is closed. The current settings
+// are kept when c is invalid.
+func Configure(c *config.Logging) error {
+	if err := Validate(c); err != nil {
+		return err
+	}
+	level, topics, _ := parseLevels(c)
+	formatter, _ := newFormatter(c.Format)
+	out, err := newOutput(c)
+	if err != nil {
+		return err
+	}
+	h := &hook{
+		level:     level,
+		topics:    topics,
+		formatter: formatter,
+		out:       out,
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	logger := log.StandardLogger()
+	logger.SetOutput(ioutil.Discard)
+	logger.ReplaceHooks(log.LevelHooks{})
+	logger.AddHook(h)
+	logger.SetLevel(h.maxLevel())
+	if current != nil {
+		current.mu.Lock()
+		if err := current.out.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to close the log output: %s\n", err)
+		}
+		current.mu.Unlock()
+	}
+	current = h
+	return nil
+}
+
+// SetLevel changes the level of topic at runtime, or the default level
+// when topic is empty. The change lasts until the next Configure.
+func SetLevel(topic, level string) error {
+	l, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		return fmt.Errorf("logging is not configured")
+	}
+	current.mu.Lock()
+	if topic == "" {
+		current.level = l
+	} else {
+		current.topics[strings.ToLower(topic)] = l
+	}
+	max := current.maxLevel()
+	current.mu.Unlock()
+	log.SetLevel(max)
+	return nil
+}
+
+// Levels returns the default level and the levels of the topics.
+func Levels() (string, map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		return log.GetLevel().String(), nil
+	}
+	current.mu.RLock()
+	defer current.mu.RUnlock()
+	topics := make(map[string]string, len(current.topics))
+	for topic, l := range current.topics {
+		topics[topic] = l.String()
+	}
+	return current.level.String(), topics
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ttsubo/goplane/config"
+)
+
+func TestTopicLevels(t *testing.T) {
+	assert := assert.New(t)
+
+	level, topics, err := parseLevels(&config.Logging{
+		Level:  "warn",
+		Topics: map[string]string{"virtualnetwork": "debug"},
+	})
+	assert.Nil(err)
+	var buf bytes.Buffer
+	h := &hook{
+		level:     level,
+		topics:    topics,
+		formatter: &log.TextFormatter{DisableTimestamp: true, DisableColors: true},
+		out:       &writerOutput{w: &buf},
+	}
+	assert.Equal(log.DebugLevel, h.maxLevel())
+
+	logger := log.New()
+	logger.SetOutput(ioutil.Discard)
+	logger.SetLevel(h.maxLevel())
+	logger.AddHook(h)
+
+	logger.WithField("Topic", "VirtualNetwork").Debug("vn")
+	logger.WithField("Topic", "Peer").Info("peer")
+	logger.Info("no topic")
+	logger.Warn("warn")
+	assert.Equal("level=debug msg=vn Topic=VirtualNetwork\nlevel=warning msg=warn\n", buf.String())
+
+	_, _, err = parseLevels(&config.Logging{Topics: map[string]string{"peer": "verbose"}})
+	assert.NotNil(err)
+	assert.NotNil(Validate(&config.Logging{Output: "file"}))
+	assert.NotNil(Validate(&config.Logging{Output: "syslog", Syslog: config.LoggingSyslog{Facility: "local8"}}))
+	assert.Nil(Validate(&config.Logging{Output: "syslog", Format: "text"}))
+}
+
+func TestRotatingFile(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "goplane-logging")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "goplane.log")
+
+	f, err := openRotatingFile(path, 10, 2)
+	assert.Nil(err)
+	for _, m := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		assert.Nil(f.Write(nil, []byte(m)))
+	}
+	assert.Nil(f.Close())
+
+	for name, expected := range map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	} {
+		b, err := ioutil.ReadFile(name)
+		assert.Nil(err)
+		assert.Equal(expected, string(b))
+	}
+	_, err = os.Stat(path + ".3")
+	assert.True(os.IsNotExist(err))
+}
+
+func TestJournalMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	e := log.NewEntry(log.New()).WithFields(log.Fields{
+		"Topic": "VirtualNetwork",
+		"Key":   "10.0.0.1:10",
+	})
+	e.Level = log.WarnLevel
+	e.Message = "a\nb"
+	assert.Equal("MESSAGE\n\x03\x00\x00\x00\x00\x00\x00\x00a\nb\nPRIORITY=4\nSYSLOG_IDENTIFIER=goplane\nKEY=10.0.0.1:10\nTOPIC=VirtualNetwork\n", string(journalMessage(e)))
+}
@@ -0,0 +1,297 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/syslog"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/ttsubo/goplane/config"
+)
+
+type output interface {
+	// Write writes entry e formatted as b.
+	Write(e *log.Entry, b []byte) error
+	Close() error
+}
+
+func newOutput(c *config.Logging) (output, error) {
+	switch c.Output {
+	case "", "stdout":
+		return &writerOutput{w: os.Stdout}, nil
+	case "none":
+		return &writerOutput{w: ioutil.Discard}, nil
+	case "syslog":
+		return newSyslogOutput(&c.Syslog)
+	case "journald":
+		return newJournaldOutput(journalSocket)
+	case "file":
+		return openRotatingFile(c.File.Path, int64(c.File.MaxSize)<<20, int(c.File.MaxBackups))
+	}
+	return nil, fmt.Errorf("invalid log output: %s", c.Output)
+}
+
+type writerOutput struct {
+	w io.Writer
+}
+
+func (o *writerOutput) Write(e *log.Entry, b []byte) error {
+	_, err := o.w.Write(b)
+	return err
+}
+
+func (o *writerOutput) Close() error {
+	return nil
+}
+
+// rotatingFile is a log file renamed to path.1, path.2, ... when it
+// grows over maxSize bytes.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	f          *os.File
+	size       int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	r := &rotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	if r.maxBackups == 0 {
+		if err := os.Remove(r.path); err != nil {
+			return err
+		}
+		return r.open()
+	}
+	for i := r.maxBackups - 1; i > 0; i-- {
+		from := fmt.Sprintf("%s.%d", r.path, i)
+		if _, err := os.Stat(from); err == nil {
+			if err := os.Rename(from, fmt.Sprintf("%s.%d", r.path, i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) Write(e *log.Entry, b []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(b)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := r.f.Write(b)
+	r.size += int64(n)
+	return err
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
+
+var syslogFacilities = map[string]syslog.Priority{
+	"kern":   syslog.LOG_KERN,
+	"user":   syslog.LOG_USER,
+	"daemon": syslog.LOG_DAEMON,
+	"local0": syslog.LOG_LOCAL0,
+	"local1": syslog.LOG_LOCAL1,
+	"local2": syslog.LOG_LOCAL2,
+	"local3": syslog.LOG_LOCAL3,
+	"local4": syslog.LOG_LOCAL4,
+	"local5": syslog.LOG_LOCAL5,
+	"local6": syslog.LOG_LOCAL6,
+	"local7": syslog.LOG_LOCAL7,
+}
+
+func syslogFacility(name string) (syslog.Priority, error) {
+	if name == "" {
+		return syslog.LOG_DAEMON, nil
+	}
+	f, ok := syslogFacilities[name]
+	if !ok {
+		return 0, fmt.Errorf("invalid syslog facility: %s", name)
+	}
+	return f, nil
+}
+
+// syslogSeverity maps a logrus level to the syslog severity, which is
+// also used as the journald priority.
+func syslogSeverity(l log.Level) syslog.Priority {
+	switch l {
+	case log.PanicLevel, log.FatalLevel:
+		return syslog.LOG_CRIT
+	case log.ErrorLevel:
+		return syslog.LOG_ERR
+	case log.WarnLevel:
+		return syslog.LOG_WARNING
+	case log.InfoLevel:
+		return syslog.LOG_INFO
+	}
+	return syslog.LOG_DEBUG
+}
+
+type syslogOutput struct {
+	w *syslog.Writer
+}
+
+func newSyslogOutput(c *config.LoggingSyslog) (*syslogOutput, error) {
+	facility, err := syslogFacility(c.Facility)
+	if err != nil {
+		return nil, err
+	}
+	tag := c.Tag
+	if tag == "" {
+		tag = "goplane"
+	}
+	w, err := syslog.Dial(c.Network, c.Address, facility|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogOutput{w: w}, nil
+}
+
+func (o *syslogOutput) Write(e *log.Entry, b []byte) error {
+	m := string(b)
+	switch syslogSeverity(e.Level) {
+	case syslog.LOG_CRIT:
+		return o.w.Crit(m)
+	case syslog.LOG_ERR:
+		return o.w.Err(m)
+	case syslog.LOG_WARNING:
+		return o.w.Warning(m)
+	case syslog.LOG_INFO:
+		return o.w.Info(m)
+	}
+	return o.w.Debug(m)
+}
+
+func (o *syslogOutput) Close() error {
+	return o.w.Close()
+}
+
+const journalSocket = "/run/systemd/journal/socket"
+
+// journaldOutput sends entries with the native journal protocol. The
+// fields of an entry become journal fields, such as TOPIC.
+type journaldOutput struct {
+	conn *net.UnixConn
+}
+
+func newJournaldOutput(path string) (*journaldOutput, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journaldOutput{conn: conn}, nil
+}
+
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		}
+		return '_'
+	}, key)
+	return strings.TrimLeft(name, "_")
+}
+
+func appendJournalField(b *bytes.Buffer, name, value string) {
+	if name == "" {
+		return
+	}
+	if !strings.ContainsRune(value, '\n') {
+		b.WriteString(name + "=" + value + "\n")
+		return
+	}
+	// values with newlines are prefixed with their length
+	b.WriteString(name + "\n")
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value + "\n")
+}
+
+func journalMessage(e *log.Entry) []byte {
+	var b bytes.Buffer
+	appendJournalField(&b, "MESSAGE", e.Message)
+	appendJournalField(&b, "PRIORITY", strconv.Itoa(int(syslogSeverity(e.Level))))
+	appendJournalField(&b, "SYSLOG_IDENTIFIER", "goplane")
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		appendJournalField(&b, journalFieldName(k), fmt.Sprint(e.Data[k]))
+	}
+	return b.Bytes()
+}
+
+func (o *journaldOutput) Write(e *log.Entry, b []byte) error {
+	_, err := o.conn.Write(journalMessage(e))
+	return err
+}
+
+func (o *journaldOutput) Close() error {
+	return o.conn.Close()
+}
@@ -16,19 +16,25 @@
 package main
 
 import (
-	"io/ioutil"
+	"fmt"
 	"os"
 	"os/signal"
 	"runtime"
 	"syscall"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/jessevdk/go-flags"
 	log "github.com/sirupsen/logrus"
 	"github.com/ttsubo/goplane/config"
+	"github.com/ttsubo/goplane/dataplane"
 	"github.com/ttsubo/goplane/internal/pkg/apiutil"
 	"github.com/ttsubo/goplane/internal/pkg/table"
+	"github.com/ttsubo/goplane/logging"
+	"github.com/ttsubo/goplane/metrics"
 	"github.com/ttsubo/goplane/netlink"
+	_ "github.com/ttsubo/goplane/ovsdb"
+	_ "github.com/ttsubo/goplane/zebra"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 
@@ -38,12 +44,6 @@ import (
 	bgpserver "github.com/ttsubo/goplane/pkg/server"
 )
 
-type Dataplaner interface {
-	Serve() error
-	AddVirtualNetwork(config.VirtualNetwork) error
-	DeleteVirtualNetwork(config.VirtualNetwork) error
-}
-
 func marshalRouteTargets(l []string) ([]*any.Any, error) {
 	rtList := make([]*any.Any, 0, len(l))
 	for _, rtString := range l {
@@ -56,11 +56,104 @@ func marshalRouteTargets(l []string) ([]*any.Any, error) {
 	return rtList, nil
 }
 
+// shutdown stops the dataplane and the embedded BGP server, giving up
+// after the configured timeout. The dataplane goes first so that the
+// withdrawals caused by closing the sessions don't reach it. Cease is
+// sent to the peers only when the state is flushed. Otherwise the
+// sessions go down with the process without a NOTIFICATION, which the
+// peers with graceful restart take as a restart and keep our routes.
+func shutdown(bgpServer *bgpserver.BgpServer, dp dataplane.Dataplaner, c config.Shutdown) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if dp != nil {
+			if err := dp.Shutdown(c.FlushState); err != nil {
+				log.Warnf("failed to shutdown dataplane: %s", err)
+			}
+		}
+		if bgpServer != nil && c.FlushState {
+			if err := bgpServer.ShutdownPeer(context.Background(), &bgpapi.ShutdownPeerRequest{
+				Communication: "goplane is shutting down",
+			}); err != nil {
+				log.Debugf("failed to shutdown peers: %s", err)
+			}
+			if err := bgpServer.StopBgp(context.Background(), &bgpapi.StopBgpRequest{}); err != nil {
+				log.Warnf("failed to stop bgp: %s", err)
+			}
+		}
+	}()
+	select {
+	case <-done:
+		log.Info("goplane stopped")
+	case <-time.After(c.TimeoutDuration()):
+		log.Warnf("shutdown didn't complete in %s", c.TimeoutDuration())
+	}
+}
+
+// linkExistsFunc returns the function checking the interfaces used by
+// the dataplane of type typ. Only the netlink dataplane uses the
+// interfaces of this host.
+func linkExistsFunc(typ string) func(string) bool {
+	if typ == "netlink" {
+		return netlink.LinkExists
+	}
+	return nil
+}
+
+// validateVirtualNetworks checks the virtual networks l of a dataplane
+// of type typ after a change through the API.
+func validateVirtualNetworks(typ string, l []config.VirtualNetwork) error {
+	c := &config.Config{}
+	c.Dataplane.Type = typ
+	c.Dataplane.VirtualNetworkList = l
+	return config.Validate(c, linkExistsFunc(typ))
+}
+
+// validateConfig checks c including the sections applied outside of the
+// config package.
+func validateConfig(c *config.Config) error {
+	var l config.ValidationErrors
+	if t := c.Dataplane.Type; t != "" && !dataplane.HasBackend(t) {
+		l = append(l, &config.ValidationError{
+			Path: "dataplane.type",
+			Err:  fmt.Errorf("invalid dataplane type %q. available types: %v", t, dataplane.Backends()),
+		})
+	}
+	if err := config.Validate(c, linkExistsFunc(c.Dataplane.Type)); err != nil {
+		l = append(l, err.(config.ValidationErrors)...)
+	}
+	if err := logging.Validate(&c.Logging); err != nil {
+		l = append(l, &config.ValidationError{Path: "logging", Err: err})
+	}
+	if _, err := dataplane.NewServerCredentials(&c.API.TLS); err != nil {
+		l = append(l, &config.ValidationError{Path: "api.tls", Err: err})
+	}
+	if len(l) > 0 {
+		return l
+	}
+	return nil
+}
+
+// loggingConfig fills the logging settings missing in the config file
+// with the command line flags.
+func loggingConfig(c config.Logging, level string, plain, disableStdlog bool) *config.Logging {
+	if c.Level == "" {
+		c.Level = level
+	}
+	if c.Format == "" && plain {
+		c.Format = "text"
+	}
+	if c.Output == "" && disableStdlog {
+		c.Output = "none"
+	}
+	return &c
+}
+
 func main() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGHUP)
+	signal.Notify(sigCh, syscall.SIGHUP, syscall.SIGTERM, syscall.SIGINT)
 
 	var opts struct {
 		ConfigFile      string `short:"f" long:"config-file" description:"specifying a config file"`
@@ -70,38 +163,21 @@ func main() {
 		DisableStdlog   bool   `long:"disable-stdlog" description:"disable standard logging"`
 		GrpcHosts       string `long:"api-hosts" description:"specify the hosts that gobgpd listens on" default:":50051"`
 		Remote          bool   `short:"r" long:"remote-gobgp" description:"remote gobgp mode"`
-		GracefulRestart bool   `short:"g" long:"graceful-restart" description:"flag restart-state in graceful-restart capability"`
+		GracefulRestart bool   `short:"g" long:"graceful-restart" description:"flag restart-state in graceful-restart capability and keep the kernel routes and FDB entries of the previous run until End-of-RIB"`
+		DataplaneHosts  string `long:"dataplane-api-hosts" description:"specify the hosts that goplane API listens on (shares the gobgpd API listener if not specified)"`
+		MetricsHosts    string `long:"metrics-hosts" description:"specify the host that serves prometheus metrics (disabled if not specified)"`
+		TLSCertFile     string `long:"api-tls-cert" description:"certificate file of the API listeners (overrides [api.tls] in the config file)"`
+		TLSKeyFile      string `long:"api-tls-key" description:"key file of the API listeners"`
+		TLSClientCAFile string `long:"api-tls-client-ca" description:"CA file to verify API client certificates with (enables mutual TLS)"`
+		CheckConfig     bool   `long:"check-config" description:"validate the config file and exit"`
+		WatchConfig     bool   `long:"watch-config" description:"reload the config file when it changes"`
+		WatchDelay      uint   `long:"watch-config-delay" description:"seconds the config file has to stay unchanged before it is reloaded" default:"2"`
 	}
 	_, err := flags.Parse(&opts)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	switch opts.LogLevel {
-	case "debug":
-		log.SetLevel(log.DebugLevel)
-	case "info":
-		log.SetLevel(log.InfoLevel)
-	default:
-		log.SetLevel(log.InfoLevel)
-	}
-
-	if opts.DisableStdlog == true {
-		log.SetOutput(ioutil.Discard)
-	} else {
-		log.SetOutput(os.Stdout)
-	}
-
-	if opts.LogPlain {
-		if opts.DisableStdlog {
-			log.SetFormatter(&log.TextFormatter{
-				DisableColors: true,
-			})
-		}
-	} else {
-		log.SetFormatter(&log.JSONFormatter{})
-	}
-
 	if opts.ConfigFile == "" {
 		opts.ConfigFile = "goplane.conf"
 	}
@@ -110,18 +186,98 @@ func main() {
 	bgpConfigCh := make(chan *bgpconfig.BgpConfigSet)
 	reloadCh := make(chan bool)
 
+	// the API listeners start before the config file is served, so
+	// their TLS and tokens are read in advance
+	initialConfig, err := config.ReadConfigfile(opts.ConfigFile, opts.ConfigType)
+	if err != nil {
+		log.Fatalf("can't read config file %s: %s", opts.ConfigFile, err)
+	}
+	if opts.CheckConfig {
+		if err := validateConfig(initialConfig); err != nil {
+			fmt.Fprintf(os.Stderr, "%s is invalid:\n%s\n", opts.ConfigFile, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s is valid\n", opts.ConfigFile)
+		return
+	}
+	logConfig := func(c config.Logging) *config.Logging {
+		return loggingConfig(c, opts.LogLevel, opts.LogPlain, opts.DisableStdlog)
+	}
+	if err := logging.Configure(logConfig(initialConfig.Logging)); err != nil {
+		log.Fatalf("failed to configure logging: %s", err)
+	}
+	tlsConfig := initialConfig.API.TLS
+	if opts.TLSCertFile != "" {
+		tlsConfig.CertFile = opts.TLSCertFile
+		tlsConfig.KeyFile = opts.TLSKeyFile
+	}
+	if opts.TLSClientCAFile != "" {
+		tlsConfig.ClientCAFile = opts.TLSClientCAFile
+	}
+	authorizer := dataplane.NewAuthorizer()
+	authorizer.SetTokens(initialConfig.API.Tokens)
+	authOpts, err := authorizer.ServerOptions(&tlsConfig)
+	if err != nil {
+		log.Fatalf("failed to set up the API listeners: %s", err)
+	}
+
 	var bgpServer *bgpserver.BgpServer
 	maxSize := 256 << 20
 	grpcOpts := []grpc.ServerOption{grpc.MaxRecvMsgSize(maxSize), grpc.MaxSendMsgSize(maxSize)}
+	grpcOpts = append(grpcOpts, authOpts...)
+	apiServer := dataplane.NewServer()
 	if !opts.Remote {
 		log.Info("gobgpd started")
-		bgpServer = bgpserver.NewBgpServer(bgpserver.GrpcListenAddress(opts.GrpcHosts), bgpserver.GrpcOption(grpcOpts))
+		serverOpts := []bgpserver.ServerOption{bgpserver.GrpcListenAddress(opts.GrpcHosts), bgpserver.GrpcOption(grpcOpts)}
+		if opts.DataplaneHosts == "" {
+			serverOpts = append(serverOpts, bgpserver.GrpcRegister(apiServer.Register))
+		}
+		bgpServer = bgpserver.NewBgpServer(serverOpts...)
 		go bgpServer.Serve()
 	}
-	go config.ReadConfigfileServe(opts.ConfigFile, opts.ConfigType, configCh, bgpConfigCh, reloadCh)
+	if opts.DataplaneHosts != "" {
+		go func() {
+			if err := apiServer.Serve(opts.DataplaneHosts, grpcOpts); err != nil {
+				log.Fatalf("failed to listen goplane grpc port: %s", err)
+			}
+		}()
+	} else if opts.Remote {
+		log.Warn("goplane API is disabled. use --dataplane-api-hosts to enable it in remote mode")
+	}
+	var collector *metrics.Collector
+	if opts.MetricsHosts != "" {
+		collector = metrics.NewCollector()
+		if opts.Remote {
+			client, err := netlink.NewClient(opts.GrpcHosts, &initialConfig.API.Client)
+			if err != nil {
+				log.Fatalf("failed to connect to gobgpd: %s", err)
+			}
+			collector.SetPeerLister(metrics.PeerListerFromClient(client))
+		} else {
+			collector.SetPeerLister(metrics.PeerListerFromServer(bgpServer))
+		}
+		go func() {
+			if err := metrics.Serve(opts.MetricsHosts, collector); err != nil {
+				log.Fatalf("failed to serve metrics: %s", err)
+			}
+		}()
+	}
+	var watchDelay time.Duration
+	if opts.WatchConfig {
+		if opts.WatchDelay == 0 {
+			log.Fatal("--watch-config-delay must be at least one second")
+		}
+		watchDelay = time.Duration(opts.WatchDelay) * time.Second
+	}
+	go config.ReadConfigfileServe(opts.ConfigFile, opts.ConfigType, configCh, bgpConfigCh, reloadCh, validateConfig, watchDelay)
 	reloadCh <- true
+	apiServer.SetReloadFunc(func() error {
+		log.Info("reload the config file")
+		reloadCh <- true
+		return nil
+	})
 
-	var dataplane Dataplaner
+	var dp dataplane.Dataplaner
 	var d *config.Dataplane
 	var c *bgpconfig.BgpConfigSet
 	for {
@@ -162,7 +318,10 @@ func main() {
 				}
 
 				if len(newConfig.Collector.Config.Url) > 0 {
-					log.Fatal("collector feature is not supported")
+					cc := newConfig.Collector.Config
+					if _, err := bgpserver.NewCollector(bgpServer, cc.Url, cc.DbName, cc.TableDumpInterval); err != nil {
+						log.Fatalf("failed to set collector config: %s", err)
+					}
 				}
 
 				for _, c := range newConfig.RpkiServers {
@@ -398,32 +557,68 @@ func main() {
 			}
 
 		case newConfig := <-configCh:
-			if dataplane == nil {
-				switch newConfig.Dataplane.Type {
-				case "netlink":
-					log.Debug("new dataplane: netlink")
-					dataplane = netlink.NewDataplane(newConfig, opts.GrpcHosts, bgpServer)
-					go func() {
-						err := dataplane.Serve()
-						if err != nil {
-							log.Errorf("dataplane finished with err: %s", err)
-						}
-					}()
-				default:
-					log.Errorf("Invalid dataplane type(%s). dataplane engine can't be started", newConfig.Dataplane.Type)
+			authorizer.SetTokens(newConfig.API.Tokens)
+			if err := logging.Configure(logConfig(newConfig.Logging)); err != nil {
+				log.Warnf("failed to configure logging: %s", err)
+			}
+			if dp == nil {
+				var err error
+				dp, err = dataplane.New(newConfig, &dataplane.Options{
+					GrpcHost:   opts.GrpcHosts,
+					BgpServer:  bgpServer,
+					Restarting: opts.GracefulRestart,
+				})
+				if err != nil {
+					log.Errorf("dataplane engine can't be started: %s", err)
+					continue
+				}
+				log.Debugf("new dataplane: %s", newConfig.Dataplane.Type)
+				apiServer.SetDataplane(dp)
+				typ := newConfig.Dataplane.Type
+				apiServer.SetValidateFunc(func(l []config.VirtualNetwork) error {
+					return validateVirtualNetworks(typ, l)
+				})
+				if collector != nil {
+					collector.SetDataplane(dp)
 				}
+				go func() {
+					err := dp.Serve()
+					if err != nil {
+						log.Errorf("dataplane finished with err: %s", err)
+					}
+				}()
 			}
 
-			as, ds := config.UpdateConfig(d, newConfig.Dataplane)
+			as, ds, us := config.UpdateConfig(d, newConfig.Dataplane)
 			d = &newConfig.Dataplane
+			apiServer.SetConfigVirtualNetworks(d.VirtualNetworkList)
 
 			for _, v := range as {
-				log.Infof("VirtualNetwork %s is added", v.RD)
-				dataplane.AddVirtualNetwork(v)
+				log.Infof("VirtualNetwork %s is added", v.Key())
+				// the config file takes over a virtual network added
+				// through the API
+				if _, err := dp.GetVirtualNetwork(v.Key()); err == nil {
+					log.Warnf("VirtualNetwork %s added through the API is replaced by the config file", v.Key())
+					if err := dp.UpdateVirtualNetwork(v); err != nil {
+						log.Warn(err)
+					}
+					continue
+				}
+				if err := dp.AddVirtualNetwork(v); err != nil {
+					log.Warn(err)
+				}
 			}
 			for _, v := range ds {
-				log.Infof("VirtualNetwork %s is deleted", v.RD)
-				dataplane.DeleteVirtualNetwork(v)
+				log.Infof("VirtualNetwork %s is deleted", v.Key())
+				if err := dp.DeleteVirtualNetwork(v); err != nil {
+					log.Warn(err)
+				}
+			}
+			for _, v := range us {
+				log.Infof("VirtualNetwork %s is updated", v.Key())
+				if err := dp.UpdateVirtualNetwork(v); err != nil {
+					log.Warn(err)
+				}
 			}
 
 		case sig := <-sigCh:
@@ -431,6 +626,14 @@ func main() {
 			case syscall.SIGHUP:
 				log.Info("reload the config file")
 				reloadCh <- true
+			case syscall.SIGTERM, syscall.SIGINT:
+				log.Infof("%s received. shutting down", sig)
+				var sc config.Shutdown
+				if d != nil {
+					sc = d.Shutdown
+				}
+				shutdown(bgpServer, dp, sc)
+				return
 			}
 		}
 	}
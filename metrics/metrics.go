@@ -0,0 +1,253 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	api "github.com/osrg/gobgp/api"
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+	"github.com/ttsubo/goplane/dataplane"
+	"golang.org/x/net/context"
+
+	bgpserver "github.com/ttsubo/goplane/pkg/server"
+)
+
+const namespace = "goplane"
+
+// PeerLister calls fn for each BGP peer.
+type PeerLister func(ctx context.Context, fn func(*api.Peer)) error
+
+// PeerListerFromServer lists the peers of an embedded BGP server.
+func PeerListerFromServer(s *bgpserver.BgpServer) PeerLister {
+	return func(ctx context.Context, fn func(*api.Peer)) error {
+		return s.ListPeer(ctx, &api.ListPeerRequest{EnableAdvertised: true}, fn)
+	}
+}
+
+// PeerListerFromClient lists the peers of a remote gobgpd.
+func PeerListerFromClient(client api.GobgpApiClient) PeerLister {
+	return func(ctx context.Context, fn func(*api.Peer)) error {
+		stream, err := client.ListPeer(ctx, &api.ListPeerRequest{EnableAdvertised: true})
+		if err != nil {
+			return err
+		}
+		for {
+			r, err := stream.Recv()
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			fn(r.Peer)
+		}
+	}
+}
+
+var (
+	peerStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bgp", "peer_state"),
+		"BGP session state of the peer (0: unknown, 1: idle, 2: connect, 3: active, 4: opensent, 5: openconfirm, 6: established).",
+		[]string{"peer"}, nil)
+	peerUptimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bgp", "peer_uptime_seconds"),
+		"Seconds since the BGP session was established.",
+		[]string{"peer"}, nil)
+	peerReceivedPrefixesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bgp", "peer_received_prefixes"),
+		"Number of prefixes received from the peer.",
+		[]string{"peer", "family"}, nil)
+	peerAcceptedPrefixesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bgp", "peer_accepted_prefixes"),
+		"Number of prefixes received from the peer and accepted by the import policy.",
+		[]string{"peer", "family"}, nil)
+	peerAdvertisedPrefixesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bgp", "peer_advertised_prefixes"),
+		"Number of prefixes advertised to the peer.",
+		[]string{"peer", "family"}, nil)
+	peerMessagesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bgp", "peer_messages_total"),
+		"Number of BGP messages exchanged with the peer.",
+		[]string{"peer", "direction", "type"}, nil)
+
+	routesInstalledDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dataplane", "routes_installed"),
+		"Number of kernel routes installed by goplane.",
+		nil, nil)
+	ribErrorsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dataplane", "rib_errors_total"),
+		"Number of routes of the BGP best paths which failed to be installed to or deleted from the kernel.",
+		nil, nil)
+	queueDepthDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dataplane", "queue_depth"),
+		"Number of events queued in an internal channel of the dataplane.",
+		[]string{"queue"}, nil)
+	vnFdbEntriesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "vn", "fdb_entries"),
+		"Number of remote MAC addresses installed to the FDB.",
+		[]string{"name", "vni"}, nil)
+	vnRemoteVtepsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "vn", "remote_vteps"),
+		"Number of remote VTEPs of the virtual network.",
+		[]string{"name", "vni"}, nil)
+	vnFloodPacketsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "vn", "flood_packets_total"),
+		"Number of VXLAN packets flooded to remote VTEPs.",
+		[]string{"name", "vni"}, nil)
+	vnFloodBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "vn", "flood_bytes_total"),
+		"Number of bytes flooded to remote VTEPs, including the VXLAN header.",
+		[]string{"name", "vni"}, nil)
+	vnQueueDepthDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "vn", "queue_depth"),
+		"Number of events queued in an internal channel of the virtual network.",
+		[]string{"name", "vni", "queue"}, nil)
+)
+
+// Collector is a prometheus.Collector exporting the BGP peers and the
+// dataplane state. Both are queried on each scrape.
+type Collector struct {
+	mu        sync.RWMutex
+	peers     PeerLister
+	dataplane dataplane.Dataplaner
+}
+
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+func (c *Collector) SetPeerLister(l PeerLister) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.peers = l
+}
+
+func (c *Collector) SetDataplane(d dataplane.Dataplaner) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dataplane = d
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		peerStateDesc, peerUptimeDesc, peerReceivedPrefixesDesc, peerAcceptedPrefixesDesc,
+		peerAdvertisedPrefixesDesc, peerMessagesDesc, routesInstalledDesc, ribErrorsDesc,
+		queueDepthDesc, vnFdbEntriesDesc, vnRemoteVtepsDesc, vnFloodPacketsDesc,
+		vnFloodBytesDesc, vnQueueDepthDesc,
+	} {
+		ch <- d
+	}
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	peers, dp := c.peers, c.dataplane
+	c.mu.RUnlock()
+
+	if peers != nil {
+		if err := peers(context.Background(), func(p *api.Peer) {
+			collectPeer(ch, p)
+		}); err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Metrics",
+			}).Warnf("failed to list peers: %s", err)
+		}
+	}
+
+	if dp != nil {
+		s, err := dp.GetStatistics()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Metrics",
+			}).Warnf("failed to get dataplane statistics: %s", err)
+			return
+		}
+		collectDataplane(ch, s)
+	}
+}
+
+func collectPeer(ch chan<- prometheus.Metric, p *api.Peer) {
+	if p.State == nil {
+		return
+	}
+	addr := p.State.NeighborAddress
+	ch <- prometheus.MustNewConstMetric(peerStateDesc, prometheus.GaugeValue, float64(p.State.SessionState), addr)
+
+	var uptime float64
+	if p.State.SessionState == api.PeerState_ESTABLISHED && p.Timers != nil && p.Timers.State != nil && p.Timers.State.Uptime != nil {
+		uptime = time.Since(time.Unix(p.Timers.State.Uptime.Seconds, 0)).Seconds()
+	}
+	ch <- prometheus.MustNewConstMetric(peerUptimeDesc, prometheus.GaugeValue, uptime, addr)
+
+	for _, a := range p.AfiSafis {
+		if a.State == nil || a.State.Family == nil {
+			continue
+		}
+		family := bgp.AfiSafiToRouteFamily(uint16(a.State.Family.Afi), uint8(a.State.Family.Safi)).String()
+		ch <- prometheus.MustNewConstMetric(peerReceivedPrefixesDesc, prometheus.GaugeValue, float64(a.State.Received), addr, family)
+		ch <- prometheus.MustNewConstMetric(peerAcceptedPrefixesDesc, prometheus.GaugeValue, float64(a.State.Accepted), addr, family)
+		ch <- prometheus.MustNewConstMetric(peerAdvertisedPrefixesDesc, prometheus.GaugeValue, float64(a.State.Advertised), addr, family)
+	}
+
+	if m := p.State.Messages; m != nil {
+		for direction, msg := range map[string]*api.Message{"received": m.Received, "sent": m.Sent} {
+			if msg == nil {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(peerMessagesDesc, prometheus.CounterValue, float64(msg.Update), addr, direction, "update")
+			ch <- prometheus.MustNewConstMetric(peerMessagesDesc, prometheus.CounterValue, float64(msg.Notification), addr, direction, "notification")
+		}
+	}
+}
+
+func collectDataplane(ch chan<- prometheus.Metric, s *dataplane.Statistics) {
+	ch <- prometheus.MustNewConstMetric(routesInstalledDesc, prometheus.GaugeValue, float64(s.RoutesInstalled))
+	ch <- prometheus.MustNewConstMetric(ribErrorsDesc, prometheus.CounterValue, float64(s.RibErrors))
+	for q, n := range s.QueueDepths {
+		ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(n), q)
+	}
+	for _, v := range s.VirtualNetworks {
+		vni := strconv.Itoa(int(v.VNI))
+		ch <- prometheus.MustNewConstMetric(vnFdbEntriesDesc, prometheus.GaugeValue, float64(v.FdbEntries), v.Name, vni)
+		ch <- prometheus.MustNewConstMetric(vnRemoteVtepsDesc, prometheus.GaugeValue, float64(v.RemoteVteps), v.Name, vni)
+		ch <- prometheus.MustNewConstMetric(vnFloodPacketsDesc, prometheus.CounterValue, float64(v.FloodPackets), v.Name, vni)
+		ch <- prometheus.MustNewConstMetric(vnFloodBytesDesc, prometheus.CounterValue, float64(v.FloodBytes), v.Name, vni)
+		for q, n := range v.QueueDepths {
+			ch <- prometheus.MustNewConstMetric(vnQueueDepthDesc, prometheus.GaugeValue, float64(n), v.Name, vni, q)
+		}
+	}
+}
+
+// Serve serves the metrics of c on /metrics at addr.
+func Serve(addr string, c *Collector) error {
+	r := prometheus.NewRegistry()
+	if err := r.Register(c); err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(r, promhttp.HandlerOpts{}))
+	log.WithFields(log.Fields{
+		"Topic": "Metrics",
+	}).Infof("serve metrics on %s", addr)
+	return http.ListenAndServe(addr, mux)
+}
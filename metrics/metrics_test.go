@@ -0,0 +1,202 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	api "github.com/osrg/gobgp/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/ttsubo/goplane/dataplane"
+	"github.com/ttsubo/goplane/internal/pkg/testutil"
+	"golang.org/x/net/context"
+)
+
+// fakeDataplane returns stats from GetStatistics, or err.
+type fakeDataplane struct {
+	dataplane.Dataplaner
+	stats *dataplane.Statistics
+	err   error
+}
+
+func (d *fakeDataplane) GetStatistics() (*dataplane.Statistics, error) {
+	return d.stats, d.err
+}
+
+// scrape returns the samples served for c, keyed by the metric name
+// with its labels.
+func scrape(t *testing.T, c *Collector) map[string]float64 {
+	r := prometheus.NewRegistry()
+	if err := r.Register(c); err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	promhttp.HandlerFor(r, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("scrape failed: %d %s", w.Code, w.Body)
+	}
+	samples := map[string]float64{}
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, " ")
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		samples[line[:i]] = v
+	}
+	return samples
+}
+
+func TestCollectPeer(t *testing.T) {
+	assert := assert.New(t)
+
+	gobgp := testutil.NewGobgpClient()
+	gobgp.AddPeer(&api.Peer{
+		State: &api.PeerState{
+			NeighborAddress: "10.0.0.2",
+			SessionState:    api.PeerState_ESTABLISHED,
+			Messages: &api.Messages{
+				Received: &api.Message{Update: 10, Notification: 1},
+				Sent:     &api.Message{Update: 20},
+			},
+		},
+		Timers: &api.Timers{
+			State: &api.TimersState{
+				Uptime: &timestamp.Timestamp{Seconds: time.Now().Add(-time.Minute).Unix()},
+			},
+		},
+		AfiSafis: []*api.AfiSafi{
+			{State: &api.AfiSafiState{
+				Family:     &api.Family{Afi: api.Family_AFI_IP, Safi: api.Family_SAFI_UNICAST},
+				Received:   3,
+				Accepted:   2,
+				Advertised: 1,
+			}},
+			// the families without a state are skipped
+			{},
+		},
+	})
+	gobgp.AddPeer(&api.Peer{
+		State: &api.PeerState{
+			NeighborAddress: "10.0.0.3",
+			SessionState:    api.PeerState_ACTIVE,
+		},
+	})
+	// a peer without a state isn't exported
+	gobgp.AddPeer(&api.Peer{})
+
+	c := NewCollector()
+	c.SetPeerLister(PeerListerFromClient(gobgp))
+	samples := scrape(t, c)
+	assert.Len(samples, 11)
+
+	assert.Equal(float64(6), samples[`goplane_bgp_peer_state{peer="10.0.0.2"}`])
+	assert.True(samples[`goplane_bgp_peer_uptime_seconds{peer="10.0.0.2"}`] >= 60)
+	assert.Equal(float64(3), samples[`goplane_bgp_peer_received_prefixes{family="ipv4-unicast",peer="10.0.0.2"}`])
+	assert.Equal(float64(2), samples[`goplane_bgp_peer_accepted_prefixes{family="ipv4-unicast",peer="10.0.0.2"}`])
+	assert.Equal(float64(1), samples[`goplane_bgp_peer_advertised_prefixes{family="ipv4-unicast",peer="10.0.0.2"}`])
+	for k, v := range map[string]float64{
+		`direction="received",peer="10.0.0.2",type="update"`:       10,
+		`direction="received",peer="10.0.0.2",type="notification"`: 1,
+		`direction="sent",peer="10.0.0.2",type="update"`:           20,
+		`direction="sent",peer="10.0.0.2",type="notification"`:     0,
+	} {
+		assert.Equal(v, samples[fmt.Sprintf("goplane_bgp_peer_messages_total{%s}", k)], k)
+	}
+
+	// the uptime of a peer not established is zero
+	assert.Equal(float64(3), samples[`goplane_bgp_peer_state{peer="10.0.0.3"}`])
+	uptime, ok := samples[`goplane_bgp_peer_uptime_seconds{peer="10.0.0.3"}`]
+	assert.True(ok)
+	assert.Equal(float64(0), uptime)
+}
+
+func TestCollectDataplane(t *testing.T) {
+	assert := assert.New(t)
+
+	d := &fakeDataplane{
+		stats: &dataplane.Statistics{
+			RoutesInstalled: 5,
+			RibErrors:       2,
+			QueueDepths:     map[string]int{"rib": 1},
+			VirtualNetworks: []dataplane.VirtualNetworkStatistics{
+				{
+					Name:         "65000:10",
+					VNI:          10,
+					FdbEntries:   4,
+					RemoteVteps:  2,
+					FloodPackets: 100,
+					FloodBytes:   6400,
+					QueueDepths:  map[string]int{"flood": 3},
+				},
+			},
+		},
+	}
+	c := NewCollector()
+	c.SetDataplane(d)
+	samples := scrape(t, c)
+	assert.Equal(map[string]float64{
+		`goplane_dataplane_routes_installed`:                             5,
+		`goplane_dataplane_rib_errors_total`:                             2,
+		`goplane_dataplane_queue_depth{queue="rib"}`:                     1,
+		`goplane_vn_fdb_entries{name="65000:10",vni="10"}`:               4,
+		`goplane_vn_remote_vteps{name="65000:10",vni="10"}`:              2,
+		`goplane_vn_flood_packets_total{name="65000:10",vni="10"}`:       100,
+		`goplane_vn_flood_bytes_total{name="65000:10",vni="10"}`:         6400,
+		`goplane_vn_queue_depth{name="65000:10",queue="flood",vni="10"}`: 3,
+	}, samples)
+
+	// a failing dataplane isn't exported, and doesn't fail the scrape
+	d.err = fmt.Errorf("dataplane is not running")
+	assert.Len(scrape(t, c), 0)
+}
+
+func TestCollect(t *testing.T) {
+	assert := assert.New(t)
+
+	// nothing is exported until the peers and the dataplane are set
+	c := NewCollector()
+	assert.Len(scrape(t, c), 0)
+
+	gobgp := testutil.NewGobgpClient()
+	gobgp.AddPeer(&api.Peer{State: &api.PeerState{NeighborAddress: "10.0.0.2"}})
+	c.SetPeerLister(PeerListerFromClient(gobgp))
+	c.SetDataplane(&fakeDataplane{stats: &dataplane.Statistics{}})
+	samples := scrape(t, c)
+	assert.Len(samples, 4)
+	assert.Contains(samples, `goplane_bgp_peer_state{peer="10.0.0.2"}`)
+	assert.Contains(samples, `goplane_dataplane_routes_installed`)
+
+	// the dataplane is exported when listing the peers fails
+	c.SetPeerLister(func(ctx context.Context, fn func(*api.Peer)) error {
+		return fmt.Errorf("connection refused")
+	})
+	samples = scrape(t, c)
+	assert.Len(samples, 2)
+	assert.Contains(samples, `goplane_dataplane_rib_errors_total`)
+}
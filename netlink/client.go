@@ -0,0 +1,166 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netlink
+
+import (
+	"io"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	api "github.com/osrg/gobgp/api"
+	log "github.com/sirupsen/logrus"
+	"github.com/ttsubo/goplane/config"
+	"github.com/ttsubo/goplane/dataplane"
+	"gopkg.in/tomb.v2"
+)
+
+// a broken MonitorTable stream is re-established after watchRetryInterval
+const watchRetryInterval = time.Second
+
+// Client is the connection to gobgpd shared by the dataplane and all
+// the virtual networks. gRPC reconnects it in the background, so it
+// stays usable across gobgpd restarts.
+type Client struct {
+	api.GobgpApiClient
+	conn *grpc.ClientConn
+}
+
+// NewClient returns a client of the gobgpd listening on target, using
+// the TLS and token settings of c. It doesn't wait for gobgpd to be
+// reachable.
+func NewClient(target string, c *config.APIClient) (*Client, error) {
+	if target == "" {
+		target = ":50051"
+	}
+	opts, err := dataplane.NewClientDialOptions(c)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.Dial(target, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		GobgpApiClient: api.NewGobgpApiClient(conn),
+		conn:           conn,
+	}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// WatchBest calls fn for each best path of family in the global table,
+// starting with the current ones, until t is dying. When the stream
+// breaks, e.g. because gobgpd restarted, it is re-established and
+// resync is called before the paths are replayed so that the caller can
+// re-add what gobgpd may have lost.
+func (c *Client) WatchBest(t *tomb.Tomb, family *api.Family, resync func(), fn func(*api.Path)) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-t.Dying():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	first := true
+	for {
+		err := c.watchBest(ctx, family, func() {
+			if !first {
+				resync()
+			}
+			first = false
+		}, fn)
+		select {
+		case <-t.Dying():
+			return nil
+		default:
+		}
+		log.WithFields(log.Fields{
+			"Topic":  "Client",
+			"Family": family,
+		}).Warnf("lost the best path stream from gobgpd: %s. retry in %s", err, watchRetryInterval)
+		select {
+		case <-t.Dying():
+			return nil
+		case <-time.After(watchRetryInterval):
+		}
+	}
+}
+
+func (c *Client) watchBest(ctx context.Context, family *api.Family, subscribed func(), fn func(*api.Path)) error {
+	stream, err := c.MonitorTable(ctx, &api.MonitorTableRequest{
+		TableType: api.TableType_GLOBAL,
+		Family:    family,
+		Current:   true,
+	}, grpc.WaitForReady(true))
+	if err != nil {
+		return err
+	}
+	subscribed()
+	for {
+		r, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		fn(r.Path)
+	}
+}
+
+// endOfRibReceived returns true when all the peers are established and
+// sent End-of-RIB for the address families used by the dataplane.
+func (c *Client) endOfRibReceived() (bool, error) {
+	stream, err := c.ListPeer(context.Background(), &api.ListPeerRequest{})
+	if err != nil {
+		return false, err
+	}
+	done := true
+	for {
+		r, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return false, err
+		}
+		p := r.Peer
+		if p.State == nil || p.State.SessionState != api.PeerState_ESTABLISHED {
+			done = false
+			continue
+		}
+		if p.GracefulRestart != nil && p.GracefulRestart.LocalRestarting {
+			done = false
+			continue
+		}
+		for _, a := range p.AfiSafis {
+			if a.Config == nil || !a.Config.Enabled || a.Config.Family == nil {
+				continue
+			}
+			f := a.Config.Family
+			if !(f.Afi == api.Family_AFI_IP && f.Safi == api.Family_SAFI_UNICAST) && !(f.Afi == api.Family_AFI_L2VPN && f.Safi == api.Family_SAFI_EVPN) {
+				continue
+			}
+			if a.MpGracefulRestart == nil || a.MpGracefulRestart.State == nil || !a.MpGracefulRestart.State.EndOfRibReceived {
+				done = false
+			}
+		}
+	}
+	return done, nil
+}
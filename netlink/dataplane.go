@@ -17,11 +17,14 @@ package netlink
 
 import (
 	"fmt"
+	"io"
 	"net"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/net/context"
-	"google.golang.org/grpc"
 
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/any"
@@ -29,6 +32,7 @@ import (
 	"github.com/osrg/gobgp/pkg/packet/bgp"
 	log "github.com/sirupsen/logrus"
 	"github.com/ttsubo/goplane/config"
+	"github.com/ttsubo/goplane/dataplane"
 	"github.com/ttsubo/goplane/internal/pkg/apiutil"
 	bgpconfig "github.com/ttsubo/goplane/internal/pkg/config"
 	"github.com/ttsubo/goplane/internal/pkg/table"
@@ -37,36 +41,52 @@ import (
 	"gopkg.in/tomb.v2"
 )
 
+// administrative distances used when DefaultRouteDistance isn't
+// configured
+const (
+	defaultExternalRouteDistance = 20
+	defaultInternalRouteDistance = 200
+)
+
+type mgmtOp struct {
+	f     func() error
+	errCh chan error
+}
+
 type Dataplane struct {
 	t         tomb.Tomb
 	config    *config.Config
 	modRibCh  chan []*table.Path
 	advPathCh chan *table.Path
 	vnMap     map[string]*VirtualNetwork
-	addVnCh   chan config.VirtualNetwork
-	delVnCh   chan config.VirtualNetwork
+	vnWg      sync.WaitGroup
+	mgmtCh    chan *mgmtOp
 	grpcHost  string
 	bgpServer *bgpserver.BgpServer
-	client    api.GobgpApiClient
+	kernel    Kernel
+	client    *Client
+	resyncCh  chan struct{}
 	routerId  string
 	localAS   uint32
+	ribErrors uint64
+	// restarting is true until stale routes restored on a graceful
+	// restart are swept
+	restarting  bool
+	staleRoutes map[string]*netlink.Route
+	// the neighbors redistributed as host routes, keyed by the IP
+	neighCh     chan *neighEvent
+	neighLinks  map[int]string
+	hostRoutes  map[string]*netlink.Neigh
+	communities []uint32
+	// watchT is killed on shutdown to stop installing the best paths
+	watchT tomb.Tomb
+	// the virtual networks stopped but maybe still tearing down their
+	// links, keyed by the config key
+	stopping map[string]*VirtualNetwork
 }
 
-func NewClient(target string, ctx context.Context) (api.GobgpApiClient, context.CancelFunc, error) {
-	grpcOpts := []grpc.DialOption{grpc.WithBlock()}
-	grpcOpts = append(grpcOpts, grpc.WithInsecure())
-	if target == "" {
-		target = ":50051"
-	}
-	cc, cancel := context.WithTimeout(ctx, time.Second)
-	conn, err := grpc.DialContext(cc, target, grpcOpts...)
-	if err != nil {
-		return nil, cancel, err
-	}
-	return api.NewGobgpApiClient(conn), cancel, nil
-}
-
-func toPathApi(path *table.Path, v *table.Validation) *api.Path {
+// ToPathApi converts path to be added through the gobgpd API.
+func ToPathApi(path *table.Path, v *table.Validation) *api.Path {
 	nlri := path.GetNlri()
 	anyNlri := apiutil.MarshalNLRI(nlri)
 	anyPattrs := apiutil.MarshalPathAttributes(path.GetPathAttrs())
@@ -107,7 +127,8 @@ func ToApiFamily(afi uint16, safi uint8) *api.Family {
 	}
 }
 
-func getNextHopFromPathAttributes(attrs []bgp.PathAttributeInterface) net.IP {
+// GetNextHopFromPathAttributes returns the nexthop of a path, or nil.
+func GetNextHopFromPathAttributes(attrs []bgp.PathAttributeInterface) net.IP {
 	for _, attr := range attrs {
 		switch a := attr.(type) {
 		case *bgp.PathAttributeNextHop:
@@ -125,11 +146,11 @@ func (d *Dataplane) getNexthop(path *api.Path) (int, net.IP, int) {
 		return 0, nil, flags
 	}
 	attrs, _ := apiutil.GetNativePathAttributes(path)
-	nh := getNextHopFromPathAttributes(attrs)
+	nh := GetNextHopFromPathAttributes(attrs)
 	if nh.To4() != nil {
 		return 0, nh.To4(), flags
 	}
-	list, err := netlink.NeighList(0, netlink.FAMILY_V6)
+	list, err := d.kernel.NeighList(0, netlink.FAMILY_V6)
 	if err != nil {
 		log.Errorf("failed to get neigh list: %s", err)
 		return 0, nil, flags
@@ -145,7 +166,7 @@ func (d *Dataplane) getNexthop(path *api.Path) (int, net.IP, int) {
 		log.Warnf("no neighbor info for %s", path)
 		return 0, nil, flags
 	}
-	list, err = netlink.NeighList(neigh.LinkIndex, netlink.FAMILY_V4)
+	list, err = d.kernel.NeighList(neigh.LinkIndex, netlink.FAMILY_V4)
 	if err != nil {
 		log.Errorf("failed to get neigh list: %s", err)
 		return 0, nil, flags
@@ -157,7 +178,7 @@ func (d *Dataplane) getNexthop(path *api.Path) (int, net.IP, int) {
 		}
 	}
 	nh = net.IPv4(169, 254, 0, 1)
-	err = netlink.NeighAdd(&netlink.Neigh{
+	err = d.kernel.NeighAdd(&netlink.Neigh{
 		LinkIndex:    neigh.LinkIndex,
 		State:        netlink.NUD_PERMANENT,
 		IP:           nh,
@@ -169,35 +190,104 @@ func (d *Dataplane) getNexthop(path *api.Path) (int, net.IP, int) {
 	return neigh.LinkIndex, nh, flags
 }
 
+// routeProtocol returns the protocol number marking the kernel routes
+// installed by goplane.
+func (d *Dataplane) routeProtocol() int {
+	return int(d.config.Dataplane.RouteProtocolNumber())
+}
+
+// routeMetric returns the metric of the kernel route for path. The
+// administrative distance of eBGP or iBGP routes is used as the metric
+// so that they compare against routes from other protocols the same
+// way as in other routing daemons.
+func (d *Dataplane) routeMetric(path *table.Path) int {
+	distance := d.config.BGP.Global.DefaultRouteDistance.Config
+	if path.IsIBGP() {
+		if distance.InternalRouteDistance != 0 {
+			return int(distance.InternalRouteDistance)
+		}
+		return defaultInternalRouteDistance
+	}
+	if distance.ExternalRouteDistance != 0 {
+		return int(distance.ExternalRouteDistance)
+	}
+	return defaultExternalRouteDistance
+}
+
+// delStaleRoutes deletes the routes to route.Dst installed by goplane
+// with a metric other than route.Priority. They are left behind when
+// the best path changes between eBGP and iBGP.
+func (d *Dataplane) delStaleRoutes(route *netlink.Route) error {
+	routes, err := d.kernel.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Dst: route.Dst}, netlink.RT_FILTER_DST)
+	if err != nil {
+		return err
+	}
+	for _, r := range routes {
+		if r.Protocol != d.routeProtocol() || r.Priority == route.Priority {
+			continue
+		}
+		log.Info("del stale route:", r)
+		if err := d.kernel.RouteDel(&r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// delRoutes deletes the routes to dest installed by goplane. The other
+// routes to dest, such as static or OSPF ones, are left as they are.
+func (d *Dataplane) delRoutes(dest string) error {
+	delete(d.staleRoutes, dest)
+	dst, _ := netlink.ParseIPNet(dest)
+	routes, err := d.kernel.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Dst: dst, Protocol: d.routeProtocol()}, netlink.RT_FILTER_DST|netlink.RT_FILTER_PROTOCOL)
+	if err != nil {
+		return err
+	}
+	for i := range routes {
+		log.Info("del route:", routes[i])
+		if err := d.kernel.RouteDel(&routes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// modRib applies the best paths to the kernel. A failure on a route
+// doesn't keep the other routes of paths from being applied, and counts
+// as one RIB error.
 func (d *Dataplane) modRib(paths []*table.Path) error {
 	routingInfo := make(map[string][]*table.Path)
 	var routes []*netlink.Route
+	var errs []string
 	for _, p := range paths {
+		// the local paths, such as the host routes of the neighbors,
+		// aren't installed
+		if ToPathApi(p, nil).NeighborIp == "<nil>" {
+			continue
+		}
 		dest := p.GetNlri().String()
-		routingInfo[dest] = append(routingInfo[dest], p)
 		if p.IsWithdraw {
-			dst, _ := netlink.ParseIPNet(dest)
-			route := &netlink.Route{
-				Dst: dst,
-				Src: net.ParseIP(d.routerId),
+			delete(routingInfo, dest)
+			if err := d.delRoutes(dest); err != nil {
+				errs = append(errs, fmt.Sprintf("failed to del route to %s: %s", dest, err))
 			}
-			log.Info("del route:", route)
-			return netlink.RouteDel(route)
+			continue
 		}
+		routingInfo[dest] = append(routingInfo[dest], p)
 	}
 
 	for dest, paths := range routingInfo {
+		delete(d.staleRoutes, dest)
 		dst, _ := netlink.ParseIPNet(dest)
 		route := &netlink.Route{
-			Dst: dst,
-			Src: net.ParseIP(d.routerId),
+			Dst:      dst,
+			Src:      net.ParseIP(d.routerId),
+			Protocol: d.routeProtocol(),
+			Priority: d.routeMetric(paths[0]),
 		}
 
 		if len(paths) == 1 {
-			if toPathApi(paths[0], nil).NeighborIp == "<nil>" {
-				return nil
-			}
-			link, gw, flags := d.getNexthop(toPathApi(paths[0], nil))
+			link, gw, flags := d.getNexthop(ToPathApi(paths[0], nil))
 			route.Gw = gw
 			route.LinkIndex = link
 			route.Flags = flags
@@ -205,10 +295,7 @@ func (d *Dataplane) modRib(paths []*table.Path) error {
 		} else {
 			mp := make([]*netlink.NexthopInfo, 0, len(paths))
 			for _, path := range paths {
-				if toPathApi(path, nil).NeighborIp == "<nil>" {
-					continue
-				}
-				link, gw, flags := d.getNexthop(toPathApi(path, nil))
+				link, gw, flags := d.getNexthop(ToPathApi(path, nil))
 				mp = append(mp, &netlink.NexthopInfo{
 					Gw:        gw,
 					LinkIndex: link,
@@ -221,14 +308,218 @@ func (d *Dataplane) modRib(paths []*table.Path) error {
 	}
 	for _, route := range routes {
 		log.Info("add route:", route)
-		if err := netlink.RouteReplace(route); err != nil {
-			return err
+		if err := d.kernel.RouteReplace(route); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to add route to %s: %s", route.Dst, err))
+			continue
+		}
+		if err := d.delStaleRoutes(route); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to del stale routes to %s: %s", route.Dst, err))
 		}
 	}
+	if len(errs) > 0 {
+		d.ribErrors += uint64(len(errs))
+		return fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
 	return nil
 }
 
+func matchNexthops(bgpNexthops, kernelNexthops []net.IP) bool {
+	for _, nh := range bgpNexthops {
+		// IPv6 nexthops are installed via a link-local IPv4
+		// address. see getNexthop()
+		if nh.To4() == nil {
+			continue
+		}
+		found := false
+		for _, knh := range kernelNexthops {
+			if nh.Equal(knh) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *Dataplane) getFibDiff() ([]dataplane.FibDiff, error) {
+	stream, err := d.client.ListPath(context.Background(), &api.ListPathRequest{
+		TableType: api.TableType_GLOBAL,
+		Family:    ToApiFamily(bgp.AFI_IP, bgp.SAFI_UNICAST),
+	})
+	if err != nil {
+		return nil, err
+	}
+	bgpRoutes := make(map[string][]net.IP)
+	for {
+		r, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		for _, p := range r.Destination.Paths {
+			if !p.Best || p.NeighborIp == "<nil>" {
+				continue
+			}
+			attrs, _ := apiutil.GetNativePathAttributes(p)
+			bgpRoutes[r.Destination.Prefix] = append(bgpRoutes[r.Destination.Prefix], GetNextHopFromPathAttributes(attrs))
+		}
+	}
+
+	routes, err := d.kernel.RouteList(nil, netlink.FAMILY_V4)
+	if err != nil {
+		return nil, err
+	}
+	kernelRoutes := make(map[string][]net.IP)
+	for _, route := range routes {
+		if route.Protocol != d.routeProtocol() {
+			continue
+		}
+		prefix := "0.0.0.0/0"
+		if route.Dst != nil {
+			prefix = route.Dst.String()
+		}
+		nexthops := []net.IP{}
+		if route.Gw != nil {
+			nexthops = append(nexthops, route.Gw)
+		}
+		for _, nh := range route.MultiPath {
+			nexthops = append(nexthops, nh.Gw)
+		}
+		kernelRoutes[prefix] = nexthops
+	}
+
+	diffs := []dataplane.FibDiff{}
+	for prefix, nexthops := range bgpRoutes {
+		knexthops, ok := kernelRoutes[prefix]
+		if !ok {
+			diffs = append(diffs, dataplane.FibDiff{
+				Type:        dataplane.FIB_DIFF_MISSING,
+				Prefix:      prefix,
+				BgpNexthops: nexthops,
+			})
+		} else if !matchNexthops(nexthops, knexthops) {
+			diffs = append(diffs, dataplane.FibDiff{
+				Type:           dataplane.FIB_DIFF_MISMATCH,
+				Prefix:         prefix,
+				BgpNexthops:    nexthops,
+				KernelNexthops: knexthops,
+			})
+		}
+	}
+	for prefix, knexthops := range kernelRoutes {
+		if _, ok := bgpRoutes[prefix]; !ok {
+			diffs = append(diffs, dataplane.FibDiff{
+				Type:           dataplane.FIB_DIFF_STALE,
+				Prefix:         prefix,
+				KernelNexthops: knexthops,
+			})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Prefix < diffs[j].Prefix
+	})
+	return diffs, nil
+}
+
+// markStaleRoutes keeps the routes installed before a graceful restart
+// or a reconnection to gobgpd until they are refreshed or swept.
+func (d *Dataplane) markStaleRoutes() error {
+	routes, err := d.kernel.RouteList(nil, netlink.FAMILY_V4)
+	if err != nil {
+		return err
+	}
+	d.staleRoutes = make(map[string]*netlink.Route)
+	for i, route := range routes {
+		if route.Protocol != d.routeProtocol() {
+			continue
+		}
+		prefix := "0.0.0.0/0"
+		if route.Dst != nil {
+			prefix = route.Dst.String()
+		}
+		d.staleRoutes[prefix] = &routes[i]
+	}
+	log.WithFields(log.Fields{
+		"Topic": "Dataplane",
+	}).Infof("%d routes are marked stale", len(d.staleRoutes))
+	return nil
+}
+
+// sweepStale deletes the stale routes that haven't been refreshed, and
+// the FDB entries restored on a graceful restart.
+func (d *Dataplane) sweepStale() {
+	for prefix, route := range d.staleRoutes {
+		log.WithFields(log.Fields{
+			"Topic": "Dataplane",
+		}).Infof("sweep stale route to %s", prefix)
+		if err := d.kernel.RouteDel(route); err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Dataplane",
+			}).Warnf("failed to del stale route to %s: %s", prefix, err)
+		}
+	}
+	d.staleRoutes = nil
+	if d.restarting {
+		d.restarting = false
+		for _, vn := range d.vnMap {
+			go vn.SweepStale()
+		}
+	}
+}
+
+// toTablePath converts a best path received from a remote gobgpd.
+func (d *Dataplane) toTablePath(p *api.Path) (*table.Path, error) {
+	nlri, err := apiutil.GetNativeNlri(p)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := apiutil.GetNativePathAttributes(p)
+	if err != nil {
+		return nil, err
+	}
+	source := &table.PeerInfo{
+		AS:      p.SourceAsn,
+		ID:      net.ParseIP(p.SourceId),
+		LocalAS: d.localAS,
+		Address: net.ParseIP(p.NeighborIp),
+	}
+	return table.NewPath(source, nlri, p.IsWithdraw, attrs, time.Now(), false), nil
+}
+
+// watchBest installs the best paths of a remote gobgpd. When the stream
+// is re-established, the installed routes are marked stale and the
+// local paths are advertised again before the current best paths are
+// replayed.
+func (d *Dataplane) watchBest() error {
+	family := ToApiFamily(bgp.AFI_IP, bgp.SAFI_UNICAST)
+	return d.client.WatchBest(&d.watchT, family, func() {
+		select {
+		case d.resyncCh <- struct{}{}:
+		case <-d.watchT.Dying():
+		}
+	}, func(p *api.Path) {
+		path, err := d.toTablePath(p)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Dataplane",
+			}).Warnf("failed to parse path: %s", err)
+			return
+		}
+		select {
+		case d.modRibCh <- []*table.Path{path}:
+		case <-d.watchT.Dying():
+		}
+	})
+}
+
 func (d *Dataplane) monitorBest() error {
+	if d.bgpServer == nil {
+		return d.watchBest()
+	}
 	w := d.bgpServer.Watch(bgpserver.WatchBestPath(true))
 
 	go func() {
@@ -239,24 +530,28 @@ func (d *Dataplane) monitorBest() error {
 		family := bgp.AfiSafiToRouteFamily(bgp.AFI_IP, bgp.SAFI_UNICAST)
 		exitCurrentLoop := false
 		for {
-			ev := <-w.Event()
 			var paths []*table.Path
-			switch msg := ev.(type) {
-			case *bgpserver.WatchEventBestPath:
-				if len(msg.MultiPathList) > 0 {
-					l := make([]*table.Path, 0)
-					for _, p := range msg.MultiPathList {
-						l = append(l, p...)
+			select {
+			case ev := <-w.Event():
+				switch msg := ev.(type) {
+				case *bgpserver.WatchEventBestPath:
+					if len(msg.MultiPathList) > 0 {
+						l := make([]*table.Path, 0)
+						for _, p := range msg.MultiPathList {
+							l = append(l, p...)
+						}
+						paths = l
+						log.Debug("## msg.MultiPathList", paths)
+					} else {
+						paths = msg.PathList
+						log.Debug("## msg.PathList", paths)
 					}
-					paths = l
-					log.Debug("## msg.MultiPathList", paths)
-				} else {
+				case *bgpserver.WatchEventUpdate:
 					paths = msg.PathList
-					log.Debug("## msg.PathList", paths)
+					log.Debug("## msg.PathList2", paths)
 				}
-			case *bgpserver.WatchEventUpdate:
-				paths = msg.PathList
-				log.Debug("## msg.PathList2", paths)
+			case <-d.watchT.Dying():
+				return
 			}
 			for _, path := range paths {
 				if path == nil || family != path.GetRouteFamily() {
@@ -265,7 +560,11 @@ func (d *Dataplane) monitorBest() error {
 				}
 			}
 			if exitCurrentLoop == false {
-				d.modRibCh <- paths
+				select {
+				case d.modRibCh <- paths:
+				case <-d.watchT.Dying():
+					return
+				}
 			} else {
 				exitCurrentLoop = false
 			}
@@ -274,38 +573,38 @@ func (d *Dataplane) monitorBest() error {
 	return nil
 }
 
+func (d *Dataplane) routerIdPath() *table.Path {
+	return table.NewPath(nil, bgp.NewIPAddrPrefix(uint8(32), d.routerId), false, []bgp.PathAttributeInterface{
+		bgp.NewPathAttributeNextHop("0.0.0.0"),
+		bgp.NewPathAttributeOrigin(bgp.BGP_ORIGIN_ATTR_TYPE_IGP),
+	}, time.Now(), false)
+}
+
 func (d *Dataplane) Serve() error {
+	client, err := NewClient(d.grpcHost, &d.config.API.Client)
+	if err != nil {
+		return fmt.Errorf("failed to create gobgpd client: %s", err)
+	}
+	defer client.Close()
+	d.client = client
+
 	for {
-		var s *bgpconfig.Global
-		ctx := context.Background()
-		client, cancel, err := NewClient(d.grpcHost, ctx)
-		if err != nil {
-			cancel()
-			log.Errorf("%s", err)
-			goto ERR
-		}
-		d.client = client
-		s = d.GetServer()
-		if err != nil {
-			log.Errorf("%s", err)
-			goto ERR
-		}
+		s := d.GetServer()
 		d.routerId = s.Config.RouterId
 		d.localAS = s.Config.As
 		if d.routerId != "" && d.localAS != 0 {
 			break
 		}
-	ERR:
 		log.Debug("BGP server is not ready..waiting...")
 		time.Sleep(time.Second * 10)
 	}
 
-	lo, err := netlink.LinkByName("lo")
+	lo, err := d.kernel.LinkByName("lo")
 	if err != nil {
 		return fmt.Errorf("failed to get lo")
 	}
 
-	addrList, err := netlink.AddrList(lo, netlink.FAMILY_ALL)
+	addrList, err := d.kernel.AddrList(lo, netlink.FAMILY_ALL)
 	if err != nil {
 		return fmt.Errorf("failed to get addr list of lo")
 	}
@@ -324,17 +623,35 @@ func (d *Dataplane) Serve() error {
 
 	if !exist {
 		log.Debugf("add route to lo")
-		err = netlink.AddrAdd(lo, addr)
+		err = d.kernel.AddrAdd(lo, addr)
 		if err != nil {
 			return fmt.Errorf("failed to add addr %s to lo", addr)
 		}
 	}
 
-	d.advPathCh <- table.NewPath(nil, bgp.NewIPAddrPrefix(uint8(32), d.routerId), false, []bgp.PathAttributeInterface{
-		bgp.NewPathAttributeNextHop("0.0.0.0"),
-		bgp.NewPathAttributeOrigin(bgp.BGP_ORIGIN_ATTR_TYPE_IGP),
-	}, time.Now(), false)
+	d.advPathCh <- d.routerIdPath()
 	time.Sleep(time.Second * 10)
+
+	var refreshCh <-chan time.Time
+	if r := d.config.Dataplane.RedistributeNeighbor; r.Enabled() {
+		if err := d.startRedistributeNeighbor(); err != nil {
+			return fmt.Errorf("failed to redistribute neighbors: %s", err)
+		}
+		refresh := time.NewTicker(r.RefreshDuration())
+		defer refresh.Stop()
+		refreshCh = refresh.C
+	}
+
+	// the End-of-RIB check runs until stale entries are swept
+	var eorCh, staleCh <-chan time.Time
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	if d.restarting {
+		if err := d.markStaleRoutes(); err != nil {
+			return fmt.Errorf("failed to mark stale routes: %s", err)
+		}
+		eorCh, staleCh = ticker.C, time.After(d.config.Dataplane.StaleTime())
+	}
 	go d.monitorBest()
 
 	for {
@@ -342,7 +659,47 @@ func (d *Dataplane) Serve() error {
 		case <-d.t.Dying():
 			log.Error("dying! ", d.t.Err())
 			return nil
+		case <-d.resyncCh:
+			log.WithFields(log.Fields{
+				"Topic": "Dataplane",
+			}).Info("reconnected to gobgpd. advertise the local paths again")
+			if _, err := d.AddPath([]*table.Path{d.routerIdPath()}); err != nil {
+				log.Error("failed to adv path: ", err)
+			}
+			if err := d.advertiseHostRoutes(false); err != nil {
+				log.Error("failed to adv host routes: ", err)
+			}
+			if err := d.markStaleRoutes(); err != nil {
+				log.Warnf("failed to mark stale routes: %s", err)
+				continue
+			}
+			eorCh, staleCh = ticker.C, time.After(d.config.Dataplane.StaleTime())
+		case <-eorCh:
+			done, err := d.client.endOfRibReceived()
+			if err != nil {
+				log.Warnf("failed to check End-of-RIB: %s", err)
+				continue
+			}
+			if done && len(d.modRibCh) == 0 {
+				log.WithFields(log.Fields{
+					"Topic": "Dataplane",
+				}).Info("End-of-RIB received from all peers")
+				d.sweepStale()
+				eorCh, staleCh = nil, nil
+			}
+		case <-staleCh:
+			log.WithFields(log.Fields{
+				"Topic": "Dataplane",
+			}).Warn("stale timer expired")
+			d.sweepStale()
+			eorCh, staleCh = nil, nil
 		case paths := <-d.modRibCh:
+			// the paths queued before the shutdown are dropped
+			select {
+			case <-d.watchT.Dying():
+				continue
+			default:
+			}
 			err = d.modRib(paths)
 			if err != nil {
 				log.Error("failed to mod rib: ", err)
@@ -352,18 +709,103 @@ func (d *Dataplane) Serve() error {
 			if err != nil {
 				log.Error("failed to adv path: ", err)
 			}
-		case v := <-d.addVnCh:
-			vn := NewVirtualNetwork(v, d.routerId, d.grpcHost)
-			d.vnMap[v.RD] = vn
-			d.t.Go(vn.Serve)
-		case v := <-d.delVnCh:
-			vn := d.vnMap[v.RD]
-			vn.Stop()
-			delete(d.vnMap, v.RD)
+		case e := <-d.neighCh:
+			if err := d.modHostRoute(&e.neigh, e.deleted); err != nil {
+				log.Error("failed to mod host route: ", err)
+			}
+		case <-refreshCh:
+			d.refreshNeighbors()
+		case op := <-d.mgmtCh:
+			op.errCh <- op.f()
 		}
 	}
 }
 
+func (d *Dataplane) mgmtOperation(f func() error) error {
+	op := &mgmtOp{
+		f:     f,
+		errCh: make(chan error, 1),
+	}
+	select {
+	case d.mgmtCh <- op:
+	case <-d.t.Dying():
+		return fmt.Errorf("dataplane is not running")
+	}
+	return <-op.errCh
+}
+
+func (d *Dataplane) addVirtualNetwork(c config.VirtualNetwork) error {
+	if _, ok := d.vnMap[c.Key()]; ok {
+		return fmt.Errorf("VirtualNetwork %s already exists", c.Key())
+	}
+	vn := NewVirtualNetwork(c, d.routerId, d.localAS, d.client, d.restarting, d.config.Dataplane.StaleTime(), d.config.Dataplane.DuplicateMac, d.kernel)
+	// a virtual network recreated with the same key starts after the
+	// old one has released the links
+	if old, ok := d.stopping[c.Key()]; ok {
+		vn.prevDoneCh = old.doneCh
+		delete(d.stopping, c.Key())
+	}
+	d.vnMap[c.Key()] = vn
+	// the virtual networks don't run in d.t, which would die with the
+	// last of them
+	d.vnWg.Add(1)
+	go func() {
+		defer d.vnWg.Done()
+		if err := vn.Serve(); err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Dataplane",
+			}).Errorf("VirtualNetwork %s finished with err: %s", c.Key(), err)
+		}
+	}()
+	return nil
+}
+
+// stopVirtualNetwork stops vn without waiting for it to tear down.
+func (d *Dataplane) stopVirtualNetwork(vn *VirtualNetwork) {
+	vn.Stop()
+	delete(d.vnMap, vn.config.Key())
+	d.stopping[vn.config.Key()] = vn
+}
+
+func (d *Dataplane) deleteVirtualNetwork(c config.VirtualNetwork) error {
+	vn, ok := d.vnMap[c.Key()]
+	if !ok {
+		return fmt.Errorf("VirtualNetwork %s doesn't exist", c.Key())
+	}
+	d.stopVirtualNetwork(vn)
+	return nil
+}
+
+func (d *Dataplane) updateVirtualNetwork(c config.VirtualNetwork) error {
+	vn, ok := d.vnMap[c.Key()]
+	if !ok {
+		log.Warnf("VirtualNetwork %s doesn't exist. add it", c.Key())
+		return d.addVirtualNetwork(c)
+	}
+	if !vn.config.NeedsRecreate(&c) {
+		vn.Update(c)
+		return nil
+	}
+	log.Infof("VirtualNetwork %s needs to be recreated", c.Key())
+	d.stopVirtualNetwork(vn)
+	return d.addVirtualNetwork(c)
+}
+
+// listVirtualNetwork returns the running virtual networks. They are
+// queried outside of the dataplane loop so that a virtual network still
+// setting up its links doesn't block it.
+func (d *Dataplane) listVirtualNetwork() ([]*VirtualNetwork, error) {
+	var l []*VirtualNetwork
+	err := d.mgmtOperation(func() error {
+		l = make([]*VirtualNetwork, 0, len(d.vnMap))
+		for _, vn := range d.vnMap {
+			l = append(l, vn)
+		}
+		return nil
+	})
+	return l, err
+}
+
 func (d *Dataplane) addPath(vrfID string, pathList []*table.Path) ([]byte, error) {
 	resource := api.TableType_GLOBAL
 	if vrfID != "" {
@@ -374,7 +816,7 @@ func (d *Dataplane) addPath(vrfID string, pathList []*table.Path) ([]byte, error
 		r, err := d.client.AddPath(context.Background(), &api.AddPathRequest{
 			TableType: resource,
 			VrfId:     vrfID,
-			Path:      toPathApi(path, nil),
+			Path:      ToPathApi(path, nil),
 		})
 		if err != nil {
 			return nil, err
@@ -399,28 +841,209 @@ func (d *Dataplane) GetServer() *bgpconfig.Global {
 }
 
 func (d *Dataplane) AddVirtualNetwork(c config.VirtualNetwork) error {
-	d.addVnCh <- c
-	return nil
+	return d.mgmtOperation(func() error {
+		return d.addVirtualNetwork(c)
+	})
 }
 
 func (d *Dataplane) DeleteVirtualNetwork(c config.VirtualNetwork) error {
-	d.delVnCh <- c
+	return d.mgmtOperation(func() error {
+		return d.deleteVirtualNetwork(c)
+	})
+}
+
+func (d *Dataplane) UpdateVirtualNetwork(c config.VirtualNetwork) error {
+	return d.mgmtOperation(func() error {
+		return d.updateVirtualNetwork(c)
+	})
+}
+
+func (d *Dataplane) ListVirtualNetwork() ([]config.VirtualNetwork, error) {
+	vns, err := d.listVirtualNetwork()
+	if err != nil {
+		return nil, err
+	}
+	l := make([]config.VirtualNetwork, 0, len(vns))
+	for _, vn := range vns {
+		l = append(l, vn.State().Config)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Key() < l[j].Key()
+	})
+	return l, nil
+}
+
+func (d *Dataplane) GetFibDiff() ([]dataplane.FibDiff, error) {
+	var diffs []dataplane.FibDiff
+	err := d.mgmtOperation(func() error {
+		var err error
+		diffs, err = d.getFibDiff()
+		return err
+	})
+	return diffs, err
+}
+
+func (d *Dataplane) GetStatistics() (*dataplane.Statistics, error) {
+	var vns []*VirtualNetwork
+	s := &dataplane.Statistics{}
+	err := d.mgmtOperation(func() error {
+		s.RibErrors = d.ribErrors
+		s.QueueDepths = map[string]int{
+			"modrib":  len(d.modRibCh),
+			"advpath": len(d.advPathCh),
+		}
+		vns = make([]*VirtualNetwork, 0, len(d.vnMap))
+		for _, vn := range d.vnMap {
+			vns = append(vns, vn)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := d.kernel.RouteList(nil, netlink.FAMILY_V4)
+	if err != nil {
+		return nil, err
+	}
+	for _, route := range routes {
+		if route.Protocol == d.routeProtocol() {
+			s.RoutesInstalled++
+		}
+	}
+
+	s.VirtualNetworks = make([]dataplane.VirtualNetworkStatistics, 0, len(vns))
+	for _, vn := range vns {
+		s.VirtualNetworks = append(s.VirtualNetworks, *vn.Statistics())
+	}
+	sort.Slice(s.VirtualNetworks, func(i, j int) bool {
+		return s.VirtualNetworks[i].Name < s.VirtualNetworks[j].Name
+	})
+	return s, nil
+}
+
+func (d *Dataplane) flushRoutes() error {
+	routes, err := d.kernel.RouteList(nil, netlink.FAMILY_V4)
+	if err != nil {
+		return err
+	}
+	for _, route := range routes {
+		if route.Protocol != d.routeProtocol() {
+			continue
+		}
+		log.Info("del route:", route)
+		if err := d.kernel.RouteDel(&route); err != nil {
+			log.Warnf("failed to del route: %s", err)
+		}
+	}
+	return nil
+}
+
+// Shutdown stops installing the best paths first, so that the
+// withdrawals caused by stopping BGP don't reach the kernel. Unless
+// flush is true, the routes of the virtual networks and the host routes
+// stay advertised for a graceful restart.
+func (d *Dataplane) Shutdown(flush bool) error {
+	d.watchT.Kill(nil)
+	var vns []*VirtualNetwork
+	err := d.mgmtOperation(func() error {
+		vns = make([]*VirtualNetwork, 0, len(d.vnMap))
+		for _, vn := range d.vnMap {
+			vns = append(vns, vn)
+			vn.keepRoutes = !flush
+			d.stopVirtualNetwork(vn)
+		}
+		if !flush {
+			return nil
+		}
+		return d.advertiseHostRoutes(true)
+	})
+	if err != nil {
+		return err
+	}
+	// the virtual networks deleted before are waited for as well
+	d.vnWg.Wait()
+	if flush {
+		for _, vn := range vns {
+			if err := vn.deleteLinks(); err != nil {
+				log.Warnf("failed to delete links of VirtualNetwork %s: %s", vn.config.Key(), err)
+			}
+		}
+		if err := d.flushRoutes(); err != nil {
+			return err
+		}
+	}
+	d.t.Kill(nil)
 	return nil
 }
 
-func NewDataplane(c *config.Config, grpcHost string, bgpServer *bgpserver.BgpServer) *Dataplane {
+func (d *Dataplane) GetVirtualNetwork(name string) (*dataplane.VirtualNetworkState, error) {
+	vns, err := d.listVirtualNetwork()
+	if err != nil {
+		return nil, err
+	}
+	for _, vn := range vns {
+		if vn.config.Key() == name {
+			return vn.State(), nil
+		}
+	}
+	return nil, fmt.Errorf("VirtualNetwork %s doesn't exist", name)
+}
+
+func (d *Dataplane) ClearDuplicateMac(name string, mac net.HardwareAddr) error {
+	vns, err := d.listVirtualNetwork()
+	if err != nil {
+		return err
+	}
+	for _, vn := range vns {
+		if vn.config.Key() == name {
+			return vn.ClearDuplicateMac(mac)
+		}
+	}
+	return fmt.Errorf("VirtualNetwork %s doesn't exist", name)
+}
+
+// LinkExists reports whether the interface name exists. It is used to
+// validate the config.
+func LinkExists(name string) bool {
+	_, err := netlink.LinkByName(name)
+	return err == nil
+}
+
+func init() {
+	dataplane.RegisterBackend("netlink", func(c *config.Config, o *dataplane.Options) (dataplane.Dataplaner, error) {
+		return NewDataplane(c, o, NewKernel()), nil
+	})
+	// the fake dataplane runs the same logic on a FakeKernel, so it
+	// needs no privileges and leaves the system untouched
+	dataplane.RegisterBackend("fake", func(c *config.Config, o *dataplane.Options) (dataplane.Dataplaner, error) {
+		return NewDataplane(c, o, NewFakeKernel()), nil
+	})
+}
+
+// NewDataplane creates a dataplane programming kernel. When
+// o.Restarting is true, the kernel routes and FDB entries installed by
+// the previous goplane process are kept until they are refreshed by
+// BGP or swept.
+func NewDataplane(c *config.Config, o *dataplane.Options, kernel Kernel) *Dataplane {
 	modRibCh := make(chan []*table.Path, 16)
 	advPathCh := make(chan *table.Path, 16)
-	addVnCh := make(chan config.VirtualNetwork)
-	delVnCh := make(chan config.VirtualNetwork)
+	mgmtCh := make(chan *mgmtOp)
+	resyncCh := make(chan struct{})
 	return &Dataplane{
-		config:    c,
-		modRibCh:  modRibCh,
-		advPathCh: advPathCh,
-		addVnCh:   addVnCh,
-		delVnCh:   delVnCh,
-		vnMap:     make(map[string]*VirtualNetwork),
-		grpcHost:  grpcHost,
-		bgpServer: bgpServer,
+		config:     c,
+		modRibCh:   modRibCh,
+		advPathCh:  advPathCh,
+		mgmtCh:     mgmtCh,
+		resyncCh:   resyncCh,
+		vnMap:      make(map[string]*VirtualNetwork),
+		stopping:   make(map[string]*VirtualNetwork),
+		grpcHost:   o.GrpcHost,
+		bgpServer:  o.BgpServer,
+		kernel:     kernel,
+		restarting: o.Restarting,
+		neighCh:    make(chan *neighEvent, 16),
+		neighLinks: make(map[int]string),
+		hostRoutes: make(map[string]*netlink.Neigh),
 	}
 }
@@ -0,0 +1,238 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netlink
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	"github.com/stretchr/testify/assert"
+	"github.com/ttsubo/goplane/config"
+	"github.com/ttsubo/goplane/dataplane"
+	"github.com/ttsubo/goplane/internal/pkg/apiutil"
+	"github.com/ttsubo/goplane/internal/pkg/table"
+	"github.com/ttsubo/goplane/internal/pkg/testutil"
+	"github.com/vishvananda/netlink"
+)
+
+func TestRouteMetric(t *testing.T) {
+	assert := assert.New(t)
+
+	newPath := func(as uint32) *table.Path {
+		source := &table.PeerInfo{
+			AS:      as,
+			LocalAS: 65000,
+			Address: net.ParseIP("10.0.0.2"),
+		}
+		return table.NewPath(source, bgp.NewIPAddrPrefix(24, "10.1.0.0"), false, []bgp.PathAttributeInterface{
+			bgp.NewPathAttributeNextHop("10.0.0.2"),
+		}, time.Now(), false)
+	}
+	ebgp, ibgp := newPath(65001), newPath(65000)
+
+	d := NewDataplane(&config.Config{}, &dataplane.Options{}, NewFakeKernel())
+	assert.Equal(defaultExternalRouteDistance, d.routeMetric(ebgp))
+	assert.Equal(defaultInternalRouteDistance, d.routeMetric(ibgp))
+
+	distance := &d.config.BGP.Global.DefaultRouteDistance.Config
+	distance.ExternalRouteDistance = 30
+	distance.InternalRouteDistance = 150
+	assert.Equal(30, d.routeMetric(ebgp))
+	assert.Equal(150, d.routeMetric(ibgp))
+}
+
+func TestModRib(t *testing.T) {
+	assert := assert.New(t)
+
+	newPath := func(as uint32, withdraw bool) *table.Path {
+		source := &table.PeerInfo{
+			AS:      as,
+			LocalAS: 65000,
+			Address: net.ParseIP("10.0.0.2"),
+		}
+		return table.NewPath(source, bgp.NewIPAddrPrefix(24, "10.1.0.0"), withdraw, []bgp.PathAttributeInterface{
+			bgp.NewPathAttributeNextHop("10.0.0.2"),
+		}, time.Now(), false)
+	}
+
+	k := NewFakeKernel()
+	d := NewDataplane(&config.Config{}, &dataplane.Options{}, k)
+	d.routerId = "10.0.0.1"
+
+	assert.Nil(d.modRib([]*table.Path{newPath(65001, false)}))
+	routes, err := k.RouteList(nil, netlink.FAMILY_V4)
+	assert.Nil(err)
+	assert.Len(routes, 1)
+	assert.Equal("10.1.0.0/24", routes[0].Dst.String())
+	assert.Equal("10.0.0.2", routes[0].Gw.String())
+	assert.Equal(config.DefaultRouteProtocol, int(routes[0].Protocol))
+	assert.Equal(defaultExternalRouteDistance, routes[0].Priority)
+
+	// the route with the eBGP metric is replaced
+	assert.Nil(d.modRib([]*table.Path{newPath(65000, false)}))
+	routes, _ = k.RouteList(nil, netlink.FAMILY_V4)
+	assert.Len(routes, 1)
+	assert.Equal(defaultInternalRouteDistance, routes[0].Priority)
+
+	// a withdrawal deletes the route of goplane only, whatever comes
+	// first in the kernel
+	dst, _ := netlink.ParseIPNet("10.1.0.0/24")
+	static := netlink.Route{Dst: dst, Gw: net.ParseIP("10.0.0.3"), Protocol: syscall.RTPROT_STATIC}
+	k.routes = append([]netlink.Route{static}, k.routes...)
+	assert.Nil(d.modRib([]*table.Path{newPath(65000, true)}))
+	routes, _ = k.RouteList(nil, netlink.FAMILY_V4)
+	assert.Len(routes, 1)
+	assert.Equal(syscall.RTPROT_STATIC, int(routes[0].Protocol))
+
+	// the paths following a withdrawal in a batch are applied too
+	other := table.NewPath(&table.PeerInfo{AS: 65001, LocalAS: 65000, Address: net.ParseIP("10.0.0.2")}, bgp.NewIPAddrPrefix(24, "10.2.0.0"), false, []bgp.PathAttributeInterface{
+		bgp.NewPathAttributeNextHop("10.0.0.2"),
+	}, time.Now(), false)
+	assert.Nil(d.modRib([]*table.Path{newPath(65001, false), newPath(65001, true), other}))
+	routes, _ = k.RouteList(nil, netlink.FAMILY_V4)
+	assert.Len(routes, 2)
+	assert.Equal(uint64(0), d.ribErrors)
+
+	// each route failing to be installed counts as a RIB error
+	d.kernel = routeReplaceFailingKernel{k}
+	assert.NotNil(d.modRib([]*table.Path{newPath(65001, false), other}))
+	assert.Equal(uint64(2), d.ribErrors)
+}
+
+// routeReplaceFailingKernel fails to install any route.
+type routeReplaceFailingKernel struct {
+	*FakeKernel
+}
+
+func (k routeReplaceFailingKernel) RouteReplace(route *netlink.Route) error {
+	return fmt.Errorf("operation not permitted")
+}
+
+func TestRedistributeNeighbor(t *testing.T) {
+	assert := assert.New(t)
+
+	k := NewFakeKernel()
+	k.LinkAdd(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth1"}})
+	eth1, _ := k.LinkByName("eth1")
+	index := eth1.Attrs().Index
+	neighs := []*netlink.Neigh{
+		{LinkIndex: index, IP: net.ParseIP("10.1.0.2"), State: netlink.NUD_REACHABLE},
+		{LinkIndex: index, IP: net.ParseIP("2001:db8:1::2"), State: netlink.NUD_STALE},
+		{LinkIndex: index, IP: net.ParseIP("fe80::2"), State: netlink.NUD_REACHABLE},
+		{LinkIndex: index, IP: net.ParseIP("10.1.0.3"), State: netlink.NUD_INCOMPLETE},
+	}
+	for _, n := range neighs {
+		assert.Nil(k.NeighAdd(n))
+	}
+
+	c := &config.Config{}
+	c.Dataplane.RedistributeNeighbor = config.RedistributeNeighbor{
+		Interfaces:  []string{"eth1"},
+		Communities: []string{"65000:100"},
+	}
+	gobgp := testutil.NewGobgpClient()
+	d := NewDataplane(c, &dataplane.Options{}, k)
+	d.client = &Client{GobgpApiClient: gobgp}
+	defer d.t.Kill(nil)
+
+	// the link-local and the incomplete neighbors aren't advertised
+	assert.Nil(d.startRedistributeNeighbor())
+	assert.Len(gobgp.Paths(), 2)
+	prefixes := []string{}
+	for _, p := range gobgp.Paths() {
+		assert.False(p.IsWithdraw)
+		nlri, _ := apiutil.GetNativeNlri(p)
+		prefixes = append(prefixes, nlri.String())
+		attrs, _ := apiutil.GetNativePathAttributes(p)
+		communities := []uint32{}
+		for _, a := range attrs {
+			if c, ok := a.(*bgp.PathAttributeCommunities); ok {
+				communities = c.Value
+			}
+		}
+		assert.Equal([]uint32{65000<<16 | 100}, communities)
+	}
+	// the IPv4 neighbors are dumped first
+	assert.Equal([]string{"10.1.0.2/32", "2001:db8:1::2/128"}, prefixes)
+
+	// a neighbor confirmed again isn't advertised twice
+	assert.Nil(d.modHostRoute(neighs[0], false))
+	assert.Len(gobgp.Paths(), 2)
+
+	// only the stale neighbors are probed
+	d.refreshNeighbors()
+	assert.Equal([]string{"2001:db8:1::2"}, k.Probes())
+
+	// a failed neighbor is withdrawn, and so is a deleted one
+	failed := *neighs[0]
+	failed.State = netlink.NUD_FAILED
+	assert.Nil(d.modHostRoute(&failed, false))
+	assert.Nil(d.modHostRoute(neighs[1], true))
+	assert.Len(gobgp.Paths(), 4)
+	assert.True(gobgp.Paths()[2].IsWithdraw)
+	assert.True(gobgp.Paths()[3].IsWithdraw)
+	assert.Len(d.hostRoutes, 0)
+
+	// the deletion of an unknown neighbor is ignored
+	assert.Nil(d.modHostRoute(neighs[1], true))
+	assert.Len(gobgp.Paths(), 4)
+}
+
+func TestRecreateVirtualNetwork(t *testing.T) {
+	assert := assert.New(t)
+
+	d := NewDataplane(&config.Config{}, &dataplane.Options{}, NewFakeKernel())
+	d.routerId = "10.0.0.1"
+	d.localAS = 65000
+	d.client = &Client{GobgpApiClient: testutil.NewGobgpClient()}
+	running := func(key string) func() bool {
+		return func() bool {
+			vn, ok := d.vnMap[key]
+			return ok && vn.State().Status == dataplane.VN_STATUS_RUNNING
+		}
+	}
+
+	assert.Nil(d.addVirtualNetwork(testVirtualNetwork))
+	assert.Eventually(running(testVirtualNetwork.Key()), 5*time.Second, 10*time.Millisecond)
+	old := d.vnMap[testVirtualNetwork.Key()]
+
+	// the only virtual network is recreated without waiting for the old
+	// one, which the new one waits for
+	c := testVirtualNetwork
+	c.VNI = 20
+	c.VtepInterface = "vtep20"
+	assert.Nil(d.updateVirtualNetwork(c))
+	assert.Eventually(running(c.Key()), 5*time.Second, 10*time.Millisecond)
+	<-old.doneCh
+
+	// the dataplane keeps running without virtual networks
+	assert.Nil(d.deleteVirtualNetwork(c))
+	assert.Len(d.vnMap, 0)
+	d.vnWg.Wait()
+	select {
+	case <-d.t.Dying():
+		assert.Fail("the dataplane is dying")
+	default:
+	}
+	assert.Nil(d.addVirtualNetwork(c))
+	assert.Eventually(running(c.Key()), 5*time.Second, 10*time.Millisecond)
+	d.stopVirtualNetwork(d.vnMap[c.Key()])
+	d.vnWg.Wait()
+}
@@ -0,0 +1,226 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netlink
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	api "github.com/osrg/gobgp/api"
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	log "github.com/sirupsen/logrus"
+	"github.com/ttsubo/goplane/dataplane"
+	"github.com/ttsubo/goplane/internal/pkg/apiutil"
+)
+
+// macMoves tracks where a MAC is for the duplicate MAC detection of
+// RFC 7432 section 15.1. The MAC/IP routes count toward their MAC, as
+// the FDB entries are per MAC.
+type macMoves struct {
+	// vtep is the VTEP of the last Type-2 route of the MAC, or nil
+	// when it was last learned on the local host
+	vtep  net.IP
+	seq   uint32
+	moves []time.Time
+	// frozen is when the MAC was frozen. The last route or local
+	// event of a frozen MAC is held until the MAC is cleared.
+	frozen    time.Time
+	heldPath  *api.Path
+	heldEvent *netlinkEvent
+}
+
+func (m *macMoves) isFrozen() bool {
+	return !m.frozen.IsZero()
+}
+
+// macMobilitySequence returns the sequence number of the MAC Mobility
+// extended community, which gobgpd increments on each move of the MAC.
+func macMobilitySequence(attrs []bgp.PathAttributeInterface) uint32 {
+	for _, attr := range attrs {
+		a, ok := attr.(*bgp.PathAttributeExtendedCommunities)
+		if !ok {
+			continue
+		}
+		for _, ec := range a.Value {
+			if m, ok := ec.(*bgp.MacMobilityExtended); ok {
+				return m.Sequence
+			}
+		}
+	}
+	return 0
+}
+
+// trackMac records that mac is at vtep, or on the local host when vtep
+// is nil, and freezes the MAC once it has moved MaxMoves times within
+// the window. It returns the moves of the MAC.
+func (n *VirtualNetwork) trackMac(mac net.HardwareAddr, vtep net.IP, seq uint32, withdraw bool) *macMoves {
+	m, ok := n.macMoves[mac.String()]
+	if !ok {
+		if withdraw {
+			return nil
+		}
+		m = &macMoves{
+			vtep: vtep,
+			seq:  seq,
+		}
+		n.macMoves[mac.String()] = m
+		return m
+	}
+	now := time.Now()
+	window := n.dupMac.WindowDuration()
+	for len(m.moves) > 0 && now.Sub(m.moves[0]) > window {
+		m.moves = m.moves[1:]
+	}
+	if withdraw {
+		// a MAC gone from where it was last seen is forgotten once
+		// its moves are out of the window
+		if m.vtep.Equal(vtep) && len(m.moves) == 0 && !m.isFrozen() {
+			delete(n.macMoves, mac.String())
+		}
+		return m
+	}
+	if m.vtep.Equal(vtep) {
+		if seq > m.seq {
+			m.seq = seq
+		}
+		return m
+	}
+	m.vtep, m.seq = vtep, seq
+	if m.isFrozen() {
+		return m
+	}
+	m.moves = append(m.moves, now)
+	if len(m.moves) < n.dupMac.MaxMoves() {
+		return m
+	}
+	m.frozen = now
+	to := "local"
+	if vtep != nil {
+		to = vtep.String()
+	}
+	log.WithFields(log.Fields{
+		"Topic": "VirtualNetwork",
+		"Key":   n.config.Key(),
+		"Mac":   mac.String(),
+	}).Warnf("duplicate mac: %d moves within %s, last to %s with sequence %d. freeze it until it is cleared", len(m.moves), window, to, seq)
+	return m
+}
+
+// holdPath reports whether the Type-2 route p of a remote VTEP is held
+// as its MAC is frozen.
+func (n *VirtualNetwork) holdPath(p *api.Path) bool {
+	if n.dupMac.Disabled {
+		return false
+	}
+	nlri, _ := apiutil.GetNativeNlri(p)
+	e := nlri.(*bgp.EVPNNLRI).RouteTypeData.(*bgp.EVPNMacIPAdvertisementRoute)
+	attrs, _ := apiutil.GetNativePathAttributes(p)
+	nexthop := GetNextHopFromPathAttributes(attrs)
+	m := n.trackMac(e.MacAddress, nexthop, macMobilitySequence(attrs), p.IsWithdraw)
+	if m == nil || !m.isFrozen() {
+		return false
+	}
+	// the withdrawal of a route the MAC has moved from is dropped
+	if !p.IsWithdraw || m.vtep.Equal(nexthop) {
+		m.heldPath, m.heldEvent = p, nil
+	}
+	return true
+}
+
+// holdEvent reports whether the local event e is held as its MAC is
+// frozen. The MAC isn't advertised meanwhile.
+func (n *VirtualNetwork) holdEvent(e *netlinkEvent) bool {
+	if n.dupMac.Disabled {
+		return false
+	}
+	m := n.trackMac(e.mac, nil, 0, e.isWithdraw)
+	if m == nil || !m.isFrozen() {
+		return false
+	}
+	if !e.isWithdraw || m.vtep == nil {
+		m.heldPath, m.heldEvent = nil, e
+	}
+	return true
+}
+
+// duplicateMacs returns the frozen MACs.
+func (n *VirtualNetwork) duplicateMacs() []dataplane.DuplicateMac {
+	l := []dataplane.DuplicateMac{}
+	for mac, m := range n.macMoves {
+		if !m.isFrozen() {
+			continue
+		}
+		hw, _ := net.ParseMAC(mac)
+		l = append(l, dataplane.DuplicateMac{
+			Mac:    hw,
+			Vtep:   m.vtep,
+			Moves:  len(m.moves),
+			Frozen: m.frozen,
+		})
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Mac.String() < l[j].Mac.String()
+	})
+	return l
+}
+
+// ClearDuplicateMac unfreezes mac, or all the frozen MACs when mac is
+// nil. The route or the local event held for a MAC is applied, and its
+// moves are counted from scratch.
+func (n *VirtualNetwork) ClearDuplicateMac(mac net.HardwareAddr) error {
+	var err error
+	n.mgmtOperation(func() {
+		if mac != nil {
+			m, ok := n.macMoves[mac.String()]
+			if !ok || !m.isFrozen() {
+				err = fmt.Errorf("mac %s isn't frozen", mac)
+				return
+			}
+			err = n.unfreezeMac(mac.String(), m)
+			return
+		}
+		for key, m := range n.macMoves {
+			if !m.isFrozen() {
+				continue
+			}
+			if err = n.unfreezeMac(key, m); err != nil {
+				return
+			}
+		}
+	})
+	return err
+}
+
+func (n *VirtualNetwork) unfreezeMac(key string, m *macMoves) error {
+	log.WithFields(log.Fields{
+		"Topic": "VirtualNetwork",
+		"Key":   n.config.Key(),
+		"Mac":   key,
+	}).Info("clear duplicate mac")
+	n.macMoves[key] = &macMoves{
+		vtep: m.vtep,
+		seq:  m.seq,
+	}
+	if m.heldPath != nil {
+		return n.modFdb(m.heldPath)
+	}
+	if m.heldEvent != nil {
+		return n.modLocalMac(m.heldEvent)
+	}
+	return nil
+}
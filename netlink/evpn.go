@@ -0,0 +1,171 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netlink
+
+import (
+	"net"
+	"time"
+
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	"github.com/ttsubo/goplane/config"
+	"github.com/ttsubo/goplane/dataplane"
+	"github.com/ttsubo/goplane/internal/pkg/table"
+)
+
+// TunnelType returns the tunnel type the virtual network c advertises
+// in the BGP Encapsulation extended community.
+func TunnelType(c *config.VirtualNetwork) bgp.TunnelType {
+	if c.Geneve() {
+		return bgp.TUNNEL_TYPE_GENEVE
+	}
+	return bgp.TUNNEL_TYPE_VXLAN
+}
+
+// EncapMatches reports whether the virtual network c can reach the
+// VTEP of a received EVPN path. A path carrying Encapsulation extended
+// communities is used only when one of them is the tunnel type of c. A
+// path without any is assumed to use it, as RFC 8365 section 5.1.3
+// leaves the default to the configuration.
+func EncapMatches(c *config.VirtualNetwork, attrs []bgp.PathAttributeInterface) bool {
+	found := false
+	for _, attr := range attrs {
+		a, ok := attr.(*bgp.PathAttributeExtendedCommunities)
+		if !ok {
+			continue
+		}
+		for _, ec := range a.Value {
+			if e, ok := ec.(*bgp.EncapExtended); ok {
+				if e.TunnelType == TunnelType(c) {
+					return true
+				}
+				found = true
+			}
+		}
+	}
+	return !found
+}
+
+// ssmRange is the IPv4 range of source-specific multicast of RFC 4607.
+var ssmRange = &net.IPNet{
+	IP:   net.IPv4(232, 0, 0, 0),
+	Mask: net.CIDRMask(8, 32),
+}
+
+// pmsiTunnel returns the PMSI tunnel attribute of the Type-3 route
+// advertising vtep as the BUM endpoint of the virtual network c. An
+// underlay multicast group is advertised as a PIM-SSM tree with vtep as
+// the source in the SSM range, and as a PIM-SM tree elsewhere.
+func pmsiTunnel(c *config.VirtualNetwork, vtep net.IP) *bgp.PathAttributePmsiTunnel {
+	if !c.Multicast() {
+		id := &bgp.IngressReplTunnelID{
+			Value: vtep,
+		}
+		return bgp.NewPathAttributePmsiTunnel(bgp.PMSI_TUNNEL_TYPE_INGRESS_REPL, false, 0, id)
+	}
+	group := net.ParseIP(c.MulticastGroup).To4()
+	typ := bgp.PMSI_TUNNEL_TYPE_PIM_SM_TREE
+	if ssmRange.Contains(group) {
+		typ = bgp.PMSI_TUNNEL_TYPE_PIM_SSM_TREE
+	}
+	// the tunnel identifier is the sender address followed by the
+	// group (RFC 6514 section 5)
+	id := make([]byte, 0, 2*net.IPv4len)
+	id = append(id, vtep.To4()...)
+	id = append(id, group...)
+	return bgp.NewPathAttributePmsiTunnel(typ, false, 0, bgp.NewDefaultPmsiTunnelID(id))
+}
+
+// pmsiGroup returns the tunnel type of the PMSI tunnel attribute of a
+// received Type-3 route, and the underlay multicast group of a PIM-SM
+// or PIM-SSM tree.
+func pmsiGroup(attrs []bgp.PathAttributeInterface) (bgp.PmsiTunnelType, net.IP) {
+	for _, attr := range attrs {
+		a, ok := attr.(*bgp.PathAttributePmsiTunnel)
+		if !ok {
+			continue
+		}
+		switch a.TunnelType {
+		case bgp.PMSI_TUNNEL_TYPE_PIM_SM_TREE, bgp.PMSI_TUNNEL_TYPE_PIM_SSM_TREE:
+		default:
+			return a.TunnelType, nil
+		}
+		id, err := a.TunnelID.Serialize()
+		if err != nil || len(id) != 2*net.IPv4len && len(id) != 2*net.IPv6len {
+			return a.TunnelType, nil
+		}
+		return a.TunnelType, net.IP(id[len(id)/2:])
+	}
+	return bgp.PMSI_TUNNEL_TYPE_NO_TUNNEL, nil
+}
+
+// The routes below are shared by the dataplanes whose VTEP is managed
+// outside of goplane.
+
+// MulticastPath returns the Type-3 route of the virtual network c
+// advertising vtep as the BUM endpoint.
+func MulticastPath(c *config.VirtualNetwork, rd string, vtep net.IP, withdraw bool) (*table.Path, error) {
+	r, err := bgp.ParseRouteDistinguisher(rd)
+	if err != nil {
+		return nil, err
+	}
+	nlri := bgp.NewEVPNNLRI(bgp.EVPN_INCLUSIVE_MULTICAST_ETHERNET_TAG, &bgp.EVPNMulticastEthernetTagRoute{
+		RD:              r,
+		IPAddressLength: uint8(32),
+		IPAddress:       vtep,
+		ETag:            c.Etag,
+	})
+	return table.NewPath(nil, nlri, withdraw, []bgp.PathAttributeInterface{
+		bgp.NewPathAttributeOrigin(bgp.BGP_ORIGIN_ATTR_TYPE_IGP),
+		bgp.NewPathAttributeMpReachNLRI("0.0.0.0", []bgp.AddrPrefixInterface{nlri}),
+		pmsiTunnel(c, vtep),
+		bgp.NewPathAttributeExtendedCommunities([]bgp.ExtendedCommunityInterface{bgp.NewEncapExtended(TunnelType(c))}),
+	}, time.Now(), false), nil
+}
+
+// MacAdvPath returns the Type-2 route of a local MAC of the virtual
+// network c. It goes to the global table, so exportRts are attached
+// here.
+func MacAdvPath(c *config.VirtualNetwork, rd string, exportRts []bgp.ExtendedCommunityInterface, e *dataplane.MacEntry, withdraw bool) (*table.Path, error) {
+	r, err := bgp.ParseRouteDistinguisher(rd)
+	if err != nil {
+		return nil, err
+	}
+	macIpAdv := &bgp.EVPNMacIPAdvertisementRoute{
+		RD: r,
+		ESI: bgp.EthernetSegmentIdentifier{
+			Type: bgp.ESI_ARBITRARY,
+		},
+		MacAddressLength: 48,
+		MacAddress:       e.Mac,
+		Labels:           []uint32{c.VNI},
+		ETag:             c.Etag,
+	}
+	if ip4 := e.IP.To4(); ip4 != nil {
+		macIpAdv.IPAddressLength = 32
+		macIpAdv.IPAddress = ip4
+	} else if e.IP != nil {
+		macIpAdv.IPAddressLength = 128
+		macIpAdv.IPAddress = e.IP
+	}
+	nlri := bgp.NewEVPNNLRI(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, macIpAdv)
+	ecs := []bgp.ExtendedCommunityInterface{bgp.NewEncapExtended(TunnelType(c))}
+	ecs = append(ecs, exportRts...)
+	return table.NewPath(nil, nlri, withdraw, []bgp.PathAttributeInterface{
+		bgp.NewPathAttributeMpReachNLRI("0.0.0.0", []bgp.AddrPrefixInterface{nlri}),
+		bgp.NewPathAttributeOrigin(bgp.BGP_ORIGIN_ATTR_TYPE_IGP),
+		bgp.NewPathAttributeExtendedCommunities(ecs),
+	}, time.Now(), false), nil
+}
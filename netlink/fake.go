@@ -0,0 +1,619 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netlink
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+)
+
+// FakeKernel is a Kernel recording links, addresses, neighbor and FDB
+// entries, and routes in memory. It is used by the "fake" dataplane
+// type and by tests. Neighbor entries added or deleted through it are
+// reported to MonitorNeigh like the kernel does.
+type FakeKernel struct {
+	mu        sync.Mutex
+	links     map[int]netlink.Link
+	nextIndex int
+	addrs     map[int][]netlink.Addr
+	neighs    []netlink.Neigh
+	routes    []netlink.Route
+	monitors  map[*neighMonitor]struct{}
+	taps      map[string]*FakeTap
+	probes    []string
+}
+
+type neighUpdate struct {
+	neigh   netlink.Neigh
+	deleted bool
+}
+
+// neighMonitor queues the updates for a MonitorNeigh call so that a
+// slow caller doesn't block the one changing the entries.
+type neighMonitor struct {
+	mu      sync.Mutex
+	queue   []neighUpdate
+	readyCh chan struct{}
+}
+
+// NewFakeKernel returns a FakeKernel with a loopback interface "lo".
+func NewFakeKernel() *FakeKernel {
+	k := &FakeKernel{
+		links:     map[int]netlink.Link{},
+		nextIndex: 1,
+		addrs:     map[int][]netlink.Addr{},
+		monitors:  map[*neighMonitor]struct{}{},
+		taps:      map[string]*FakeTap{},
+	}
+	k.LinkAdd(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "lo"}})
+	return k
+}
+
+// copyLink returns a copy of l so that the links handed out aren't
+// changed behind the caller's back.
+func copyLink(l netlink.Link) netlink.Link {
+	switch l := l.(type) {
+	case *netlink.Device:
+		c := *l
+		return &c
+	case *netlink.Bridge:
+		c := *l
+		return &c
+	case *netlink.Vxlan:
+		c := *l
+		return &c
+	case *netlink.Tuntap:
+		c := *l
+		return &c
+	case *Geneve:
+		c := *l
+		return &c
+	}
+	return l
+}
+
+func (k *FakeKernel) linkByName(name string) (netlink.Link, error) {
+	for _, l := range k.links {
+		if l.Attrs().Name == name {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("Link not found")
+}
+
+func (k *FakeKernel) linkOf(link netlink.Link) (netlink.Link, error) {
+	if l, ok := k.links[link.Attrs().Index]; ok {
+		return l, nil
+	}
+	return k.linkByName(link.Attrs().Name)
+}
+
+func (k *FakeKernel) LinkByName(name string) (netlink.Link, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l, err := k.linkByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return copyLink(l), nil
+}
+
+func (k *FakeKernel) LinkByIndex(index int) (netlink.Link, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l, ok := k.links[index]
+	if !ok {
+		return nil, fmt.Errorf("Link not found")
+	}
+	return copyLink(l), nil
+}
+
+func (k *FakeKernel) LinkList() ([]netlink.Link, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	links := make([]netlink.Link, 0, len(k.links))
+	for _, l := range k.links {
+		links = append(links, copyLink(l))
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].Attrs().Index < links[j].Attrs().Index
+	})
+	return links, nil
+}
+
+// LinkAdd records link and assigns it an index.
+func (k *FakeKernel) LinkAdd(link netlink.Link) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, err := k.linkByName(link.Attrs().Name); err == nil {
+		return syscall.EEXIST
+	}
+	link.Attrs().Index = k.nextIndex
+	k.nextIndex++
+	k.links[link.Attrs().Index] = copyLink(link)
+	return nil
+}
+
+// LinkDel deletes link with its addresses, neighbor entries and
+// routes. The links enslaved to it are released.
+func (k *FakeKernel) LinkDel(link netlink.Link) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l, err := k.linkOf(link)
+	if err != nil {
+		return err
+	}
+	index := l.Attrs().Index
+	delete(k.links, index)
+	delete(k.addrs, index)
+	for _, m := range k.links {
+		if m.Attrs().MasterIndex == index {
+			m.Attrs().MasterIndex = 0
+		}
+	}
+	neighs := k.neighs[:0]
+	for _, n := range k.neighs {
+		if n.LinkIndex == index {
+			k.notify(n, true)
+			continue
+		}
+		neighs = append(neighs, n)
+	}
+	k.neighs = neighs
+	routes := k.routes[:0]
+	for _, r := range k.routes {
+		if r.LinkIndex != index {
+			routes = append(routes, r)
+		}
+	}
+	k.routes = routes
+	return nil
+}
+
+func (k *FakeKernel) setFlags(link netlink.Link, up bool) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l, err := k.linkOf(link)
+	if err != nil {
+		return err
+	}
+	if up {
+		l.Attrs().Flags |= net.FlagUp
+	} else {
+		l.Attrs().Flags &^= net.FlagUp
+	}
+	return nil
+}
+
+func (k *FakeKernel) LinkSetUp(link netlink.Link) error {
+	return k.setFlags(link, true)
+}
+
+func (k *FakeKernel) LinkSetDown(link netlink.Link) error {
+	return k.setFlags(link, false)
+}
+
+func (k *FakeKernel) setMaster(link netlink.Link, index int) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l, err := k.linkOf(link)
+	if err != nil {
+		return err
+	}
+	if _, ok := k.links[index]; index != 0 && !ok {
+		return syscall.ENODEV
+	}
+	l.Attrs().MasterIndex = index
+	return nil
+}
+
+func (k *FakeKernel) LinkSetMaster(link netlink.Link, master *netlink.Bridge) error {
+	return k.setMaster(link, master.Attrs().Index)
+}
+
+func (k *FakeKernel) LinkSetNoMaster(link netlink.Link) error {
+	return k.setMaster(link, 0)
+}
+
+func (k *FakeKernel) LinkSetHardwareAddr(link netlink.Link, hwaddr net.HardwareAddr) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l, err := k.linkOf(link)
+	if err != nil {
+		return err
+	}
+	l.Attrs().HardwareAddr = append(net.HardwareAddr(nil), hwaddr...)
+	return nil
+}
+
+// Links returns the names of the links.
+func (k *FakeKernel) Links() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	names := make([]string, 0, len(k.links))
+	for _, l := range k.links {
+		names = append(names, l.Attrs().Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (k *FakeKernel) AddrList(link netlink.Link, family int) ([]netlink.Addr, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l, err := k.linkOf(link)
+	if err != nil {
+		return nil, err
+	}
+	addrs := []netlink.Addr{}
+	for _, a := range k.addrs[l.Attrs().Index] {
+		if familyOf(a.IP) == family || family == netlink.FAMILY_ALL {
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs, nil
+}
+
+func (k *FakeKernel) AddrAdd(link netlink.Link, addr *netlink.Addr) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l, err := k.linkOf(link)
+	if err != nil {
+		return err
+	}
+	index := l.Attrs().Index
+	for _, a := range k.addrs[index] {
+		if a.Equal(*addr) {
+			return syscall.EEXIST
+		}
+	}
+	k.addrs[index] = append(k.addrs[index], *addr)
+	return nil
+}
+
+func familyOf(ip net.IP) int {
+	if ip.To4() != nil {
+		return netlink.FAMILY_V4
+	}
+	return netlink.FAMILY_V6
+}
+
+func sameNeigh(a, b *netlink.Neigh) bool {
+	return a.LinkIndex == b.LinkIndex && a.IP.Equal(b.IP) && bytes.Equal(a.HardwareAddr, b.HardwareAddr)
+}
+
+// MonitorNeigh calls fn for the neighbor entries added or deleted
+// through k until done is closed.
+func (k *FakeKernel) MonitorNeigh(done <-chan struct{}, fn func(*netlink.Neigh, bool)) error {
+	m := &neighMonitor{readyCh: make(chan struct{}, 1)}
+	k.mu.Lock()
+	k.monitors[m] = struct{}{}
+	k.mu.Unlock()
+	defer func() {
+		k.mu.Lock()
+		delete(k.monitors, m)
+		k.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-m.readyCh:
+		}
+		m.mu.Lock()
+		queue := m.queue
+		m.queue = nil
+		m.mu.Unlock()
+		for _, u := range queue {
+			n := u.neigh
+			fn(&n, u.deleted)
+		}
+	}
+}
+
+func (k *FakeKernel) notify(n netlink.Neigh, deleted bool) {
+	for m := range k.monitors {
+		m.mu.Lock()
+		m.queue = append(m.queue, neighUpdate{neigh: n, deleted: deleted})
+		m.mu.Unlock()
+		select {
+		case m.readyCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (k *FakeKernel) NeighList(linkIndex, family int) ([]netlink.Neigh, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	neighs := []netlink.Neigh{}
+	for _, n := range k.neighs {
+		if linkIndex != 0 && n.LinkIndex != linkIndex {
+			continue
+		}
+		if family != 0 && n.Family != family && (n.Family != 0 || familyOf(n.IP) != family) {
+			continue
+		}
+		neighs = append(neighs, n)
+	}
+	return neighs, nil
+}
+
+func (k *FakeKernel) neighAdd(neigh *netlink.Neigh, replace bool) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.links[neigh.LinkIndex]; !ok {
+		return syscall.ENODEV
+	}
+	for i := range k.neighs {
+		if sameNeigh(&k.neighs[i], neigh) {
+			if !replace {
+				return syscall.EEXIST
+			}
+			k.neighs[i] = *neigh
+			k.notify(*neigh, false)
+			return nil
+		}
+	}
+	k.neighs = append(k.neighs, *neigh)
+	k.notify(*neigh, false)
+	return nil
+}
+
+func (k *FakeKernel) NeighAdd(neigh *netlink.Neigh) error {
+	return k.neighAdd(neigh, false)
+}
+
+// NeighAppend adds neigh next to the entries of the same MAC address
+// with other destinations, as the kernel does for VXLAN FDB entries.
+func (k *FakeKernel) NeighAppend(neigh *netlink.Neigh) error {
+	return k.neighAdd(neigh, true)
+}
+
+func (k *FakeKernel) NeighDel(neigh *netlink.Neigh) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for i := range k.neighs {
+		if sameNeigh(&k.neighs[i], neigh) {
+			n := k.neighs[i]
+			k.neighs = append(k.neighs[:i], k.neighs[i+1:]...)
+			k.notify(n, true)
+			return nil
+		}
+	}
+	return syscall.ENOENT
+}
+
+// NeighProbe records the IP of neigh. See Probes.
+func (k *FakeKernel) NeighProbe(neigh *netlink.Neigh) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.probes = append(k.probes, neigh.IP.String())
+	return nil
+}
+
+// Probes returns the IPs of the neighbors probed by NeighProbe.
+func (k *FakeKernel) Probes() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return append([]string(nil), k.probes...)
+}
+
+func routeFamily(r *netlink.Route) int {
+	if r.Dst == nil {
+		if r.Gw != nil {
+			return familyOf(r.Gw)
+		}
+		return netlink.FAMILY_V4
+	}
+	return familyOf(r.Dst.IP)
+}
+
+func sameDst(a, b *net.IPNet) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.String() == b.String()
+}
+
+func routeTable(r *netlink.Route) int {
+	if r.Table == 0 {
+		return syscall.RT_TABLE_MAIN
+	}
+	return r.Table
+}
+
+func (k *FakeKernel) RouteList(link netlink.Link, family int) ([]netlink.Route, error) {
+	filter := &netlink.Route{}
+	var mask uint64
+	if link != nil {
+		filter.LinkIndex = link.Attrs().Index
+		mask |= netlink.RT_FILTER_OIF
+	}
+	return k.RouteListFiltered(family, filter, mask)
+}
+
+// RouteListFiltered supports RT_FILTER_DST, RT_FILTER_OIF,
+// RT_FILTER_TABLE and RT_FILTER_PROTOCOL.
+func (k *FakeKernel) RouteListFiltered(family int, filter *netlink.Route, filterMask uint64) ([]netlink.Route, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	routes := []netlink.Route{}
+	for _, r := range k.routes {
+		if family != netlink.FAMILY_ALL && routeFamily(&r) != family {
+			continue
+		}
+		if filterMask&netlink.RT_FILTER_DST != 0 && !sameDst(r.Dst, filter.Dst) {
+			continue
+		}
+		if filterMask&netlink.RT_FILTER_OIF != 0 && r.LinkIndex != filter.LinkIndex {
+			continue
+		}
+		if filterMask&netlink.RT_FILTER_TABLE != 0 && routeTable(&r) != routeTable(filter) {
+			continue
+		}
+		if filterMask&netlink.RT_FILTER_PROTOCOL != 0 && r.Protocol != filter.Protocol {
+			continue
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+// RouteReplace replaces the route with the same destination, table and
+// metric, or adds route.
+func (k *FakeKernel) RouteReplace(route *netlink.Route) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for i, r := range k.routes {
+		if sameDst(r.Dst, route.Dst) && routeTable(&r) == routeTable(route) && r.Priority == route.Priority {
+			k.routes[i] = *route
+			return nil
+		}
+	}
+	k.routes = append(k.routes, *route)
+	return nil
+}
+
+// RouteDel deletes the first route matching the destination and the
+// other attributes set in route, as the kernel does.
+func (k *FakeKernel) RouteDel(route *netlink.Route) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for i, r := range k.routes {
+		if !sameDst(r.Dst, route.Dst) || routeTable(&r) != routeTable(route) {
+			continue
+		}
+		if route.Priority != 0 && r.Priority != route.Priority {
+			continue
+		}
+		if route.Protocol != 0 && r.Protocol != route.Protocol {
+			continue
+		}
+		if route.LinkIndex != 0 && r.LinkIndex != route.LinkIndex {
+			continue
+		}
+		if route.Gw != nil && !r.Gw.Equal(route.Gw) {
+			continue
+		}
+		if route.Src != nil && !r.Src.Equal(route.Src) {
+			continue
+		}
+		k.routes = append(k.routes[:i], k.routes[i+1:]...)
+		return nil
+	}
+	return syscall.ESRCH
+}
+
+// FakeTap is a TAP device opened through FakeKernel. The frames written
+// by goplane are sent to Frames, and the ones passed to Inject are read
+// by goplane.
+type FakeTap struct {
+	k       *FakeKernel
+	name    string
+	inCh    chan []byte
+	outCh   chan []byte
+	closeCh chan struct{}
+	once    sync.Once
+}
+
+func (t *FakeTap) Read(b []byte) (int, error) {
+	select {
+	case f := <-t.inCh:
+		return copy(b, f), nil
+	case <-t.closeCh:
+		return 0, os.ErrClosed
+	}
+}
+
+// Write queues a frame to Frames. It is dropped when the queue is full,
+// as the kernel does when nobody reads the device.
+func (t *FakeTap) Write(b []byte) (int, error) {
+	select {
+	case <-t.closeCh:
+		return 0, os.ErrClosed
+	default:
+	}
+	select {
+	case t.outCh <- append([]byte(nil), b...):
+	default:
+	}
+	return len(b), nil
+}
+
+// Close deletes the device.
+func (t *FakeTap) Close() error {
+	t.once.Do(func() {
+		close(t.closeCh)
+		t.k.mu.Lock()
+		delete(t.k.taps, t.name)
+		t.k.mu.Unlock()
+		if l, err := t.k.LinkByName(t.name); err == nil {
+			t.k.LinkDel(l)
+		}
+	})
+	return nil
+}
+
+// Inject passes frame to goplane as if the bridge sent it to the device.
+func (t *FakeTap) Inject(frame []byte) {
+	select {
+	case t.inCh <- frame:
+	case <-t.closeCh:
+	}
+}
+
+// Frames returns the frames written by goplane.
+func (t *FakeTap) Frames() <-chan []byte {
+	return t.outCh
+}
+
+// OpenTap adds the TAP device name, which is deleted when it is closed.
+func (k *FakeKernel) OpenTap(name string) (io.ReadWriteCloser, error) {
+	if err := k.LinkAdd(&netlink.Tuntap{
+		LinkAttrs: netlink.LinkAttrs{Name: name},
+		Mode:      netlink.TUNTAP_MODE_TAP,
+	}); err != nil {
+		return nil, err
+	}
+	t := &FakeTap{
+		k:       k,
+		name:    name,
+		inCh:    make(chan []byte),
+		outCh:   make(chan []byte, 64),
+		closeCh: make(chan struct{}),
+	}
+	k.mu.Lock()
+	k.taps[name] = t
+	k.mu.Unlock()
+	return t, nil
+}
+
+// Tap returns the TAP device name opened through k, or nil.
+func (k *FakeKernel) Tap(name string) *FakeTap {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.taps[name]
+}
@@ -0,0 +1,145 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netlink
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	log "github.com/sirupsen/logrus"
+	"github.com/ttsubo/goplane/internal/pkg/table"
+	"github.com/vishvananda/netlink"
+)
+
+// setGateway makes the bridge the anycast gateway of the virtual
+// network. The bridge of every host gets the same MAC and addresses, so
+// the tenants keep their gateway wherever they move.
+func (n *VirtualNetwork) setGateway(br netlink.Link) error {
+	if !n.config.Gateway() {
+		return nil
+	}
+	name := br.Attrs().Name
+	mac, err := net.ParseMAC(n.config.GatewayMac)
+	if err != nil {
+		return err
+	}
+	log.Debugf("set %s address %s", name, mac)
+	if err := n.kernel.LinkSetHardwareAddr(br, mac); err != nil {
+		return fmt.Errorf("failed to set %s address %s. %s", name, mac, err)
+	}
+	addrs, err := n.kernel.AddrList(br, netlink.FAMILY_ALL)
+	if err != nil {
+		return fmt.Errorf("failed to list addresses of %s. %s", name, err)
+	}
+	for _, s := range n.config.GatewayAddresses {
+		addr, err := netlink.ParseAddr(s)
+		if err != nil {
+			return err
+		}
+		exists := false
+		for _, a := range addrs {
+			if a.Equal(*addr) {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+		log.Debugf("add address %s to %s", addr.IPNet, name)
+		if err := n.kernel.AddrAdd(br, addr); err != nil {
+			return fmt.Errorf("failed to add address %s to %s. %s", addr.IPNet, name, err)
+		}
+	}
+	return nil
+}
+
+// isGatewayMac reports whether mac is the anycast gateway MAC. Every
+// host advertises it, so the routes of the other hosts are ignored
+// rather than moving the MAC from one VTEP to another on each of them.
+func (n *VirtualNetwork) isGatewayMac(mac net.HardwareAddr) bool {
+	if !n.config.Gateway() {
+		return false
+	}
+	gw, err := net.ParseMAC(n.config.GatewayMac)
+	return err == nil && bytes.Equal(gw, mac)
+}
+
+// gatewayPath returns the Type-2 route of a gateway address with the
+// Default Gateway extended community (RFC 7432 section 10.1).
+func (n *VirtualNetwork) gatewayPath(ip net.IP, withdraw bool) (*table.Path, error) {
+	rd, err := bgp.ParseRouteDistinguisher(n.rd)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := net.ParseMAC(n.config.GatewayMac)
+	if err != nil {
+		return nil, err
+	}
+	macIpAdv := &bgp.EVPNMacIPAdvertisementRoute{
+		RD: rd,
+		ESI: bgp.EthernetSegmentIdentifier{
+			Type: bgp.ESI_ARBITRARY,
+		},
+		MacAddressLength: 48,
+		MacAddress:       mac,
+		Labels:           []uint32{n.config.VNI},
+		ETag:             n.config.Etag,
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		macIpAdv.IPAddressLength = 32
+		macIpAdv.IPAddress = ip4
+	} else {
+		macIpAdv.IPAddressLength = 128
+		macIpAdv.IPAddress = ip
+	}
+	nlri := bgp.NewEVPNNLRI(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, macIpAdv)
+	ecs := []bgp.ExtendedCommunityInterface{
+		bgp.NewEncapExtended(TunnelType(&n.config)),
+		bgp.NewDefaultGatewayExtended(),
+		// the MAC is on every host, so it is advertised as static
+		// to keep the remote VTEPs from taking it for a moving one
+		bgp.NewMacMobilityExtended(0, true),
+	}
+	ecs = append(ecs, n.exportRts...)
+	return table.NewPath(nil, nlri, withdraw, []bgp.PathAttributeInterface{
+		bgp.NewPathAttributeMpReachNLRI("0.0.0.0", []bgp.AddrPrefixInterface{nlri}),
+		bgp.NewPathAttributeOrigin(bgp.BGP_ORIGIN_ATTR_TYPE_IGP),
+		bgp.NewPathAttributeExtendedCommunities(ecs),
+	}, time.Now(), false), nil
+}
+
+// advertiseGateway advertises or withdraws the Type-2 routes of the
+// gateway addresses.
+func (n *VirtualNetwork) advertiseGateway(withdraw bool) error {
+	for _, s := range n.config.GatewayAddresses {
+		ip, _, err := net.ParseCIDR(s)
+		if err != nil {
+			return err
+		}
+		path, err := n.gatewayPath(ip, withdraw)
+		if err != nil {
+			return err
+		}
+		if _, err := n.AddPath([]*table.Path{path}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
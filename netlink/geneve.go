@@ -0,0 +1,268 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netlink
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/ttsubo/goplane/dataplane"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+)
+
+const (
+	IFLA_GENEVE_ID      = 1 // taken from /usr/include/linux/if_link.h
+	IFLA_GENEVE_REMOTE  = 2 // taken from /usr/include/linux/if_link.h
+	IFLA_GENEVE_PORT    = 5 // taken from /usr/include/linux/if_link.h
+	IFLA_GENEVE_REMOTE6 = 7 // taken from /usr/include/linux/if_link.h
+)
+
+// Geneve is a geneve device of the kernel. Unlike a vxlan device, it
+// has no FDB of remote VTEPs, so a virtual network has one device per
+// remote VTEP. The vendored netlink package doesn't know the link
+// type, so the kernel lists the devices back as netlink.GenericLink.
+type Geneve struct {
+	netlink.LinkAttrs
+	ID     uint32
+	Remote net.IP
+	Dport  uint16
+}
+
+func (g *Geneve) Attrs() *netlink.LinkAttrs {
+	return &g.LinkAttrs
+}
+
+func (g *Geneve) Type() string {
+	return "geneve"
+}
+
+func geneveLinkAdd(g *Geneve) error {
+	req := nl.NewNetlinkRequest(syscall.RTM_NEWLINK, syscall.NLM_F_CREATE|syscall.NLM_F_EXCL|syscall.NLM_F_ACK)
+	req.AddData(nl.NewIfInfomsg(syscall.AF_UNSPEC))
+	req.AddData(nl.NewRtAttr(syscall.IFLA_IFNAME, nl.ZeroTerminated(g.Name)))
+
+	info := nl.NewRtAttr(syscall.IFLA_LINKINFO, nil)
+	nl.NewRtAttrChild(info, nl.IFLA_INFO_KIND, nl.NonZeroTerminated(g.Type()))
+	data := nl.NewRtAttrChild(info, nl.IFLA_INFO_DATA, nil)
+	nl.NewRtAttrChild(data, IFLA_GENEVE_ID, nl.Uint32Attr(g.ID))
+	if ip := g.Remote.To4(); ip != nil {
+		nl.NewRtAttrChild(data, IFLA_GENEVE_REMOTE, []byte(ip))
+	} else {
+		nl.NewRtAttrChild(data, IFLA_GENEVE_REMOTE6, []byte(g.Remote.To16()))
+	}
+	if g.Dport != 0 {
+		port := make([]byte, 2)
+		binary.BigEndian.PutUint16(port, g.Dport)
+		nl.NewRtAttrChild(data, IFLA_GENEVE_PORT, port)
+	}
+	req.AddData(info)
+
+	_, err := req.Execute(syscall.NETLINK_ROUTE, 0)
+	return err
+}
+
+// GeneveHeader is the header of RFC 8926 without options carrying an
+// ethernet frame.
+type GeneveHeader struct {
+	VNI uint32
+}
+
+func NewGeneveHeader(vni uint32) *GeneveHeader {
+	return &GeneveHeader{
+		VNI: vni,
+	}
+}
+
+func (g *GeneveHeader) Serialize() []byte {
+	buf := make([]byte, 8)
+	// transparent ethernet bridging
+	binary.BigEndian.PutUint16(buf[2:4], 0x6558)
+	buf[4] = byte((g.VNI >> 16) & 0xff)
+	buf[5] = byte((g.VNI >> 8) & 0xff)
+	buf[6] = byte(g.VNI & 0xff)
+	return buf
+}
+
+// tunnelPrefix is the prefix of the names of the geneve devices of the
+// virtual network. The devices are named after the VTEP interface with
+// a sequence number, such as vtep10-0.
+func (n *VirtualNetwork) tunnelPrefix() string {
+	return n.config.VtepInterface + "-"
+}
+
+// addTunnel returns the geneve device to vtep. It is created and
+// attached to the bridge if it doesn't exist yet.
+func (n *VirtualNetwork) addTunnel(vtep net.IP) (netlink.Link, error) {
+	if name, ok := n.tunnels[vtep.String()]; ok {
+		return n.kernel.LinkByName(name)
+	}
+	used := make(map[string]bool, len(n.tunnels))
+	for _, name := range n.tunnels {
+		used[name] = true
+	}
+	name := ""
+	for i := 0; ; i++ {
+		name = fmt.Sprintf("%s%d", n.tunnelPrefix(), i)
+		if !used[name] {
+			break
+		}
+	}
+
+	b, err := n.kernel.LinkByName(n.bridgeName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s", n.bridgeName())
+	}
+	log.Debugf("add %s to %s", name, vtep)
+	err = n.kernel.LinkAdd(&Geneve{
+		LinkAttrs: netlink.LinkAttrs{
+			Name: name,
+		},
+		ID:     n.config.VNI,
+		Remote: vtep,
+		Dport:  n.config.Port(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add link %s. %s", name, err)
+	}
+	link, err := n.kernel.LinkByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s", name)
+	}
+	err = n.kernel.LinkSetUp(link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set %s up", name)
+	}
+	err = n.kernel.LinkSetMaster(link, b.(*netlink.Bridge))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set master %s dev %s", n.bridgeName(), name)
+	}
+	n.tunnels[vtep.String()] = name
+	return link, nil
+}
+
+// releaseTunnel deletes the geneve device to vtep once neither the
+// Type-3 route nor a Type-2 route of the VTEP is left.
+func (n *VirtualNetwork) releaseTunnel(vtep net.IP) {
+	name, ok := n.tunnels[vtep.String()]
+	if !ok {
+		return
+	}
+	if _, ok := n.connMap[vtep.String()]; ok {
+		return
+	}
+	for _, e := range n.remoteFdb {
+		if e.Vtep.Equal(vtep) {
+			return
+		}
+	}
+	delete(n.tunnels, vtep.String())
+	link, err := n.kernel.LinkByName(name)
+	if err != nil {
+		return
+	}
+	log.Debugf("del %s", name)
+	if err := n.kernel.LinkDel(link); err != nil {
+		log.WithFields(log.Fields{
+			"Topic": "VirtualNetwork",
+			"Key":   n.config.Key(),
+		}).Warnf("failed to del %s: %s", name, err)
+	}
+}
+
+// deleteTunnels deletes the geneve devices of the virtual network,
+// including the ones left by a previous run.
+func (n *VirtualNetwork) deleteTunnels() error {
+	links, err := n.kernel.LinkList()
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		if link.Type() != "geneve" || !strings.HasPrefix(link.Attrs().Name, n.tunnelPrefix()) {
+			continue
+		}
+		log.Debugf("del %s", link.Attrs().Name)
+		if err := n.kernel.LinkDel(link); err != nil {
+			return fmt.Errorf("failed to del %s", link.Attrs().Name)
+		}
+	}
+	n.tunnels = map[string]string{}
+	return nil
+}
+
+func bridgeFdbNeigh(index int, mac net.HardwareAddr) *netlink.Neigh {
+	return &netlink.Neigh{
+		LinkIndex:    index,
+		Family:       syscall.AF_BRIDGE,
+		State:        int(netlink.NUD_NOARP),
+		Flags:        int(netlink.NTF_MASTER),
+		HardwareAddr: mac,
+	}
+}
+
+// modGeneveFdb points the MAC of a Type-2 route to the geneve device of
+// its VTEP with a static FDB entry of the bridge.
+func (n *VirtualNetwork) modGeneveFdb(mac net.HardwareAddr, vtep net.IP, withdraw bool) error {
+	delete(n.staleFdb, mac.String())
+	e, ok := n.remoteFdb[mac.String()]
+	if withdraw {
+		// the withdrawal of a MAC moved to another VTEP may come
+		// after the new route
+		if ok && e.Vtep.Equal(vtep) {
+			n.delGeneveFdb(e)
+		}
+		return nil
+	}
+	if ok {
+		if e.Vtep.Equal(vtep) {
+			return nil
+		}
+		n.delGeneveFdb(e)
+	}
+	link, err := n.addTunnel(vtep)
+	if err != nil {
+		return err
+	}
+	if err := n.kernel.NeighAppend(bridgeFdbNeigh(link.Attrs().Index, mac)); err != nil {
+		log.WithFields(log.Fields{
+			"Topic": "VirtualNetwork",
+			"Key":   n.config.Key(),
+		}).Debugf("failed to add fdb %s dev %s: %s", mac, link.Attrs().Name, err)
+		n.releaseTunnel(vtep)
+		return err
+	}
+	n.remoteFdb[mac.String()] = &dataplane.FdbEntry{
+		Mac:  mac,
+		Vtep: vtep,
+	}
+	return nil
+}
+
+func (n *VirtualNetwork) delGeneveFdb(e *dataplane.FdbEntry) {
+	delete(n.remoteFdb, e.Mac.String())
+	if name, ok := n.tunnels[e.Vtep.String()]; ok {
+		if link, err := n.kernel.LinkByName(name); err == nil {
+			if err := n.kernel.NeighDel(bridgeFdbNeigh(link.Attrs().Index, e.Mac)); err != nil {
+				log.Warnf("failed to del fdb %s dev %s: %s", e.Mac, name, err)
+			}
+		}
+	}
+	n.releaseTunnel(e.Vtep)
+}
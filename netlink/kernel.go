@@ -0,0 +1,206 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netlink
+
+import (
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+)
+
+// Kernel is the set of kernel operations used by the dataplane and the
+// virtual networks. NewKernel returns the one calling the kernel via
+// netlink. FakeKernel keeps the state in memory so that the dataplane
+// logic can run without root.
+type Kernel interface {
+	LinkByName(name string) (netlink.Link, error)
+	LinkByIndex(index int) (netlink.Link, error)
+	LinkList() ([]netlink.Link, error)
+	LinkAdd(link netlink.Link) error
+	LinkDel(link netlink.Link) error
+	LinkSetUp(link netlink.Link) error
+	LinkSetDown(link netlink.Link) error
+	LinkSetMaster(link netlink.Link, master *netlink.Bridge) error
+	LinkSetNoMaster(link netlink.Link) error
+	LinkSetHardwareAddr(link netlink.Link, hwaddr net.HardwareAddr) error
+	AddrList(link netlink.Link, family int) ([]netlink.Addr, error)
+	AddrAdd(link netlink.Link, addr *netlink.Addr) error
+	NeighList(linkIndex, family int) ([]netlink.Neigh, error)
+	NeighAdd(neigh *netlink.Neigh) error
+	NeighAppend(neigh *netlink.Neigh) error
+	NeighDel(neigh *netlink.Neigh) error
+	RouteList(link netlink.Link, family int) ([]netlink.Route, error)
+	RouteListFiltered(family int, filter *netlink.Route, filterMask uint64) ([]netlink.Route, error)
+	RouteReplace(route *netlink.Route) error
+	RouteDel(route *netlink.Route) error
+	// MonitorNeigh calls fn for each neighbor entry added or deleted
+	// until done is closed.
+	MonitorNeigh(done <-chan struct{}, fn func(neigh *netlink.Neigh, deleted bool)) error
+	// NeighProbe makes the kernel confirm a stale neighbor entry.
+	NeighProbe(neigh *netlink.Neigh) error
+	// OpenTap creates the TAP device name of a userspace VTEP. It is
+	// deleted when the returned device is closed.
+	OpenTap(name string) (io.ReadWriteCloser, error)
+}
+
+type netlinkKernel struct{}
+
+// NewKernel returns the Kernel calling the kernel via netlink.
+func NewKernel() Kernel {
+	return &netlinkKernel{}
+}
+
+func (k *netlinkKernel) LinkByName(name string) (netlink.Link, error) {
+	return netlink.LinkByName(name)
+}
+
+func (k *netlinkKernel) LinkByIndex(index int) (netlink.Link, error) {
+	return netlink.LinkByIndex(index)
+}
+
+func (k *netlinkKernel) LinkList() ([]netlink.Link, error) {
+	return netlink.LinkList()
+}
+
+func (k *netlinkKernel) LinkAdd(link netlink.Link) error {
+	if g, ok := link.(*Geneve); ok {
+		return geneveLinkAdd(g)
+	}
+	return netlink.LinkAdd(link)
+}
+
+func (k *netlinkKernel) LinkDel(link netlink.Link) error {
+	return netlink.LinkDel(link)
+}
+
+func (k *netlinkKernel) LinkSetUp(link netlink.Link) error {
+	return netlink.LinkSetUp(link)
+}
+
+func (k *netlinkKernel) LinkSetDown(link netlink.Link) error {
+	return netlink.LinkSetDown(link)
+}
+
+func (k *netlinkKernel) LinkSetMaster(link netlink.Link, master *netlink.Bridge) error {
+	return netlink.LinkSetMaster(link, master)
+}
+
+func (k *netlinkKernel) LinkSetNoMaster(link netlink.Link) error {
+	return netlink.LinkSetNoMaster(link)
+}
+
+func (k *netlinkKernel) LinkSetHardwareAddr(link netlink.Link, hwaddr net.HardwareAddr) error {
+	return netlink.LinkSetHardwareAddr(link, hwaddr)
+}
+
+func (k *netlinkKernel) AddrList(link netlink.Link, family int) ([]netlink.Addr, error) {
+	return netlink.AddrList(link, family)
+}
+
+func (k *netlinkKernel) AddrAdd(link netlink.Link, addr *netlink.Addr) error {
+	return netlink.AddrAdd(link, addr)
+}
+
+func (k *netlinkKernel) NeighList(linkIndex, family int) ([]netlink.Neigh, error) {
+	return netlink.NeighList(linkIndex, family)
+}
+
+func (k *netlinkKernel) NeighAdd(neigh *netlink.Neigh) error {
+	return netlink.NeighAdd(neigh)
+}
+
+func (k *netlinkKernel) NeighAppend(neigh *netlink.Neigh) error {
+	return netlink.NeighAppend(neigh)
+}
+
+func (k *netlinkKernel) NeighDel(neigh *netlink.Neigh) error {
+	return netlink.NeighDel(neigh)
+}
+
+func (k *netlinkKernel) RouteList(link netlink.Link, family int) ([]netlink.Route, error) {
+	return netlink.RouteList(link, family)
+}
+
+func (k *netlinkKernel) RouteListFiltered(family int, filter *netlink.Route, filterMask uint64) ([]netlink.Route, error) {
+	return netlink.RouteListFiltered(family, filter, filterMask)
+}
+
+func (k *netlinkKernel) RouteReplace(route *netlink.Route) error {
+	return netlink.RouteReplace(route)
+}
+
+func (k *netlinkKernel) RouteDel(route *netlink.Route) error {
+	return netlink.RouteDel(route)
+}
+
+func (k *netlinkKernel) MonitorNeigh(done <-chan struct{}, fn func(*netlink.Neigh, bool)) error {
+	s, err := nl.Subscribe(syscall.NETLINK_ROUTE, uint(RTMGRP_NEIGH), uint(RTMGRP_LINK), uint(RTMGRP_NOTIFY))
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-done
+		s.Close()
+	}()
+
+	for {
+		msgs, err := s.Receive()
+		if err != nil {
+			select {
+			case <-done:
+				return nil
+			default:
+			}
+			return err
+		}
+		for _, msg := range msgs {
+			switch RTM_TYPE(msg.Header.Type) {
+			case RTM_NEWNEIGH:
+				if n, err := netlink.NeighDeserialize(msg.Data); err == nil {
+					fn(n, false)
+				}
+			case RTM_DELNEIGH:
+				if n, err := netlink.NeighDeserialize(msg.Data); err == nil {
+					fn(n, true)
+				}
+			}
+		}
+	}
+}
+
+// NeighProbe sends a datagram to the discard port of the neighbor. The
+// kernel probes a stale entry it is used by, so the entry becomes
+// REACHABLE again or FAILED.
+func (k *netlinkKernel) NeighProbe(neigh *netlink.Neigh) error {
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: neigh.IP, Port: 9})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write(nil)
+	return err
+}
+
+func (k *netlinkKernel) OpenTap(name string) (io.ReadWriteCloser, error) {
+	f, err := OpenTap(name)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,165 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netlink
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	log "github.com/sirupsen/logrus"
+	"github.com/ttsubo/goplane/internal/pkg/table"
+	"github.com/vishvananda/netlink"
+)
+
+// neighEvent is a change of a neighbor entry on an interface whose
+// neighbors are redistributed.
+type neighEvent struct {
+	neigh   netlink.Neigh
+	deleted bool
+}
+
+// the states of the neighbor entries advertised as host routes
+const neighValidStates = netlink.NUD_REACHABLE | netlink.NUD_STALE | netlink.NUD_DELAY | netlink.NUD_PROBE | netlink.NUD_PERMANENT
+
+// hostRoutePath returns the /32 or /128 route of a neighbor.
+func (d *Dataplane) hostRoutePath(ip net.IP, withdraw bool) *table.Path {
+	var nlri bgp.AddrPrefixInterface
+	attrs := []bgp.PathAttributeInterface{
+		bgp.NewPathAttributeOrigin(bgp.BGP_ORIGIN_ATTR_TYPE_IGP),
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		nlri = bgp.NewIPAddrPrefix(32, ip4.String())
+		attrs = append(attrs, bgp.NewPathAttributeNextHop("0.0.0.0"))
+	} else {
+		nlri = bgp.NewIPv6AddrPrefix(128, ip.String())
+		attrs = append(attrs, bgp.NewPathAttributeMpReachNLRI("::", []bgp.AddrPrefixInterface{nlri}))
+	}
+	if len(d.communities) > 0 {
+		attrs = append(attrs, bgp.NewPathAttributeCommunities(d.communities))
+	}
+	return table.NewPath(nil, nlri, withdraw, attrs, time.Now(), false)
+}
+
+// startRedistributeNeighbor advertises the neighbors on the configured
+// interfaces and starts watching them.
+func (d *Dataplane) startRedistributeNeighbor() error {
+	r := &d.config.Dataplane.RedistributeNeighbor
+	d.communities = make([]uint32, 0, len(r.Communities))
+	for _, s := range r.Communities {
+		c, err := table.ParseCommunity(s)
+		if err != nil {
+			return err
+		}
+		d.communities = append(d.communities, c)
+	}
+	for _, ifname := range r.Interfaces {
+		link, err := d.kernel.LinkByName(ifname)
+		if err != nil {
+			return fmt.Errorf("failed to get %s", ifname)
+		}
+		d.neighLinks[link.Attrs().Index] = ifname
+	}
+	d.t.Go(d.monitorNeigh)
+
+	for index, ifname := range d.neighLinks {
+		for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+			neighs, err := d.kernel.NeighList(index, family)
+			if err != nil {
+				return fmt.Errorf("failed to list neighbors of %s: %s", ifname, err)
+			}
+			for i := range neighs {
+				if err := d.modHostRoute(&neighs[i], false); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Dataplane) monitorNeigh() error {
+	return d.kernel.MonitorNeigh(d.t.Dying(), func(n *netlink.Neigh, deleted bool) {
+		if _, ok := d.neighLinks[n.LinkIndex]; !ok {
+			return
+		}
+		select {
+		case d.neighCh <- &neighEvent{*n, deleted}:
+		case <-d.t.Dying():
+		}
+	})
+}
+
+// modHostRoute advertises a neighbor as a host route, or withdraws it
+// when the neighbor is deleted or becomes FAILED.
+func (d *Dataplane) modHostRoute(n *netlink.Neigh, deleted bool) error {
+	if n.IP == nil || n.IP.IsUnspecified() || n.IP.IsMulticast() || n.IP.IsLinkLocalUnicast() {
+		return nil
+	}
+	key := n.IP.String()
+	cur, ok := d.hostRoutes[key]
+	if deleted || n.State&netlink.NUD_FAILED != 0 {
+		// the IP may have moved to another interface
+		if !ok || cur.LinkIndex != n.LinkIndex {
+			return nil
+		}
+		delete(d.hostRoutes, key)
+		log.WithFields(log.Fields{
+			"Topic": "Dataplane",
+		}).Infof("withdraw host route of %s on %s", key, d.neighLinks[n.LinkIndex])
+		_, err := d.AddPath([]*table.Path{d.hostRoutePath(n.IP, true)})
+		return err
+	}
+	if n.State&neighValidStates == 0 {
+		return nil
+	}
+	neigh := *n
+	d.hostRoutes[key] = &neigh
+	if ok {
+		return nil
+	}
+	log.WithFields(log.Fields{
+		"Topic": "Dataplane",
+	}).Infof("advertise host route of %s on %s", key, d.neighLinks[n.LinkIndex])
+	_, err := d.AddPath([]*table.Path{d.hostRoutePath(n.IP, false)})
+	return err
+}
+
+// advertiseHostRoutes advertises or withdraws all the host routes.
+func (d *Dataplane) advertiseHostRoutes(withdraw bool) error {
+	for _, n := range d.hostRoutes {
+		if _, err := d.AddPath([]*table.Path{d.hostRoutePath(n.IP, withdraw)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshNeighbors probes the stale neighbors. The kernel confirms the
+// live hosts, and the others become FAILED and are withdrawn.
+func (d *Dataplane) refreshNeighbors() {
+	for key, n := range d.hostRoutes {
+		if n.State&netlink.NUD_STALE == 0 {
+			continue
+		}
+		if err := d.kernel.NeighProbe(n); err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Dataplane",
+			}).Debugf("failed to probe %s: %s", key, err)
+		}
+	}
+}
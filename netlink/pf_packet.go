@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net"
 	"syscall"
+	"time"
 	"unsafe"
 )
 
@@ -83,6 +84,11 @@ func (c *PFConn) Write(b []byte) (n int, err error) {
 	return int(r1), e
 }
 
+func (c *PFConn) SetReadTimeout(d time.Duration) error {
+	tv := syscall.NsecToTimeval(d.Nanoseconds())
+	return syscall.SetsockoptTimeval(c.fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv)
+}
+
 func (c *PFConn) Close() error {
 	_, _, e := syscall.Syscall(syscall.SYS_CLOSE, uintptr(c.fd), 0, 0)
 	if e > 0 {
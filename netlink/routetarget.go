@@ -0,0 +1,133 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netlink
+
+import (
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	"github.com/ttsubo/goplane/config"
+)
+
+// RFC 8365 section 5.1.2.1. The local administrator field of an
+// auto-derived route target is A(1 bit), TYPE(3 bits), D-ID(4 bits)
+// and Service ID(24 bits). A is 0 for an auto-derived route target,
+// TYPE is 1 for VXLAN and the service ID carries the VNI.
+const (
+	autoRtTypeVXLAN = 1 << 28
+	autoRtVNIMask   = 0xffffff
+)
+
+// autoRouteTarget returns the route target auto-derived from the local
+// AS and the VNI. A 4-octet AS is truncated to its lower 2 octets as
+// other implementations do.
+func autoRouteTarget(as, vni uint32) bgp.ExtendedCommunityInterface {
+	localAdmin := uint32(autoRtTypeVXLAN | (vni & autoRtVNIMask))
+	return bgp.NewTwoOctetAsSpecificExtended(bgp.EC_SUBTYPE_ROUTE_TARGET, uint16(as), localAdmin, true)
+}
+
+// autoRouteDistinguisher returns the type 1 RD made of the router ID
+// and the EVI index (RFC 7432 section 7.9).
+func autoRouteDistinguisher(routerId string, evi uint16) bgp.RouteDistinguisherInterface {
+	return bgp.NewRouteDistinguisherIPAddressAS(routerId, evi)
+}
+
+func parseRouteTargets(l []string, as, vni uint32) ([]bgp.ExtendedCommunityInterface, error) {
+	rts := make([]bgp.ExtendedCommunityInterface, 0, len(l))
+	for _, s := range l {
+		if s == config.RouteTargetAuto {
+			rts = append(rts, autoRouteTarget(as, vni))
+			continue
+		}
+		rt, err := bgp.ParseRouteTarget(s)
+		if err != nil {
+			return nil, err
+		}
+		rts = append(rts, rt)
+	}
+	return rts, nil
+}
+
+func routeTargetsFromPathAttributes(attrs []bgp.PathAttributeInterface) []bgp.ExtendedCommunityInterface {
+	rts := []bgp.ExtendedCommunityInterface{}
+	for _, attr := range attrs {
+		if a, ok := attr.(*bgp.PathAttributeExtendedCommunities); ok {
+			for _, ec := range a.Value {
+				typ, subtype := ec.GetTypes()
+				if subtype != bgp.EC_SUBTYPE_ROUTE_TARGET {
+					continue
+				}
+				switch typ {
+				case bgp.EC_TYPE_TRANSITIVE_TWO_OCTET_AS_SPECIFIC, bgp.EC_TYPE_TRANSITIVE_IP4_SPECIFIC, bgp.EC_TYPE_TRANSITIVE_FOUR_OCTET_AS_SPECIFIC:
+					rts = append(rts, ec)
+				}
+			}
+		}
+	}
+	return rts
+}
+
+// RouteDistinguisher returns the RD of the virtual network c, which is
+// auto-derived from routerId when it isn't configured.
+func RouteDistinguisher(c *config.VirtualNetwork, routerId string) string {
+	if c.RD != "" {
+		return c.RD
+	}
+	return autoRouteDistinguisher(routerId, c.EviIndex()).String()
+}
+
+// RouteTargets returns the import and export route targets of the
+// virtual network c with the route distinguisher rd. Without either
+// list, the configured RD is used as the route target. An auto-derived
+// RD differs on every host, so the auto-derived route target is used
+// instead.
+func RouteTargets(c *config.VirtualNetwork, rd string, localAS uint32) ([]bgp.ExtendedCommunityInterface, []bgp.ExtendedCommunityInterface, error) {
+	if len(c.ImportRtList) == 0 && len(c.ExportRtList) == 0 {
+		if c.RD == "" {
+			rt := autoRouteTarget(localAS, c.VNI)
+			return []bgp.ExtendedCommunityInterface{rt}, []bgp.ExtendedCommunityInterface{rt}, nil
+		}
+		rt, err := bgp.ParseRouteTarget(rd)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []bgp.ExtendedCommunityInterface{rt}, []bgp.ExtendedCommunityInterface{rt}, nil
+	}
+	im, err := parseRouteTargets(c.ImportRtList, localAS, c.VNI)
+	if err != nil {
+		return nil, nil, err
+	}
+	ex, err := parseRouteTargets(c.ExportRtList, localAS, c.VNI)
+	if err != nil {
+		return nil, nil, err
+	}
+	return im, ex, nil
+}
+
+// IsImported reports whether a received path carries one of the import
+// route targets of the virtual network c. Without an explicit import
+// list, paths are matched by etag only as before.
+func IsImported(c *config.VirtualNetwork, importRts []bgp.ExtendedCommunityInterface, attrs []bgp.PathAttributeInterface) bool {
+	if len(c.ImportRtList) == 0 {
+		return true
+	}
+	for _, rt := range routeTargetsFromPathAttributes(attrs) {
+		for _, im := range importRts {
+			if rt.String() == im.String() {
+				return true
+			}
+		}
+	}
+	return false
+}
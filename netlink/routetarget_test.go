@@ -0,0 +1,81 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netlink
+
+import (
+	"testing"
+
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	"github.com/stretchr/testify/assert"
+	"github.com/ttsubo/goplane/config"
+)
+
+func TestAutoRouteTarget(t *testing.T) {
+	assert := assert.New(t)
+
+	rt := autoRouteTarget(65000, 10)
+	assert.Equal("65000:268435466", rt.String())
+	_, subtype := rt.GetTypes()
+	assert.Equal(bgp.EC_SUBTYPE_ROUTE_TARGET, subtype)
+
+	// VNI is limited to 24 bits
+	assert.Equal(autoRouteTarget(65000, 10).String(), autoRouteTarget(65000, 1<<24|10).String())
+}
+
+func TestAutoRouteDistinguisher(t *testing.T) {
+	assert.Equal(t, "10.0.0.1:10", autoRouteDistinguisher("10.0.0.1", 10).String())
+}
+
+func TestRouteTargets(t *testing.T) {
+	assert := assert.New(t)
+
+	// the configured RD is the route target by default
+	c := &config.VirtualNetwork{RD: "65000:10", VNI: 10}
+	im, ex, err := RouteTargets(c, RouteDistinguisher(c, "10.0.0.1"), 65000)
+	assert.Nil(err)
+	assert.Equal("65000:10", im[0].String())
+	assert.Equal("65000:10", ex[0].String())
+
+	// the auto-derived RD isn't, as it differs on every host
+	c = &config.VirtualNetwork{VNI: 10}
+	im, ex, err = RouteTargets(c, RouteDistinguisher(c, "10.0.0.1"), 65000)
+	assert.Nil(err)
+	assert.Equal("65000:268435466", im[0].String())
+	assert.Equal("65000:268435466", ex[0].String())
+}
+
+func TestParseRouteTargets(t *testing.T) {
+	assert := assert.New(t)
+
+	rts, err := parseRouteTargets([]string{"auto", "65000:100"}, 65000, 10)
+	assert.Nil(err)
+	assert.Equal(2, len(rts))
+	assert.Equal("65000:268435466", rts[0].String())
+	assert.Equal("65000:100", rts[1].String())
+
+	_, err = parseRouteTargets([]string{"invalid"}, 65000, 10)
+	assert.NotNil(err)
+
+	attrs := []bgp.PathAttributeInterface{
+		bgp.NewPathAttributeExtendedCommunities([]bgp.ExtendedCommunityInterface{
+			bgp.NewEncapExtended(bgp.TUNNEL_TYPE_VXLAN),
+			rts[1],
+		}),
+	}
+	l := routeTargetsFromPathAttributes(attrs)
+	assert.Equal(1, len(l))
+	assert.Equal("65000:100", l[0].String())
+}
@@ -0,0 +1,62 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package netlink
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	IFF_TAP   = 0x0002     // taken from /usr/include/linux/if_tun.h
+	IFF_NO_PI = 0x1000     // taken from /usr/include/linux/if_tun.h
+	TUNSETIFF = 0x400454ca // taken from /usr/include/linux/if_tun.h
+)
+
+type ifReq struct {
+	name  [syscall.IFNAMSIZ]byte
+	flags uint16
+	_     [22]byte
+}
+
+// OpenTap creates the TAP device ifname and returns its file, which
+// reads and writes one ethernet frame at a time. The device is deleted
+// when the file is closed.
+func OpenTap(ifname string) (*os.File, error) {
+	fd, err := syscall.Open("/dev/net/tun", syscall.O_RDWR|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	req := ifReq{
+		flags: IFF_TAP | IFF_NO_PI,
+	}
+	copy(req.name[:syscall.IFNAMSIZ-1], ifname)
+	if _, _, e := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd),
+		uintptr(TUNSETIFF), uintptr(unsafe.Pointer(&req))); e > 0 {
+		syscall.Close(fd)
+		return nil, e
+	}
+	// a non-blocking file is served by the runtime poller, so that
+	// closing it wakes up a pending read
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), "/dev/net/tun"), nil
+}
@@ -0,0 +1,221 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netlink
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/tomb.v2"
+)
+
+// vxlanSocket is the UDP socket of a port receiving the VXLAN packets
+// of the userspace VTEPs. It is shared by the virtual networks using
+// the port and hands the decapsulated frames to the one of their VNI.
+type vxlanSocket struct {
+	conn *net.UDPConn
+	port uint16
+	// vnis are guarded by vxlanSocketsMu
+	vnis map[uint32]chan<- []byte
+	// deadCh is closed when receiving fails. The virtual networks using
+	// the socket restart then.
+	deadCh chan struct{}
+}
+
+var (
+	vxlanSocketsMu sync.Mutex
+	vxlanSockets   = map[uint16]*vxlanSocket{}
+)
+
+// openVxlanSocket registers ch to receive the frames of vni arriving
+// on port. The socket is bound by the first virtual network using the
+// port.
+func openVxlanSocket(port uint16, vni uint32, ch chan<- []byte) (*vxlanSocket, error) {
+	vxlanSocketsMu.Lock()
+	defer vxlanSocketsMu.Unlock()
+	s, ok := vxlanSockets[port]
+	if !ok {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: int(port)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on udp port %d: %s", port, err)
+		}
+		s = &vxlanSocket{
+			conn:   conn,
+			port:   port,
+			vnis:   map[uint32]chan<- []byte{},
+			deadCh: make(chan struct{}),
+		}
+		vxlanSockets[port] = s
+		go s.receive()
+	}
+	if _, ok := s.vnis[vni]; ok {
+		return nil, fmt.Errorf("vni %d is already received on udp port %d", vni, port)
+	}
+	s.vnis[vni] = ch
+	return s, nil
+}
+
+// close stops receiving the frames of vni. The socket is closed once
+// no virtual network uses it.
+func (s *vxlanSocket) close(vni uint32) {
+	vxlanSocketsMu.Lock()
+	defer vxlanSocketsMu.Unlock()
+	delete(s.vnis, vni)
+	if len(s.vnis) == 0 {
+		s.conn.Close()
+		// a failed socket may have been replaced already
+		if vxlanSockets[s.port] == s {
+			delete(vxlanSockets, s.port)
+		}
+	}
+}
+
+// fail unregisters and closes the socket after an error, so that the
+// virtual networks using it open a new one when they restart.
+func (s *vxlanSocket) fail() {
+	vxlanSocketsMu.Lock()
+	defer vxlanSocketsMu.Unlock()
+	if vxlanSockets[s.port] == s {
+		delete(vxlanSockets, s.port)
+	}
+	s.conn.Close()
+	close(s.deadCh)
+}
+
+// send encapsulates frame with the header of vni and sends it to the
+// VTEP at addr.
+func (s *vxlanSocket) send(vni uint32, addr *net.UDPAddr, frame []byte) (int, error) {
+	b := NewVXLAN(vni).Serialize()
+	b = append(b, frame...)
+	return s.conn.WriteToUDP(b, addr)
+}
+
+func (s *vxlanSocket) isClosed() bool {
+	vxlanSocketsMu.Lock()
+	defer vxlanSocketsMu.Unlock()
+	return vxlanSockets[s.port] != s
+}
+
+func (s *vxlanSocket) receive() {
+	buf := make([]byte, 65536)
+	for {
+		n, from, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			if s.isClosed() {
+				return
+			}
+			log.WithFields(log.Fields{
+				"Topic": "VirtualNetwork",
+				"Port":  s.port,
+			}).Errorf("failed to receive vxlan packets: %s", err)
+			s.fail()
+			return
+		}
+		v := &VXLAN{}
+		if err := v.DecodeFromBytes(buf[:n]); err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "VirtualNetwork",
+				"Port":  s.port,
+			}).Debugf("drop a packet from %s: %s", from, err)
+			continue
+		}
+		vxlanSocketsMu.Lock()
+		ch, ok := s.vnis[v.VNI]
+		vxlanSocketsMu.Unlock()
+		if !ok {
+			continue
+		}
+		// a slow virtual network drops its frames rather than the
+		// ones of the others
+		select {
+		case ch <- append([]byte(nil), buf[8:n]...):
+		default:
+		}
+	}
+}
+
+// closeUserspace closes the TAP device and the socket of a userspace
+// VTEP. Closing the device deletes it from the bridge.
+func (n *VirtualNetwork) closeUserspace() {
+	if n.sock != nil {
+		n.sock.close(n.config.VNI)
+		n.sock = nil
+	}
+	if n.tap != nil {
+		n.tap.Close()
+		n.tap = nil
+	}
+}
+
+// readTap passes the frames the bridge sends to the TAP device to the
+// main loop.
+func (n *VirtualNetwork) readTap(tap io.Reader, t *tomb.Tomb) error {
+	buf := make([]byte, 65536)
+	for {
+		l, err := tap.Read(buf)
+		select {
+		case <-t.Dying():
+			return nil
+		default:
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read from %s: %s", n.config.VtepInterface, err)
+		}
+		select {
+		case n.tapCh <- append([]byte(nil), buf[:l]...):
+		case <-t.Dying():
+			return nil
+		}
+	}
+}
+
+// forward sends a frame read from the TAP device to the VTEP of its
+// destination MAC learned from a Type-2 route. The broadcast, multicast
+// and unknown unicast frames are flooded to the VTEPs of the Type-3
+// routes by ingress replication.
+func (n *VirtualNetwork) forward(frame []byte) {
+	if len(frame) < 14 || n.sock == nil {
+		return
+	}
+	port := int(n.config.Port())
+	dst := net.HardwareAddr(frame[0:6])
+	if dst[0]&1 == 0 {
+		if e, ok := n.remoteFdb[dst.String()]; ok {
+			if _, err := n.sock.send(n.config.VNI, &net.UDPAddr{IP: e.Vtep, Port: port}, frame); err != nil {
+				log.WithFields(log.Fields{
+					"Topic": "VirtualNetwork",
+					"Key":   n.config.Key(),
+				}).Debugf("failed to send to %s: %s", e.Vtep, err)
+			}
+			return
+		}
+	}
+	for addr := range n.connMap {
+		cnt, err := n.sock.send(n.config.VNI, &net.UDPAddr{IP: net.ParseIP(addr), Port: port}, frame)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "VirtualNetwork",
+				"Key":   n.config.Key(),
+			}).Debugf("failed to flood to %s: %s", addr, err)
+			continue
+		}
+		n.floodPkts++
+		n.floodBytes += uint64(cnt)
+	}
+}
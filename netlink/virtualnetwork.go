@@ -16,8 +16,12 @@
 package netlink
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"net"
+	"sort"
+	"sync"
 	"syscall"
 	"time"
 
@@ -27,8 +31,8 @@ import (
 	"github.com/osrg/gobgp/pkg/packet/bgp"
 	"github.com/ttsubo/goplane/internal/pkg/table"
 	"github.com/ttsubo/goplane/config"
+	"github.com/ttsubo/goplane/dataplane"
 	"github.com/vishvananda/netlink"
-	"github.com/vishvananda/netlink/nl"
 	"golang.org/x/net/context"
 	"gopkg.in/tomb.v2"
 )
@@ -39,6 +43,12 @@ type netlinkEvent struct {
 	isWithdraw bool
 }
 
+type sniffer struct {
+	conn    *PFConn
+	index   int
+	closeCh chan struct{}
+}
+
 type VirtualNetwork struct {
 	t           tomb.Tomb
 	connMap     map[string]net.Conn
@@ -47,184 +57,474 @@ type VirtualNetwork struct {
 	macadvCh    chan *api.Path
 	floodCh     chan []byte
 	netlinkCh   chan *netlinkEvent
-	grpcHost    string
-	client      api.GobgpApiClient
+	tapCh       chan []byte
+	decapCh     chan []byte
+	updateCh    chan config.VirtualNetwork
+	mgmtCh      chan func()
+	doneCh      chan struct{}
+	resyncCh    chan struct{}
+	client      *Client
+	kernel      Kernel
+	tap         io.ReadWriteCloser
+	sock        *vxlanSocket
+	tunnels     map[string]string
+	floodVteps  map[string]struct{}
 	routerId    string
+	localAS     uint32
+	rd          string
+	importRts   []bgp.ExtendedCommunityInterface
+	exportRts   []bgp.ExtendedCommunityInterface
+	mu          sync.RWMutex
+	sniffers    map[string]*sniffer
+	localMacs   map[string]*dataplane.MacEntry
+	remoteFdb   map[string]*dataplane.FdbEntry
+	floodPkts   uint64
+	floodBytes  uint64
+	restarting  bool
+	staleFdb    map[string]*dataplane.FdbEntry
+	staleTime   time.Duration
+	// runTomb is killed when the current run of the virtual network
+	// finishes
+	runTomb   *tomb.Tomb
+	status    dataplane.VirtualNetworkStatus
+	lastError string
+	restarts  uint32
+	// macMoves are guarded by the main loop like localMacs
+	macMoves map[string]*macMoves
+	dupMac   config.DuplicateMac
+	// the State and Statistics saved by the main loop, so that they
+	// don't wait for the setup or the backoff of the virtual network
+	snapMu    sync.Mutex
+	snapState *dataplane.VirtualNetworkState
+	snapStats *dataplane.VirtualNetworkStatistics
+	// prevDoneCh is closed when the virtual network this one replaces
+	// has torn down
+	prevDoneCh <-chan struct{}
+	// keepRoutes is set before the virtual network is stopped on a
+	// shutdown keeping the state. Its routes aren't withdrawn then.
+	keepRoutes bool
 }
 
+// vxlanMulticastTTL is the TTL of the VXLAN packets sent to an underlay
+// multicast group.
+const vxlanMulticastTTL = 64
+
+// a failed virtual network is restarted after a backoff doubling from
+// vnMinBackoff up to vnMaxBackoff
+const (
+	vnMinBackoff = time.Second
+	vnMaxBackoff = time.Minute
+)
+
 func (n *VirtualNetwork) Stop() {
 	n.t.Kill(fmt.Errorf("admin stop"))
 }
 
-func (n *VirtualNetwork) modVrf(withdraw bool) error {
-	rd, err := bgp.ParseRouteDistinguisher(n.config.RD)
-	if err != nil {
-		return err
+// Update applies member and sniff interface changes to a running
+// virtual network. Other changes require the virtual network to be
+// recreated.
+func (n *VirtualNetwork) Update(c config.VirtualNetwork) {
+	select {
+	case n.updateCh <- c:
+	case <-n.doneCh:
 	}
-	rt, err := bgp.ParseRouteTarget(n.config.RD)
+}
+
+// mgmtOperation runs f in the main loop of the virtual network, or
+// directly once the main loop has finished.
+func (n *VirtualNetwork) mgmtOperation(f func()) {
+	ch := make(chan struct{})
+	select {
+	case n.mgmtCh <- func() {
+		f()
+		close(ch)
+	}:
+		<-ch
+	case <-n.doneCh:
+		f()
+	}
+}
+
+// State returns the state of the virtual network saved last by the
+// main loop, at most a second ago.
+func (n *VirtualNetwork) State() *dataplane.VirtualNetworkState {
+	select {
+	case <-n.doneCh:
+		return n.state()
+	default:
+	}
+	n.snapMu.Lock()
+	defer n.snapMu.Unlock()
+	return n.snapState
+}
+
+// Statistics returns the statistics of the virtual network saved last
+// by the main loop with the current queue depths.
+func (n *VirtualNetwork) Statistics() *dataplane.VirtualNetworkStatistics {
+	select {
+	case <-n.doneCh:
+		return n.statistics()
+	default:
+	}
+	n.snapMu.Lock()
+	s := *n.snapStats
+	n.snapMu.Unlock()
+	s.QueueDepths = n.queueDepths()
+	return &s
+}
+
+// saveSnapshot saves the state and the statistics returned by State
+// and Statistics. It is called from the main loop.
+func (n *VirtualNetwork) saveSnapshot() {
+	state, stats := n.state(), n.statistics()
+	n.snapMu.Lock()
+	defer n.snapMu.Unlock()
+	n.snapState, n.snapStats = state, stats
+}
+
+func (n *VirtualNetwork) state() *dataplane.VirtualNetworkState {
+	s := &dataplane.VirtualNetworkState{
+		Config:      n.config,
+		RD:          n.rd,
+		Bridge:      n.bridgeName(),
+		Vtep:        n.config.VtepInterface,
+		RemoteVteps: make([]string, 0, len(n.connMap)),
+		LocalMacs:   make([]dataplane.MacEntry, 0, len(n.localMacs)),
+		RemoteFdb:   make([]dataplane.FdbEntry, 0, len(n.remoteFdb)),
+		Status:      n.status,
+		LastError:   n.lastError,
+		Restarts:    n.restarts,
+	}
+	s.DuplicateMacs = n.duplicateMacs()
+	for addr := range n.connMap {
+		s.RemoteVteps = append(s.RemoteVteps, addr)
+	}
+	sort.Strings(s.RemoteVteps)
+	for _, e := range n.localMacs {
+		s.LocalMacs = append(s.LocalMacs, *e)
+	}
+	sort.Slice(s.LocalMacs, func(i, j int) bool {
+		return s.LocalMacs[i].Mac.String() < s.LocalMacs[j].Mac.String()
+	})
+	for _, e := range n.remoteFdb {
+		s.RemoteFdb = append(s.RemoteFdb, *e)
+	}
+	sort.Slice(s.RemoteFdb, func(i, j int) bool {
+		return s.RemoteFdb[i].Mac.String() < s.RemoteFdb[j].Mac.String()
+	})
+	return s
+}
+
+func (n *VirtualNetwork) statistics() *dataplane.VirtualNetworkStatistics {
+	return &dataplane.VirtualNetworkStatistics{
+		Name:         n.config.Key(),
+		VNI:          n.config.VNI,
+		FdbEntries:   len(n.remoteFdb),
+		RemoteVteps:  len(n.connMap),
+		FloodPackets: n.floodPkts,
+		FloodBytes:   n.floodBytes,
+		QueueDepths:  n.queueDepths(),
+	}
+}
+
+func (n *VirtualNetwork) queueDepths() map[string]int {
+	return map[string]int{
+		"multicast": len(n.multicastCh),
+		"macadv":    len(n.macadvCh),
+		"flood":     len(n.floodCh),
+		"netlink":   len(n.netlinkCh),
+		"tap":       len(n.tapCh),
+		"decap":     len(n.decapCh),
+	}
+}
+
+func (n *VirtualNetwork) bridgeName() string {
+	return fmt.Sprintf("br%d", n.config.VNI)
+}
+
+func (n *VirtualNetwork) routeTargets() ([]bgp.ExtendedCommunityInterface, []bgp.ExtendedCommunityInterface, error) {
+	return RouteTargets(&n.config, n.rd, n.localAS)
+}
+
+func (n *VirtualNetwork) isImported(attrs []bgp.PathAttributeInterface) bool {
+	return IsImported(&n.config, n.importRts, attrs) && EncapMatches(&n.config, attrs)
+}
+
+func (n *VirtualNetwork) modVrf(withdraw bool) error {
+	rd, err := bgp.ParseRouteDistinguisher(n.rd)
 	if err != nil {
 		return err
 	}
 	if withdraw {
-		return n.DeleteVRF(n.config.RD)
+		return n.DeleteVRF(n.config.Key())
 	}
-	log.Debugf("RD: %s", n.config.RD)
+	log.Debugf("RD: %s", n.rd)
 	log.Debugf("rd: %s", rd)
-	return n.AddVRF(n.config.RD, 0, rd, []bgp.ExtendedCommunityInterface{rt}, []bgp.ExtendedCommunityInterface{rt})
+	log.Debugf("import RT: %s, export RT: %s", n.importRts, n.exportRts)
+	return n.AddVRF(n.config.Key(), 0, rd, n.importRts, n.exportRts)
 }
 
+// Serve runs the virtual network until it is stopped. When it fails,
+// it is cleaned up and restarted with exponential backoff so that a
+// broken virtual network doesn't affect the others.
 func (n *VirtualNetwork) Serve() error {
-	ctx := context.Background()
-	client, cancel, err := NewClient(n.grpcHost, ctx)
-	if err != nil {
-		cancel()
-		log.Fatalf("%s", err)
+	defer close(n.doneCh)
+
+	if n.prevDoneCh != nil && !n.waitFor(n.prevDoneCh) {
+		n.status = dataplane.VN_STATUS_STOPPED
+		return nil
 	}
-	n.client = client
 
-	log.Debugf("vtep intf: %s", n.config.VtepInterface)
-	link, err := netlink.LinkByName(n.config.VtepInterface)
-	master := 0
-	if err == nil {
-		log.Debug("link type:", link.Type())
-		vtep := link.(*netlink.Vxlan)
-		err = netlink.LinkSetDown(vtep)
-		log.Debugf("set %s down", n.config.VtepInterface)
-		if err != nil {
-			return fmt.Errorf("failed to set link %s down", n.config.VtepInterface)
+	backoff := vnMinBackoff
+	for {
+		n.status = dataplane.VN_STATUS_STARTING
+		n.saveSnapshot()
+		start := time.Now()
+		err := n.run()
+		if err == nil {
+			n.status = dataplane.VN_STATUS_STOPPED
+			return nil
 		}
-		master = vtep.MasterIndex
-		log.Debugf("del %s", n.config.VtepInterface)
-		err = netlink.LinkDel(link)
-		if err != nil {
-			return fmt.Errorf("failed to del %s", n.config.VtepInterface)
+		n.status = dataplane.VN_STATUS_FAILED
+		n.lastError = err.Error()
+		n.restarts++
+		n.saveSnapshot()
+		if time.Since(start) > vnMaxBackoff {
+			backoff = vnMinBackoff
 		}
-	}
-
-	if master > 0 {
-		b, _ := netlink.LinkByIndex(master)
-		br := b.(*netlink.Bridge)
-		err = netlink.LinkSetDown(br)
-		log.Debugf("set %s down", br.LinkAttrs.Name)
-		if err != nil {
-			return fmt.Errorf("failed to set %s down", br.LinkAttrs.Name)
+		log.WithFields(log.Fields{
+			"Topic": "VirtualNetwork",
+			"Key":   n.config.Key(),
+		}).Errorf("failed: %s. restart in %s", err, backoff)
+		if !n.wait(backoff) {
+			n.status = dataplane.VN_STATUS_STOPPED
+			return nil
 		}
-		log.Debugf("del %s", br.LinkAttrs.Name)
-		err = netlink.LinkDel(br)
-		if err != nil {
-			return fmt.Errorf("failed to del %s", br.LinkAttrs.Name)
+		backoff *= 2
+		if backoff > vnMaxBackoff {
+			backoff = vnMaxBackoff
 		}
 	}
+}
 
-	brName := fmt.Sprintf("br%d", n.config.VNI)
+// wait waits for d while serving management operations. It returns
+// false if the virtual network is stopped in the meantime.
+func (n *VirtualNetwork) wait(d time.Duration) bool {
+	ch := make(chan struct{})
+	timer := time.AfterFunc(d, func() {
+		close(ch)
+	})
+	defer timer.Stop()
+	return n.waitFor(ch)
+}
 
-	b, err := netlink.LinkByName(brName)
-	if err == nil {
-		br := b.(*netlink.Bridge)
-		err = netlink.LinkSetDown(br)
-		log.Debugf("set %s down", br.LinkAttrs.Name)
-		if err != nil {
-			return fmt.Errorf("failed to set %s down", br.LinkAttrs.Name)
-		}
-		log.Debugf("del %s", br.LinkAttrs.Name)
-		err = netlink.LinkDel(br)
-		if err != nil {
-			return fmt.Errorf("failed to del %s", br.LinkAttrs.Name)
+// waitFor waits for ch to be closed while serving management
+// operations. It returns false if the virtual network is stopped in the
+// meantime.
+func (n *VirtualNetwork) waitFor(ch <-chan struct{}) bool {
+	for {
+		select {
+		case <-ch:
+			return true
+		case <-n.t.Dying():
+			return false
+		case f := <-n.mgmtCh:
+			f()
+			n.saveSnapshot()
+		case c := <-n.updateCh:
+			n.config.MemberInterfaces = c.MemberInterfaces
+			n.config.SniffInterfaces = c.SniffInterfaces
+			n.saveSnapshot()
 		}
 	}
+}
 
-	br := &netlink.Bridge{
-		LinkAttrs: netlink.LinkAttrs{
-			Name: brName,
-		},
+// cleanup releases what a failed run of the virtual network set up so
+// that the next run starts from scratch.
+func (n *VirtualNetwork) cleanup() {
+	n.runTomb.Kill(nil)
+	for ifname := range n.sniffers {
+		n.stopSniffer(ifname)
 	}
-
-	log.Debugf("add %s", brName)
-	err = netlink.LinkAdd(br)
-	if err != nil {
-		return fmt.Errorf("failed to add link %s. %s", brName, err)
+	for h, conn := range n.connMap {
+		log.Debugf("close udp connection to %s", h)
+		conn.Close()
 	}
-	err = netlink.LinkSetUp(br)
-	if err != nil {
-		return fmt.Errorf("failed to set %s up", brName)
+	n.closeUserspace()
+	// the goroutines of this run have to be gone before the next run
+	// creates the links again
+	n.runTomb.Wait()
+	n.connMap = map[string]net.Conn{}
+	n.localMacs = map[string]*dataplane.MacEntry{}
+	n.remoteFdb = map[string]*dataplane.FdbEntry{}
+	n.staleFdb = map[string]*dataplane.FdbEntry{}
+	n.tunnels = map[string]string{}
+	n.floodVteps = map[string]struct{}{}
+	if err := n.modVrf(true); err != nil {
+		log.WithFields(log.Fields{
+			"Topic": "VirtualNetwork",
+			"Key":   n.config.Key(),
+		}).Debugf("failed to delete vrf: %s", err)
 	}
+}
 
-	link = &netlink.Vxlan{
-		LinkAttrs: netlink.LinkAttrs{
-			Name: n.config.VtepInterface,
-		},
-		VxlanId: int(n.config.VNI),
-		SrcAddr: net.ParseIP(n.routerId),
-	}
+func (n *VirtualNetwork) run() (err error) {
+	t := &tomb.Tomb{}
+	// keep the tomb alive until it is killed, so that cleanup can wait
+	// for it even if the run fails before starting the goroutines
+	t.Go(func() error {
+		<-t.Dying()
+		return nil
+	})
+	n.runTomb = t
+	defer func() {
+		if err != nil {
+			n.cleanup()
+		}
+	}()
 
-	log.Debugf("add %s", n.config.VtepInterface)
-	err = netlink.LinkAdd(link)
-	if err != nil {
-		return fmt.Errorf("failed to add link %s. %s", n.config.VtepInterface, err)
-	}
-	err = netlink.LinkSetUp(link)
+	n.importRts, n.exportRts, err = n.routeTargets()
 	if err != nil {
-		return fmt.Errorf("failed to set %s up", n.config.VtepInterface)
+		return err
 	}
 
-	err = netlink.LinkSetMaster(link, br)
-	if err != nil {
-		return fmt.Errorf("failed to set master %s dev %s", brName, n.config.VtepInterface)
+	var br *netlink.Bridge
+	// the TAP device of a userspace VTEP goes with the previous process,
+	// and the geneve devices are created again from the routes
+	if n.restarting && !n.config.Userspace() && !n.config.Geneve() {
+		br, err = n.restoreLinks()
+		if err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "VirtualNetwork",
+				"Key":   n.config.Key(),
+			}).Warnf("failed to restore links. recreate them: %s", err)
+		}
 	}
-
-	for _, member := range n.config.MemberInterfaces {
-		m, err := netlink.LinkByName(member)
+	if br == nil {
+		br, err = n.createLinks()
 		if err != nil {
-			log.Errorf("can't find %s", member)
-			continue
+			return err
 		}
-		err = netlink.LinkSetUp(m)
+	}
+
+	for _, member := range n.config.MemberInterfaces {
+		err = n.addMember(br, member)
 		if err != nil {
-			return fmt.Errorf("failed to set %s up", member)
+			return err
 		}
-		err = netlink.LinkSetMaster(m, br)
+	}
+
+	err = n.setGateway(br)
+	if err != nil {
+		return err
+	}
+
+	if n.config.Userspace() {
+		n.sock, err = openVxlanSocket(n.config.Port(), n.config.VNI, n.decapCh)
 		if err != nil {
-			return fmt.Errorf("failed to set master %s dev %s", brName, member)
+			return err
 		}
 	}
 
 	withdraw := false
 	err = n.modVrf(withdraw)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("failed to add vrf: %s", err)
 	}
 
 	err = n.sendMulticast(withdraw)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("failed to advertise multicast route: %s", err)
 	}
 
-	n.t.Go(n.monitorBest)
-	n.t.Go(n.monitorNetlink)
+	err = n.advertiseGateway(withdraw)
+	if err != nil {
+		return fmt.Errorf("failed to advertise gateway: %s", err)
+	}
 
 	for _, member := range n.config.SniffInterfaces {
-		n.t.Go(func() error {
-			return n.sniffPkt(member)
+		err = n.startSniffer(member)
+		if err != nil {
+			return err
+		}
+	}
+
+	t.Go(func() error {
+		return n.monitorBest(t)
+	})
+	t.Go(func() error {
+		return n.monitorNetlink(t)
+	})
+	if n.tap != nil {
+		tap := n.tap
+		t.Go(func() error {
+			return n.readTap(tap, t)
 		})
 	}
+	if n.sock != nil {
+		sock := n.sock
+		t.Go(func() error {
+			select {
+			case <-sock.deadCh:
+				return fmt.Errorf("udp socket on port %d failed", sock.port)
+			case <-t.Dying():
+				return nil
+			}
+		})
+	}
+
+	if n.restarts > 0 {
+		log.WithFields(log.Fields{
+			"Topic": "VirtualNetwork",
+			"Key":   n.config.Key(),
+		}).Infof("recovered after %d restarts", n.restarts)
+	}
+	n.status = dataplane.VN_STATUS_RUNNING
+	n.saveSnapshot()
+
+	// the End-of-RIB check runs after a reconnection to gobgpd until
+	// stale entries are swept
+	var eorCh, staleCh <-chan time.Time
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	// the snapshot is saved on a tick only if the loop has handled an
+	// event since the last one
+	snapshotTicker := time.NewTicker(time.Second)
+	defer snapshotTicker.Stop()
+	changed := false
 
 	for {
 		select {
 		case <-n.t.Dying():
-			log.Errorf("stop virtualnetwork %s", n.config.RD)
+			log.Errorf("stop virtualnetwork %s", n.config.Key())
+			t.Kill(nil)
+			for ifname := range n.sniffers {
+				n.stopSniffer(ifname)
+			}
 			for h, conn := range n.connMap {
 				log.Debugf("close udp connection to %s", h)
 				conn.Close()
 			}
+			n.closeUserspace()
+			t.Wait()
+			if n.keepRoutes {
+				return nil
+			}
 			withdraw = true
+			n.advertiseGateway(withdraw)
 			n.modVrf(withdraw)
 			return nil
+		case <-t.Dying():
+			if err := t.Err(); err != nil {
+				return err
+			}
+			return fmt.Errorf("monitoring goroutines finished")
 		case p := <-n.multicastCh:
 			nlri, _ := apiutil.GetNativeNlri(p)
 			e := nlri.(*bgp.EVPNNLRI).RouteTypeData.(*bgp.EVPNMulticastEthernetTagRoute)
 			attrs, _ := apiutil.GetNativePathAttributes(p)
-			nexthop := getNextHopFromPathAttributes(attrs)
-			if e.ETag != n.config.Etag || nexthop.String() == "0.0.0.0" {
+			nexthop := GetNextHopFromPathAttributes(attrs)
+			if e.ETag != n.config.Etag || nexthop.String() == "0.0.0.0" || !n.isImported(attrs) {
 				continue
 			}
 			err = n.modConnMap(p)
@@ -232,12 +532,20 @@ func (n *VirtualNetwork) Serve() error {
 				log.Errorf("mod conn failed. kill main loop. err: %s", err)
 				return err
 			}
+			err = n.modFloodFdb(p)
+			if err != nil {
+				log.Errorf("mod flood fdb failed. kill main loop. err: %s", err)
+				return err
+			}
 		case p := <-n.macadvCh:
 			nlri, _ := apiutil.GetNativeNlri(p)
 			e := nlri.(*bgp.EVPNNLRI).RouteTypeData.(*bgp.EVPNMacIPAdvertisementRoute)
 			attrs, _ := apiutil.GetNativePathAttributes(p)
-			nexthop := getNextHopFromPathAttributes(attrs)
-			if e.ETag != n.config.Etag || nexthop.String() == "0.0.0.0" {
+			nexthop := GetNextHopFromPathAttributes(attrs)
+			if e.ETag != n.config.Etag || nexthop.String() == "0.0.0.0" || !n.isImported(attrs) || n.isGatewayMac(e.MacAddress) {
+				continue
+			}
+			if n.holdPath(p) {
 				continue
 			}
 			err = n.modFdb(p)
@@ -251,32 +559,436 @@ func (n *VirtualNetwork) Serve() error {
 				log.Errorf("flood failed. kill main loop. err: %s", err)
 				return err
 			}
+		case frame := <-n.tapCh:
+			n.forward(frame)
+		case frame := <-n.decapCh:
+			if _, err = n.tap.Write(frame); err != nil {
+				return fmt.Errorf("failed to write to %s: %s", n.config.VtepInterface, err)
+			}
 		case e := <-n.netlinkCh:
-			err = n.modPath(e)
+			if n.holdEvent(e) {
+				continue
+			}
+			err = n.modLocalMac(e)
 			if err != nil {
 				log.Errorf("modpath failed. kill main loop. err: %s", err)
 				return err
 			}
+		case <-n.resyncCh:
+			n.resync()
+			eorCh, staleCh = ticker.C, time.After(n.staleTime)
+		case <-eorCh:
+			done, err := n.client.endOfRibReceived()
+			if err != nil {
+				log.Warnf("failed to check End-of-RIB: %s", err)
+				continue
+			}
+			if done && len(n.macadvCh) == 0 {
+				n.sweepStale()
+				eorCh, staleCh = nil, nil
+			}
+		case <-staleCh:
+			n.sweepStale()
+			eorCh, staleCh = nil, nil
+		case <-snapshotTicker.C:
+			if changed {
+				n.saveSnapshot()
+				changed = false
+			}
+			continue
+		case f := <-n.mgmtCh:
+			f()
+			n.saveSnapshot()
+			continue
+		case c := <-n.updateCh:
+			log.Infof("update virtualnetwork %s", n.config.Key())
+			err = n.modMembers(c.MemberInterfaces)
+			if err != nil {
+				log.Errorf("mod member interfaces failed. err: %s", err)
+			}
+			n.modSniffers(c.SniffInterfaces)
 		}
+		changed = true
 	}
 }
 
+// createLinks deletes the bridge and the VTEP left by a previous run
+// and creates them from scratch.
+func (n *VirtualNetwork) createLinks() (*netlink.Bridge, error) {
+	if err := n.deleteTunnels(); err != nil {
+		return nil, err
+	}
+	log.Debugf("vtep intf: %s", n.config.VtepInterface)
+	link, err := n.kernel.LinkByName(n.config.VtepInterface)
+	master := 0
+	if err == nil {
+		log.Debug("link type:", link.Type())
+		err = n.kernel.LinkSetDown(link)
+		log.Debugf("set %s down", n.config.VtepInterface)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set link %s down", n.config.VtepInterface)
+		}
+		master = link.Attrs().MasterIndex
+		log.Debugf("del %s", n.config.VtepInterface)
+		err = n.kernel.LinkDel(link)
+		if err != nil {
+			return nil, fmt.Errorf("failed to del %s", n.config.VtepInterface)
+		}
+	}
+
+	if master > 0 {
+		b, _ := n.kernel.LinkByIndex(master)
+		br := b.(*netlink.Bridge)
+		err = n.kernel.LinkSetDown(br)
+		log.Debugf("set %s down", br.LinkAttrs.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set %s down", br.LinkAttrs.Name)
+		}
+		log.Debugf("del %s", br.LinkAttrs.Name)
+		err = n.kernel.LinkDel(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to del %s", br.LinkAttrs.Name)
+		}
+	}
+
+	brName := n.bridgeName()
+
+	b, err := n.kernel.LinkByName(brName)
+	if err == nil {
+		br := b.(*netlink.Bridge)
+		err = n.kernel.LinkSetDown(br)
+		log.Debugf("set %s down", br.LinkAttrs.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set %s down", br.LinkAttrs.Name)
+		}
+		log.Debugf("del %s", br.LinkAttrs.Name)
+		err = n.kernel.LinkDel(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to del %s", br.LinkAttrs.Name)
+		}
+	}
+
+	br := &netlink.Bridge{
+		LinkAttrs: netlink.LinkAttrs{
+			Name: brName,
+		},
+	}
+
+	log.Debugf("add %s", brName)
+	err = n.kernel.LinkAdd(br)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add link %s. %s", brName, err)
+	}
+	err = n.kernel.LinkSetUp(br)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set %s up", brName)
+	}
+
+	if n.config.Geneve() {
+		// the geneve devices are added for each remote VTEP
+		return br, nil
+	}
+	if n.config.Userspace() {
+		log.Debugf("open tap %s", n.config.VtepInterface)
+		n.tap, err = n.kernel.OpenTap(n.config.VtepInterface)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open tap %s. %s", n.config.VtepInterface, err)
+		}
+		link, err = n.kernel.LinkByName(n.config.VtepInterface)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s", n.config.VtepInterface)
+		}
+	} else {
+		vxlan := &netlink.Vxlan{
+			LinkAttrs: netlink.LinkAttrs{
+				Name: n.config.VtepInterface,
+			},
+			VxlanId: int(n.config.VNI),
+			SrcAddr: net.ParseIP(n.routerId),
+		}
+		if n.config.Multicast() {
+			dev, err := n.kernel.LinkByName(n.config.MulticastInterface)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get %s", n.config.MulticastInterface)
+			}
+			vxlan.Group = net.ParseIP(n.config.MulticastGroup)
+			vxlan.VtepDevIndex = dev.Attrs().Index
+			// the kernel sends to a multicast group with TTL 1
+			// by default, which doesn't cross a routed underlay
+			vxlan.TTL = vxlanMulticastTTL
+		}
+		link = vxlan
+
+		log.Debugf("add %s", n.config.VtepInterface)
+		err = n.kernel.LinkAdd(link)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add link %s. %s", n.config.VtepInterface, err)
+		}
+	}
+	err = n.kernel.LinkSetUp(link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set %s up", n.config.VtepInterface)
+	}
+
+	err = n.kernel.LinkSetMaster(link, br)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set master %s dev %s", brName, n.config.VtepInterface)
+	}
+
+	return br, nil
+}
+
+// deleteLinks deletes the VTEP and the bridge of the virtual network.
+// The FDB entries on the VTEP go with it.
+func (n *VirtualNetwork) deleteLinks() error {
+	if err := n.deleteTunnels(); err != nil {
+		return err
+	}
+	for _, name := range []string{n.config.VtepInterface, n.bridgeName()} {
+		link, err := n.kernel.LinkByName(name)
+		if err != nil {
+			continue
+		}
+		log.Debugf("del %s", name)
+		if err := n.kernel.LinkDel(link); err != nil {
+			return fmt.Errorf("failed to del %s", name)
+		}
+	}
+	return nil
+}
+
+// restoreLinks reuses the bridge and the VTEP left by the previous
+// goplane process on a graceful restart. The remote FDB entries on the
+// VTEP are kept as stale entries until they are refreshed by BGP or
+// swept.
+func (n *VirtualNetwork) restoreLinks() (*netlink.Bridge, error) {
+	link, err := n.kernel.LinkByName(n.config.VtepInterface)
+	if err != nil {
+		return nil, err
+	}
+	vtep, ok := link.(*netlink.Vxlan)
+	if !ok || vtep.VxlanId != int(n.config.VNI) || !vtep.SrcAddr.Equal(net.ParseIP(n.routerId)) || !vtep.Group.Equal(net.ParseIP(n.config.MulticastGroup)) {
+		return nil, fmt.Errorf("%s doesn't match the config", n.config.VtepInterface)
+	}
+	b, err := n.kernel.LinkByName(n.bridgeName())
+	if err != nil {
+		return nil, err
+	}
+	br, ok := b.(*netlink.Bridge)
+	if !ok || vtep.MasterIndex != br.Attrs().Index {
+		return nil, fmt.Errorf("%s isn't a member of %s", n.config.VtepInterface, n.bridgeName())
+	}
+	if err := n.kernel.LinkSetUp(br); err != nil {
+		return nil, fmt.Errorf("failed to set %s up", br.Attrs().Name)
+	}
+	if err := n.kernel.LinkSetUp(vtep); err != nil {
+		return nil, fmt.Errorf("failed to set %s up", n.config.VtepInterface)
+	}
+
+	neighs, err := n.kernel.NeighList(vtep.Attrs().Index, syscall.AF_BRIDGE)
+	if err != nil {
+		return nil, err
+	}
+	for _, neigh := range neighs {
+		// skip entries learned by the bridge and flooding entries
+		if neigh.IP == nil || neigh.IP.IsUnspecified() || len(neigh.HardwareAddr) == 0 || bytes.Equal(neigh.HardwareAddr, make([]byte, len(neigh.HardwareAddr))) {
+			continue
+		}
+		e := &dataplane.FdbEntry{
+			Mac:  neigh.HardwareAddr,
+			Vtep: neigh.IP,
+		}
+		n.remoteFdb[e.Mac.String()] = e
+		n.staleFdb[e.Mac.String()] = e
+	}
+	log.WithFields(log.Fields{
+		"Topic": "VirtualNetwork",
+		"Key":   n.config.Key(),
+	}).Infof("graceful restart: restored %s and %s with %d stale fdb entries", n.bridgeName(), n.config.VtepInterface, len(n.staleFdb))
+	return br, nil
+}
+
+// SweepStale deletes the FDB entries restored on a graceful restart
+// that haven't been refreshed by BGP.
+func (n *VirtualNetwork) SweepStale() {
+	n.mgmtOperation(func() {
+		n.restarting = false
+		n.sweepStale()
+	})
+}
+
+func (n *VirtualNetwork) sweepStale() {
+	if len(n.staleFdb) == 0 {
+		return
+	}
+	// the FDB of a userspace VTEP is remoteFdb itself
+	var link netlink.Link
+	if !n.config.Userspace() && !n.config.Geneve() {
+		var err error
+		link, err = n.kernel.LinkByName(n.config.VtepInterface)
+		if err != nil {
+			log.Warnf("failed to sweep stale fdb entries: %s", err)
+			return
+		}
+	}
+	for mac, e := range n.staleFdb {
+		log.WithFields(log.Fields{
+			"Topic": "VirtualNetwork",
+			"Key":   n.config.Key(),
+		}).Infof("sweep stale fdb entry %s dst %s", mac, e.Vtep)
+		if n.config.Geneve() {
+			n.delGeneveFdb(e)
+		} else if link != nil {
+			if err := n.kernel.NeighDel(fdbNeigh(link.Attrs().Index, e.Mac, e.Vtep)); err != nil {
+				log.Warnf("failed to del stale fdb entry %s: %s", mac, err)
+			}
+		}
+		delete(n.remoteFdb, mac)
+	}
+	n.staleFdb = map[string]*dataplane.FdbEntry{}
+}
+
+// resync is called when the stream from gobgpd is re-established. The
+// VRF and the local paths are added again in case gobgpd restarted, and
+// the remote FDB entries are kept as stale until they are refreshed by
+// the replayed paths.
+func (n *VirtualNetwork) resync() {
+	log.WithFields(log.Fields{
+		"Topic": "VirtualNetwork",
+		"Key":   n.config.Key(),
+	}).Info("reconnected to gobgpd. advertise the local paths again")
+	if err := n.modVrf(false); err != nil {
+		// gobgpd didn't restart if the VRF still exists
+		log.Debugf("failed to add vrf: %s", err)
+	}
+	if err := n.sendMulticast(false); err != nil {
+		log.Warnf("failed to advertise multicast route: %s", err)
+	}
+	if err := n.advertiseGateway(false); err != nil {
+		log.Warnf("failed to advertise gateway: %s", err)
+	}
+	for _, e := range n.localMacs {
+		if err := n.modPath(&netlinkEvent{mac: e.Mac, ip: e.IP}); err != nil {
+			log.Warnf("failed to advertise %s: %s", e.Mac, err)
+		}
+	}
+	for mac, e := range n.remoteFdb {
+		n.staleFdb[mac] = e
+	}
+}
+
+func inStringList(one string, list []string) bool {
+	for _, s := range list {
+		if s == one {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *VirtualNetwork) addMember(br netlink.Link, member string) error {
+	m, err := n.kernel.LinkByName(member)
+	if err != nil {
+		log.Errorf("can't find %s", member)
+		return nil
+	}
+	err = n.kernel.LinkSetUp(m)
+	if err != nil {
+		return fmt.Errorf("failed to set %s up", member)
+	}
+	err = n.kernel.LinkSetMaster(m, br.(*netlink.Bridge))
+	if err != nil {
+		return fmt.Errorf("failed to set master %s dev %s", br.Attrs().Name, member)
+	}
+	return nil
+}
+
+func (n *VirtualNetwork) modMembers(members []string) error {
+	brName := n.bridgeName()
+	br, err := n.kernel.LinkByName(brName)
+	if err != nil {
+		return fmt.Errorf("failed to get %s", brName)
+	}
+	for _, member := range members {
+		if inStringList(member, n.config.MemberInterfaces) {
+			continue
+		}
+		log.Debugf("add %s to %s", member, brName)
+		err = n.addMember(br, member)
+		if err != nil {
+			return err
+		}
+	}
+	for _, member := range n.config.MemberInterfaces {
+		if inStringList(member, members) {
+			continue
+		}
+		m, err := n.kernel.LinkByName(member)
+		if err != nil {
+			log.Errorf("can't find %s", member)
+			continue
+		}
+		log.Debugf("del %s from %s", member, brName)
+		err = n.kernel.LinkSetNoMaster(m)
+		if err != nil {
+			return fmt.Errorf("failed to set nomaster dev %s", member)
+		}
+	}
+	n.config.MemberInterfaces = members
+	return nil
+}
+
+func (n *VirtualNetwork) modSniffers(ifnames []string) {
+	for _, ifname := range ifnames {
+		n.mu.RLock()
+		_, ok := n.sniffers[ifname]
+		n.mu.RUnlock()
+		if ok {
+			continue
+		}
+		if err := n.startSniffer(ifname); err != nil {
+			log.Errorf("failed to start sniffing %s: %s", ifname, err)
+		}
+	}
+	n.mu.RLock()
+	stale := make([]string, 0, len(n.sniffers))
+	for ifname := range n.sniffers {
+		if !inStringList(ifname, ifnames) {
+			stale = append(stale, ifname)
+		}
+	}
+	n.mu.RUnlock()
+	for _, ifname := range stale {
+		n.stopSniffer(ifname)
+	}
+	n.config.SniffInterfaces = ifnames
+}
+
 func (f *VirtualNetwork) modConnMap(path *api.Path) error {
 	attrs, _ := apiutil.GetNativePathAttributes(path)
-	nexthop := getNextHopFromPathAttributes(attrs)
+	nexthop := GetNextHopFromPathAttributes(attrs)
 	addr := nexthop.String()
 	nlri, _ := apiutil.GetNativeNlri(path)
 	e := nlri.(*bgp.EVPNNLRI).RouteTypeData.(*bgp.EVPNMulticastEthernetTagRoute)
 	etag := e.ETag
 	log.Debugf("mod cannection map: nh %s, vtep addr %s etag %d withdraw %t", addr, path.GetNlri(), etag, path.IsWithdraw)
 	if path.IsWithdraw {
+		// a withdrawal may follow a failed dial or a restart of the
+		// virtual network, so an unknown VTEP isn't an error
 		_, ok := f.connMap[addr]
 		if !ok {
-			return fmt.Errorf("can't find %s conn", addr)
+			log.WithFields(log.Fields{
+				"Topic": "VirtualNetwork",
+				"Key":   f.config.Key(),
+			}).Debugf("ignore the withdrawal of unknown vtep %s", addr)
+			return nil
 		}
 
 		f.connMap[addr].Close()
 		delete(f.connMap, addr)
+		if f.config.Geneve() {
+			f.releaseTunnel(nexthop)
+		}
 	} else {
 		_, ok := f.connMap[addr]
 		if ok {
@@ -284,9 +996,16 @@ func (f *VirtualNetwork) modConnMap(path *api.Path) error {
 			f.connMap[addr].Close()
 			delete(f.connMap, addr)
 		}
-		udpAddr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", addr, f.config.VxlanPort))
+		port := f.config.VxlanPort
+		if f.config.Geneve() {
+			if _, err := f.addTunnel(nexthop); err != nil {
+				return err
+			}
+			port = f.config.Port()
+		}
+		udpAddr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", addr, port))
 		if err != nil {
-			log.Fatal(err)
+			return fmt.Errorf("failed to resolve vtep %s: %s", addr, err)
 		}
 
 		log.Debugf("connect to %s", addr)
@@ -304,9 +1023,65 @@ func (f *VirtualNetwork) modConnMap(path *api.Path) error {
 	return nil
 }
 
+// modFloodFdb replicates the BUM traffic of a virtual network using an
+// underlay multicast group to the remote VTEPs outside of the group,
+// such as the ones using ingress replication. The kernel sends the BUM
+// traffic to the group and to the destinations of the all-zeros FDB
+// entry.
+func (n *VirtualNetwork) modFloodFdb(path *api.Path) error {
+	if !n.config.Multicast() {
+		return nil
+	}
+	attrs, _ := apiutil.GetNativePathAttributes(path)
+	vtep := GetNextHopFromPathAttributes(attrs)
+	typ, group := pmsiGroup(attrs)
+	replicate := !path.IsWithdraw && !group.Equal(net.ParseIP(n.config.MulticastGroup))
+	if _, ok := n.floodVteps[vtep.String()]; ok == replicate {
+		return nil
+	}
+
+	link, err := n.kernel.LinkByName(n.config.VtepInterface)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"Topic": "VirtualNetwork",
+			"Key":   n.config.Key(),
+		}).Debugf("failed lookup link by name: %s", n.config.VtepInterface)
+		return nil
+	}
+	neigh := fdbNeigh(link.Attrs().Index, make(net.HardwareAddr, 6), vtep)
+	if replicate {
+		log.WithFields(log.Fields{
+			"Topic": "VirtualNetwork",
+			"Key":   n.config.Key(),
+		}).Infof("%s isn't in %s (pmsi tunnel type %d). replicate bum traffic to it", vtep, n.config.MulticastGroup, typ)
+		if err := n.kernel.NeighAppend(neigh); err != nil {
+			return fmt.Errorf("failed to add flood fdb entry dst %s: %s", vtep, err)
+		}
+		n.floodVteps[vtep.String()] = struct{}{}
+	} else {
+		if err := n.kernel.NeighDel(neigh); err != nil {
+			log.Warnf("failed to del flood fdb entry dst %s: %s", vtep, err)
+		}
+		delete(n.floodVteps, vtep.String())
+	}
+	return nil
+}
+
+func fdbNeigh(index int, mac net.HardwareAddr, vtep net.IP) *netlink.Neigh {
+	return &netlink.Neigh{
+		LinkIndex:    index,
+		Family:       int(netlink.NDA_VNI),
+		State:        int(netlink.NUD_NOARP | netlink.NUD_PERMANENT),
+		Type:         syscall.RTM_NEWNEIGH,
+		Flags:        int(netlink.NTF_SELF),
+		IP:           vtep,
+		HardwareAddr: mac,
+	}
+}
+
 func (f *VirtualNetwork) modFdb(path *api.Path) error {
 	attrs, _ := apiutil.GetNativePathAttributes(path)
-	nexthop := getNextHopFromPathAttributes(attrs)
+	nexthop := GetNextHopFromPathAttributes(attrs)
 	nlri, _ := apiutil.GetNativeNlri(path)
 	log.WithFields(log.Fields{
 		"Topic": "VirtualNetwork",
@@ -316,7 +1091,28 @@ func (f *VirtualNetwork) modFdb(path *api.Path) error {
 	e := nlri.(*bgp.EVPNNLRI).RouteTypeData.(*bgp.EVPNMacIPAdvertisementRoute)
 	mac := e.MacAddress
 
-	link, err := netlink.LinkByName(f.config.VtepInterface)
+	if f.config.Geneve() {
+		return f.modGeneveFdb(mac, nexthop, path.IsWithdraw)
+	}
+
+	if f.config.Userspace() {
+		delete(f.staleFdb, mac.String())
+		if path.IsWithdraw {
+			// the withdrawal of a MAC moved to another VTEP may
+			// come after the new route
+			if entry, ok := f.remoteFdb[mac.String()]; ok && entry.Vtep.Equal(nexthop) {
+				delete(f.remoteFdb, mac.String())
+			}
+		} else {
+			f.remoteFdb[mac.String()] = &dataplane.FdbEntry{
+				Mac:  mac,
+				Vtep: nexthop,
+			}
+		}
+		return nil
+	}
+
+	link, err := f.kernel.LinkByName(f.config.VtepInterface)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"Topic": "VirtualNetwork",
@@ -325,39 +1121,43 @@ func (f *VirtualNetwork) modFdb(path *api.Path) error {
 		return nil
 	}
 
-	n := &netlink.Neigh{
-		LinkIndex:    link.Attrs().Index,
-		Family:       int(netlink.NDA_VNI),
-		State:        int(netlink.NUD_NOARP | netlink.NUD_PERMANENT),
-		Type:         syscall.RTM_NEWNEIGH,
-		Flags:        int(netlink.NTF_SELF),
-		IP:           nexthop,
-		HardwareAddr: mac,
-	}
+	n := fdbNeigh(link.Attrs().Index, mac, nexthop)
+	delete(f.staleFdb, mac.String())
 
 	if path.IsWithdraw {
-		err = netlink.NeighDel(n)
+		err = f.kernel.NeighDel(n)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"Topic": "VirtualNetwork",
 				"Etag":  f.config.Etag,
 			}).Errorf("failed to del fdb: %s, %s", n, err)
+		} else if entry, ok := f.remoteFdb[mac.String()]; ok && entry.Vtep.Equal(nexthop) {
+			delete(f.remoteFdb, mac.String())
 		}
 	} else {
-		err = netlink.NeighAppend(n)
+		err = f.kernel.NeighAppend(n)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"Topic": "VirtualNetwork",
 				"Etag":  f.config.Etag,
 			}).Debugf("failed to add fdb: %s, %s", n, err)
+		} else {
+			f.remoteFdb[mac.String()] = &dataplane.FdbEntry{
+				Mac:  mac,
+				Vtep: nexthop,
+			}
 		}
 	}
 	return err
 }
 
 func (f *VirtualNetwork) flood(pkt []byte) error {
-	vxlanHeader := NewVXLAN(f.config.VNI)
-	b := vxlanHeader.Serialize()
+	var b []byte
+	if f.config.Geneve() {
+		b = NewGeneveHeader(f.config.VNI).Serialize()
+	} else {
+		b = NewVXLAN(f.config.VNI).Serialize()
+	}
 	b = append(b, pkt...)
 
 	for _, c := range f.connMap {
@@ -369,6 +1169,8 @@ func (f *VirtualNetwork) flood(pkt []byte) error {
 		if err != nil {
 			return err
 		}
+		f.floodPkts++
+		f.floodBytes += uint64(cnt)
 	}
 
 	return nil
@@ -381,7 +1183,7 @@ func (n *VirtualNetwork) sendMulticast(withdraw bool) error {
 	pattrs = append(pattrs, bgp.NewPathAttributeOrigin(bgp.BGP_ORIGIN_ATTR_TYPE_IGP))
 
 	//	var rd bgp.RouteDistinguisherInterface
-	rd, err := bgp.ParseRouteDistinguisher(n.config.RD)
+	rd, err := bgp.ParseRouteDistinguisher(n.rd)
 	if err != nil {
 		return err
 	}
@@ -396,21 +1198,19 @@ func (n *VirtualNetwork) sendMulticast(withdraw bool) error {
 	nexthop := "0.0.0.0"
 	pattrs = append(pattrs, bgp.NewPathAttributeMpReachNLRI(nexthop, []bgp.AddrPrefixInterface{nlri}))
 
-	id := &bgp.IngressReplTunnelID{
-		Value: net.ParseIP(n.routerId),
-	}
-	pattrs = append(pattrs, bgp.NewPathAttributePmsiTunnel(bgp.PMSI_TUNNEL_TYPE_INGRESS_REPL, false, 0, id))
+	pattrs = append(pattrs, pmsiTunnel(&n.config, net.ParseIP(n.routerId)))
+	pattrs = append(pattrs, bgp.NewPathAttributeExtendedCommunities([]bgp.ExtendedCommunityInterface{bgp.NewEncapExtended(TunnelType(&n.config))}))
 
 	path := table.NewPath(nil, nlri, withdraw, pattrs, time.Now(), false)
-	log.Debugf("RD: %s", n.config.RD)
-	_, err = n.AddVRFPath(n.config.RD, []*table.Path{path})
+	log.Debugf("RD: %s", n.rd)
+	_, err = n.AddVRFPath(n.config.Key(), []*table.Path{path})
 	return err
 }
 
 func (f *VirtualNetwork) modPath(n *netlinkEvent) error {
 	pattrs := []bgp.PathAttributeInterface{}
 
-	rd, err := bgp.ParseRouteDistinguisher(f.config.RD)
+	rd, err := bgp.ParseRouteDistinguisher(f.rd)
 	if err != nil {
 		return err
 	}
@@ -437,13 +1237,34 @@ func (f *VirtualNetwork) modPath(n *netlinkEvent) error {
 	//	o.SubType = bgp.EC_SUBTYPE_ENCAPSULATION
 	//	o.Value = &bgp.EncapExtended{bgp.TUNNEL_TYPE_VXLAN}
 	//	pattrs = append(pattrs, bgp.NewPathAttributeExtendedCommunities([]bgp.ExtendedCommunityInterface{o}))
-	pattrs = append(pattrs, bgp.NewPathAttributeExtendedCommunities([]bgp.ExtendedCommunityInterface{bgp.NewEncapExtended(bgp.TUNNEL_TYPE_VXLAN)}))
+	// this path goes to the global table, so the VRF doesn't attach
+	// the export route targets for us
+	ecs := []bgp.ExtendedCommunityInterface{bgp.NewEncapExtended(TunnelType(&f.config))}
+	ecs = append(ecs, f.exportRts...)
+	pattrs = append(pattrs, bgp.NewPathAttributeExtendedCommunities(ecs))
 	path := table.NewPath(nil, nlri, n.isWithdraw, pattrs, time.Now(), false)
 
 	_, err = f.AddPath([]*table.Path{path})
 	return err
 }
 
+// modLocalMac advertises or withdraws a MAC learned on a sniff
+// interface.
+func (n *VirtualNetwork) modLocalMac(e *netlinkEvent) error {
+	if err := n.modPath(e); err != nil {
+		return err
+	}
+	if e.isWithdraw {
+		delete(n.localMacs, e.mac.String())
+	} else {
+		n.localMacs[e.mac.String()] = &dataplane.MacEntry{
+			Mac: e.mac,
+			IP:  e.ip,
+		}
+	}
+	return nil
+}
+
 func (n *VirtualNetwork) addPath(vrfID string, pathList []*table.Path) ([]byte, error) {
 	resource := api.TableType_GLOBAL
 	if vrfID != "" {
@@ -454,7 +1275,7 @@ func (n *VirtualNetwork) addPath(vrfID string, pathList []*table.Path) ([]byte,
 		r, err := n.client.AddPath(context.Background(), &api.AddPathRequest{
 			TableType: resource,
 			VrfId:     vrfID,
-			Path:      toPathApi(path, nil),
+			Path:      ToPathApi(path, nil),
 		})
 		if err != nil {
 			return nil, err
@@ -497,120 +1318,206 @@ func (n *VirtualNetwork) DeleteVRF(name string) error {
 	return err
 }
 
-func (n *VirtualNetwork) monitorBest() error {
-	watcher, err := n.client.MonitorTable(context.Background(), &api.MonitorTableRequest{
-		TableType: api.TableType_GLOBAL,
-                Family: &api.Family{
-                        Afi:  api.Family_AFI_L2VPN,
-                        Safi: api.Family_SAFI_EVPN,
-                },
-		Current:   true,
-	})
-	if err != nil {
-		return err
+func (n *VirtualNetwork) monitorBest(t *tomb.Tomb) error {
+	family := &api.Family{
+		Afi:  api.Family_AFI_L2VPN,
+		Safi: api.Family_SAFI_EVPN,
 	}
-	for {
-		r, err := watcher.Recv()
-		if err != nil {
-			return err
+	return n.client.WatchBest(t, family, func() {
+		select {
+		case n.resyncCh <- struct{}{}:
+		case <-t.Dying():
 		}
-		paths := []*api.Path{r.Path}
-		path := paths[0]
+	}, func(path *api.Path) {
 		nlri, _ := apiutil.GetNativeNlri(path)
 
+		var ch chan *api.Path
 		switch nlri.(*bgp.EVPNNLRI).RouteType {
 		case bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT:
-			n.macadvCh <- path
+			ch = n.macadvCh
 		case bgp.EVPN_INCLUSIVE_MULTICAST_ETHERNET_TAG:
-			n.multicastCh <- path
+			ch = n.multicastCh
+		default:
+			return
 		}
-	}
+		select {
+		case ch <- path:
+		case <-t.Dying():
+		}
+	})
 }
 
-func (f *VirtualNetwork) sniffPkt(ifname string) error {
+func (f *VirtualNetwork) startSniffer(ifname string) error {
+	link, err := f.kernel.LinkByName(ifname)
+	if err != nil {
+		log.Errorf("failed to get link %s", ifname)
+		return err
+	}
+	if f.config.Userspace() {
+		// the bridge floods the broadcasts to the TAP device, so only
+		// the local MACs are learned from the interface
+		f.mu.Lock()
+		f.sniffers[ifname] = &sniffer{
+			index:   link.Attrs().Index,
+			closeCh: make(chan struct{}),
+		}
+		f.mu.Unlock()
+		return nil
+	}
 	conn, err := NewPFConn(ifname)
 	if err != nil {
 		return err
 	}
+	// wake up periodically so that a sniffer can be stopped
+	// without tearing down the whole virtual network
+	err = conn.SetReadTimeout(time.Second)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	s := &sniffer{
+		conn:    conn,
+		index:   link.Attrs().Index,
+		closeCh: make(chan struct{}),
+	}
+	log.WithFields(log.Fields{
+		"Topic": "VirtualNetwork",
+		"Etag":  f.config.Etag,
+	}).Debugf("monitoring: %s, index: %d", link.Attrs().Name, link.Attrs().Index)
+	f.mu.Lock()
+	f.sniffers[ifname] = s
+	f.mu.Unlock()
+	t := f.runTomb
+	t.Go(func() error {
+		return f.sniffPkt(s, t)
+	})
+	return nil
+}
+
+func (f *VirtualNetwork) stopSniffer(ifname string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.sniffers[ifname]
+	if !ok {
+		return
+	}
+	log.WithFields(log.Fields{
+		"Topic": "VirtualNetwork",
+		"Etag":  f.config.Etag,
+	}).Debugf("stop monitoring: %s", ifname)
+	close(s.closeCh)
+	delete(f.sniffers, ifname)
+}
+
+func (f *VirtualNetwork) isSniffing(index int) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, s := range f.sniffers {
+		if s.index == index {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *VirtualNetwork) sniffPkt(s *sniffer, t *tomb.Tomb) error {
+	defer s.conn.Close()
 	buf := make([]byte, 2048)
 	for {
-		n, err := conn.Read(buf)
+		n, err := s.conn.Read(buf)
+		select {
+		case <-s.closeCh:
+			return nil
+		case <-t.Dying():
+			return nil
+		default:
+		}
 		if err != nil {
-			log.Errorf("failed to recv from %s, err: %s", conn, err)
+			if err == syscall.EAGAIN || err == syscall.EINTR {
+				continue
+			}
+			log.Errorf("failed to recv from %s, err: %s", s.conn, err)
 			return err
 		}
 		log.WithFields(log.Fields{
 			"Topic": "VirtualNetwork",
 			"Etag":  f.config.Etag,
-		}).Debugf("recv from %s, len: %d", conn, n)
-		f.floodCh <- buf[:n]
+		}).Debugf("recv from %s, len: %d", s.conn, n)
+		select {
+		case f.floodCh <- buf[:n]:
+		case <-s.closeCh:
+			return nil
+		case <-t.Dying():
+			return nil
+		}
 	}
 }
 
-func (f *VirtualNetwork) monitorNetlink() error {
-	s, err := nl.Subscribe(syscall.NETLINK_ROUTE, uint(RTMGRP_NEIGH), uint(RTMGRP_LINK), uint(RTMGRP_NOTIFY))
-	if err != nil {
-		return err
-	}
-
-	idxs := make([]int, 0, len(f.config.SniffInterfaces))
-	for _, member := range f.config.SniffInterfaces {
-		link, err := netlink.LinkByName(member)
-		if err != nil {
-			log.Errorf("failed to get link %s", member)
-			return err
+func (f *VirtualNetwork) monitorNetlink(t *tomb.Tomb) error {
+	return f.kernel.MonitorNeigh(t.Dying(), func(n *netlink.Neigh, deleted bool) {
+		if !f.isSniffing(n.LinkIndex) {
+			return
 		}
 		log.WithFields(log.Fields{
 			"Topic": "VirtualNetwork",
 			"Etag":  f.config.Etag,
-		}).Debugf("monitoring: %s, index: %d", link.Attrs().Name, link.Attrs().Index)
-		idxs = append(idxs, link.Attrs().Index)
-	}
-
-	for {
-		msgs, err := s.Receive()
-		if err != nil {
-			return err
-		}
-
-		for _, msg := range msgs {
-			t := RTM_TYPE(msg.Header.Type)
-			switch t {
-			case RTM_NEWNEIGH, RTM_DELNEIGH:
-				n, _ := netlink.NeighDeserialize(msg.Data)
-				for _, idx := range idxs {
-					if n.LinkIndex == idx {
-						log.WithFields(log.Fields{
-							"Topic": "VirtualNetwork",
-							"Etag":  f.config.Etag,
-						}).Debugf("mac: %s, ip: %s, index: %d, family: %s, state: %s, type: %s, flags: %s", n.HardwareAddr, n.IP, n.LinkIndex, NDA_TYPE(n.Family), NUD_TYPE(n.State), RTM_TYPE(n.Type), NTF_TYPE(n.Flags))
-						var withdraw bool
-						if t == RTM_DELNEIGH {
-							withdraw = true
-						}
-						f.netlinkCh <- &netlinkEvent{n.HardwareAddr, n.IP, withdraw}
-						break
-					}
-				}
-			}
+		}).Debugf("mac: %s, ip: %s, index: %d, family: %s, state: %s, type: %s, flags: %s, deleted: %t", n.HardwareAddr, n.IP, n.LinkIndex, NDA_TYPE(n.Family), NUD_TYPE(n.State), RTM_TYPE(n.Type), NTF_TYPE(n.Flags), deleted)
+		select {
+		case f.netlinkCh <- &netlinkEvent{n.HardwareAddr, n.IP, deleted}:
+		case <-t.Dying():
 		}
-	}
+	})
 }
 
-func NewVirtualNetwork(config config.VirtualNetwork, routerId, grpcHost string) *VirtualNetwork {
+// NewVirtualNetwork creates a virtual network advertised via client.
+// When restarting is true, the links and the FDB entries left by the
+// previous goplane process are reused. Remote FDB entries are kept for
+// staleTime at most after a reconnection to gobgpd. The MACs moving too
+// often are frozen as dupMac configures. The links and the FDB entries
+// are programmed through kernel.
+func NewVirtualNetwork(config config.VirtualNetwork, routerId string, localAS uint32, client *Client, restarting bool, staleTime time.Duration, dupMac config.DuplicateMac, kernel Kernel) *VirtualNetwork {
 	macadvCh := make(chan *api.Path, 16)
 	multicastCh := make(chan *api.Path, 16)
 	floodCh := make(chan []byte, 16)
 	netlinkCh := make(chan *netlinkEvent, 16)
+	tapCh := make(chan []byte, 64)
+	decapCh := make(chan []byte, 64)
+	updateCh := make(chan config.VirtualNetwork)
+	mgmtCh := make(chan func())
+	doneCh := make(chan struct{})
+	resyncCh := make(chan struct{})
+
+	rd := RouteDistinguisher(&config, routerId)
 
-	return &VirtualNetwork{
+	n := &VirtualNetwork{
 		config:      config,
 		connMap:     map[string]net.Conn{},
 		macadvCh:    macadvCh,
 		multicastCh: multicastCh,
 		floodCh:     floodCh,
 		netlinkCh:   netlinkCh,
+		tapCh:       tapCh,
+		decapCh:     decapCh,
+		updateCh:    updateCh,
+		mgmtCh:      mgmtCh,
+		doneCh:      doneCh,
+		resyncCh:    resyncCh,
+		sniffers:    map[string]*sniffer{},
+		localMacs:   map[string]*dataplane.MacEntry{},
+		remoteFdb:   map[string]*dataplane.FdbEntry{},
+		staleFdb:    map[string]*dataplane.FdbEntry{},
+		tunnels:     map[string]string{},
+		floodVteps:  map[string]struct{}{},
+		restarting:  restarting,
+		staleTime:   staleTime,
+		macMoves:    map[string]*macMoves{},
+		dupMac:      dupMac,
 		routerId:    routerId,
-		grpcHost:    grpcHost,
+		localAS:     localAS,
+		rd:          rd,
+		client:      client,
+		kernel:      kernel,
 	}
+	n.saveSnapshot()
+	return n
 }
@@ -0,0 +1,433 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netlink
+
+import (
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	"github.com/stretchr/testify/assert"
+	"github.com/ttsubo/goplane/config"
+	"github.com/ttsubo/goplane/dataplane"
+	"github.com/ttsubo/goplane/internal/pkg/apiutil"
+	"github.com/ttsubo/goplane/internal/pkg/testutil"
+	"github.com/vishvananda/netlink"
+	"google.golang.org/grpc"
+)
+
+func fdbEntries(k *FakeKernel, vtep string) map[string]string {
+	link, err := k.LinkByName(vtep)
+	if err != nil {
+		return nil
+	}
+	neighs, _ := k.NeighList(link.Attrs().Index, syscall.AF_BRIDGE)
+	m := map[string]string{}
+	for _, n := range neighs {
+		m[n.HardwareAddr.String()] = n.IP.String()
+	}
+	return m
+}
+
+var testVirtualNetwork = config.VirtualNetwork{
+	RD:               "65000:10",
+	VNI:              10,
+	VxlanPort:        4789,
+	VtepInterface:    "vtep10",
+	MemberInterfaces: []string{"eth1"},
+}
+
+func TestVirtualNetworkFdb(t *testing.T) {
+	assert := assert.New(t)
+
+	k := NewFakeKernel()
+	n := NewVirtualNetwork(testVirtualNetwork, "10.0.0.1", 65000, nil, false, 0, config.DuplicateMac{}, k)
+	_, err := n.createLinks()
+	assert.Nil(err)
+
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:01", "10.0.0.2", false)))
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:02", "10.0.0.3", false)))
+	assert.Equal(map[string]string{
+		"aa:bb:cc:00:00:01": "10.0.0.2",
+		"aa:bb:cc:00:00:02": "10.0.0.3",
+	}, fdbEntries(k, "vtep10"))
+
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:02", "10.0.0.3", true)))
+	assert.Equal(map[string]string{"aa:bb:cc:00:00:01": "10.0.0.2"}, fdbEntries(k, "vtep10"))
+	assert.Len(n.remoteFdb, 1)
+
+	// on a graceful restart, the entries are restored as stale, and the
+	// ones not refreshed by BGP are swept
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:03", "10.0.0.3", false)))
+	n = NewVirtualNetwork(testVirtualNetwork, "10.0.0.1", 65000, nil, true, 0, config.DuplicateMac{}, k)
+	_, err = n.restoreLinks()
+	assert.Nil(err)
+	assert.Len(n.staleFdb, 2)
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:01", "10.0.0.2", false)))
+	n.sweepStale()
+	assert.Equal(map[string]string{"aa:bb:cc:00:00:01": "10.0.0.2"}, fdbEntries(k, "vtep10"))
+
+	// links of another VNI aren't reused
+	c := testVirtualNetwork
+	c.VNI = 20
+	n = NewVirtualNetwork(c, "10.0.0.1", 65000, nil, true, 0, config.DuplicateMac{}, k)
+	_, err = n.restoreLinks()
+	assert.NotNil(err)
+}
+
+func TestVirtualNetworkLifecycle(t *testing.T) {
+	assert := assert.New(t)
+
+	k := NewFakeKernel()
+	assert.Nil(k.LinkAdd(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth1"}}))
+	gobgp := testutil.NewGobgpClient()
+	n := NewVirtualNetwork(testVirtualNetwork, "10.0.0.1", 65000, &Client{GobgpApiClient: gobgp}, false, 0, config.DuplicateMac{}, k)
+	go n.Serve()
+
+	assert.Eventually(func() bool {
+		return n.State().Status == dataplane.VN_STATUS_RUNNING
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal([]string{"br10", "eth1", "lo", "vtep10"}, k.Links())
+	br, _ := k.LinkByName("br10")
+	for _, name := range []string{"eth1", "vtep10"} {
+		link, _ := k.LinkByName(name)
+		assert.Equal(br.Attrs().Index, link.Attrs().MasterIndex)
+	}
+	assert.True(gobgp.HasVrf("65000:10"))
+	assert.Len(gobgp.Paths(), 1)
+
+	// remote MACs are installed from the best paths
+	gobgp.SendBest(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:01", "10.0.0.2", false))
+	assert.Eventually(func() bool {
+		return len(n.State().RemoteFdb) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(map[string]string{"aa:bb:cc:00:00:01": "10.0.0.2"}, fdbEntries(k, "vtep10"))
+
+	// members are updated in place
+	n.Update(config.VirtualNetwork{})
+	assert.Eventually(func() bool {
+		link, _ := k.LinkByName("eth1")
+		return link.Attrs().MasterIndex == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	n.Stop()
+	<-n.doneCh
+	assert.Equal(dataplane.VN_STATUS_STOPPED, n.State().Status)
+	assert.False(gobgp.HasVrf("65000:10"))
+	assert.Nil(n.deleteLinks())
+	assert.Equal([]string{"eth1", "lo"}, k.Links())
+}
+
+func TestVirtualNetworkFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	// the gateway MAC is invalid, so every run fails before starting
+	// its goroutines
+	c := testVirtualNetwork
+	c.GatewayAddresses = []string{"192.168.10.1/24"}
+	c.GatewayMac = "invalid"
+	gobgp := testutil.NewGobgpClient()
+	n := NewVirtualNetwork(c, "10.0.0.1", 65000, &Client{GobgpApiClient: gobgp}, false, 0, config.DuplicateMac{}, NewFakeKernel())
+	go n.Serve()
+
+	assert.Eventually(func() bool {
+		return n.State().Status == dataplane.VN_STATUS_FAILED
+	}, 5*time.Second, 10*time.Millisecond)
+	s := n.State()
+	assert.NotEmpty(s.LastError)
+	assert.Equal(uint32(1), s.Restarts)
+	assert.Equal("65000:10", n.Statistics().Name)
+
+	n.Stop()
+	<-n.doneCh
+	assert.Equal(dataplane.VN_STATUS_STOPPED, n.State().Status)
+}
+
+func TestVirtualNetworkUserspace(t *testing.T) {
+	assert := assert.New(t)
+
+	// pick a free port for the vxlan socket
+	l, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.Nil(err)
+	port := l.LocalAddr().(*net.UDPAddr).Port
+	l.Close()
+
+	c := testVirtualNetwork
+	c.VtepMode = config.VtepModeUserspace
+	c.VxlanPort = uint16(port)
+	k := NewFakeKernel()
+	n := NewVirtualNetwork(c, "10.0.0.1", 65000, nil, false, 0, config.DuplicateMac{}, k)
+	_, err = n.createLinks()
+	assert.Nil(err)
+	defer n.closeUserspace()
+	br, _ := k.LinkByName("br10")
+	vtep, _ := k.LinkByName("vtep10")
+	assert.Equal("tuntap", vtep.Type())
+	assert.Equal(br.Attrs().Index, vtep.Attrs().MasterIndex)
+
+	// remote MACs aren't written to the kernel
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:01", "127.0.0.1", false)))
+	assert.Len(n.remoteFdb, 1)
+	assert.Empty(fdbEntries(k, "vtep10"))
+
+	// the late withdrawal of a moved MAC keeps the new VTEP
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:02", "10.0.0.2", false)))
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:02", "10.0.0.3", false)))
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:02", "10.0.0.2", true)))
+	assert.Equal("10.0.0.3", n.remoteFdb["aa:bb:cc:00:00:02"].Vtep.String())
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:02", "10.0.0.3", true)))
+	assert.Len(n.remoteFdb, 1)
+
+	// the remote VTEP is this host, so the encapsulated frame comes back
+	// to the socket and is decapsulated for the same VNI
+	n.sock, err = openVxlanSocket(c.Port(), c.VNI, n.decapCh)
+	assert.Nil(err)
+	frame := make([]byte, 64)
+	copy(frame, []byte{0xaa, 0xbb, 0xcc, 0x00, 0x00, 0x01})
+	n.forward(frame)
+	select {
+	case f := <-n.decapCh:
+		assert.Equal(frame, f)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the frame wasn't received")
+	}
+
+	// a VNI is received by one virtual network per port
+	_, err = openVxlanSocket(c.Port(), c.VNI, make(chan []byte))
+	assert.NotNil(err)
+
+	// a failed socket is replaced by the next virtual network opening
+	// the port
+	sock := n.sock
+	sock.fail()
+	<-sock.deadCh
+	s, err := openVxlanSocket(c.Port(), 20, make(chan []byte))
+	assert.Nil(err)
+	assert.False(sock == s)
+	s.close(20)
+
+	n.closeUserspace()
+	assert.Equal([]string{"br10", "lo"}, k.Links())
+}
+
+func TestVirtualNetworkGeneve(t *testing.T) {
+	assert := assert.New(t)
+
+	c := testVirtualNetwork
+	c.Encap = config.EncapGeneve
+	k := NewFakeKernel()
+	n := NewVirtualNetwork(c, "10.0.0.1", 65000, nil, false, 0, config.DuplicateMac{}, k)
+	br, err := n.createLinks()
+	assert.Nil(err)
+	assert.Equal([]string{"br10", "lo"}, k.Links())
+
+	// a geneve device is added for each remote VTEP
+	assert.Nil(n.modConnMap(testutil.NewEvpnPath(bgp.EVPN_INCLUSIVE_MULTICAST_ETHERNET_TAG, "", "127.0.0.2", false)))
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:01", "127.0.0.2", false)))
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:02", "127.0.0.3", false)))
+	assert.Equal([]string{"br10", "lo", "vtep10-0", "vtep10-1"}, k.Links())
+	link, _ := k.LinkByName("vtep10-1")
+	g, ok := link.(*Geneve)
+	assert.True(ok)
+	assert.Equal(uint32(10), g.ID)
+	assert.Equal("127.0.0.3", g.Remote.String())
+	assert.Equal(uint16(4789), g.Dport)
+	assert.Equal(br.Attrs().Index, g.MasterIndex)
+	neighs, _ := k.NeighList(g.Index, syscall.AF_BRIDGE)
+	assert.Len(neighs, 1)
+	assert.Equal("aa:bb:cc:00:00:02", neighs[0].HardwareAddr.String())
+
+	// the device goes with the last MAC moving away, and the late
+	// withdrawal from the previous VTEP is ignored
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:02", "127.0.0.2", false)))
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:02", "127.0.0.3", true)))
+	assert.Equal([]string{"br10", "lo", "vtep10-0"}, k.Links())
+	link, _ = k.LinkByName("vtep10-0")
+	neighs, _ = k.NeighList(link.Attrs().Index, syscall.AF_BRIDGE)
+	assert.Len(neighs, 2)
+
+	// the device stays while the Type-3 route is there
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:01", "127.0.0.2", true)))
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:02", "127.0.0.2", true)))
+	assert.Equal([]string{"br10", "lo", "vtep10-0"}, k.Links())
+	assert.Nil(n.modConnMap(testutil.NewEvpnPath(bgp.EVPN_INCLUSIVE_MULTICAST_ETHERNET_TAG, "", "127.0.0.2", true)))
+	assert.Equal([]string{"br10", "lo"}, k.Links())
+
+	// the withdrawal of an unknown VTEP is ignored
+	assert.Nil(n.modConnMap(testutil.NewEvpnPath(bgp.EVPN_INCLUSIVE_MULTICAST_ETHERNET_TAG, "", "127.0.0.4", true)))
+	assert.Equal([]string{"br10", "lo"}, k.Links())
+
+	// routes of another encapsulation aren't used
+	encap := func(typ bgp.TunnelType) []bgp.PathAttributeInterface {
+		return []bgp.PathAttributeInterface{
+			bgp.NewPathAttributeExtendedCommunities([]bgp.ExtendedCommunityInterface{bgp.NewEncapExtended(typ)}),
+		}
+	}
+	assert.True(n.isImported(nil))
+	assert.True(n.isImported(encap(bgp.TUNNEL_TYPE_GENEVE)))
+	assert.False(n.isImported(encap(bgp.TUNNEL_TYPE_VXLAN)))
+
+	assert.Nil(n.modFdb(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:01", "127.0.0.2", false)))
+	assert.Nil(n.deleteLinks())
+	assert.Equal([]string{"lo"}, k.Links())
+}
+
+func TestVirtualNetworkMulticast(t *testing.T) {
+	assert := assert.New(t)
+
+	c := testVirtualNetwork
+	c.MulticastGroup = "239.1.1.10"
+	c.MulticastInterface = "eth0"
+	k := NewFakeKernel()
+	assert.Nil(k.LinkAdd(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0"}}))
+	n := NewVirtualNetwork(c, "10.0.0.1", 65000, nil, false, 0, config.DuplicateMac{}, k)
+	_, err := n.createLinks()
+	assert.Nil(err)
+	link, _ := k.LinkByName("vtep10")
+	dev, _ := k.LinkByName("eth0")
+	vtep := link.(*netlink.Vxlan)
+	assert.Equal("239.1.1.10", vtep.Group.String())
+	assert.Equal(dev.Attrs().Index, vtep.VtepDevIndex)
+
+	// the group is advertised as a PIM-SM tree, or a PIM-SSM one in
+	// the SSM range
+	pmsi := pmsiTunnel(&c, net.ParseIP("10.0.0.1"))
+	typ, group := pmsiGroup([]bgp.PathAttributeInterface{pmsi})
+	assert.Equal(bgp.PMSI_TUNNEL_TYPE_PIM_SM_TREE, typ)
+	assert.Equal("239.1.1.10", group.String())
+	ssm := c
+	ssm.MulticastGroup = "232.1.1.10"
+	typ, group = pmsiGroup([]bgp.PathAttributeInterface{pmsiTunnel(&ssm, net.ParseIP("10.0.0.1"))})
+	assert.Equal(bgp.PMSI_TUNNEL_TYPE_PIM_SSM_TREE, typ)
+	assert.Equal("232.1.1.10", group.String())
+
+	// VTEPs in the group get the BUM traffic from the group, and the
+	// others by ingress replication
+	assert.Nil(n.modFloodFdb(testutil.NewEvpnPath(bgp.EVPN_INCLUSIVE_MULTICAST_ETHERNET_TAG, "", "10.0.0.2", false, pmsiTunnel(&c, net.ParseIP("10.0.0.2")))))
+	assert.Empty(fdbEntries(k, "vtep10"))
+	assert.Nil(n.modFloodFdb(testutil.NewEvpnPath(bgp.EVPN_INCLUSIVE_MULTICAST_ETHERNET_TAG, "", "10.0.0.3", false, pmsiTunnel(&testVirtualNetwork, net.ParseIP("10.0.0.3")))))
+	assert.Nil(n.modFloodFdb(testutil.NewEvpnPath(bgp.EVPN_INCLUSIVE_MULTICAST_ETHERNET_TAG, "", "10.0.0.4", false, pmsiTunnel(&ssm, net.ParseIP("10.0.0.4")))))
+	neighs, _ := k.NeighList(vtep.Index, syscall.AF_BRIDGE)
+	vteps := []string{}
+	for _, neigh := range neighs {
+		assert.Equal("00:00:00:00:00:00", neigh.HardwareAddr.String())
+		vteps = append(vteps, neigh.IP.String())
+	}
+	assert.Equal([]string{"10.0.0.3", "10.0.0.4"}, vteps)
+
+	assert.Nil(n.modFloodFdb(testutil.NewEvpnPath(bgp.EVPN_INCLUSIVE_MULTICAST_ETHERNET_TAG, "", "10.0.0.3", true, pmsiTunnel(&testVirtualNetwork, net.ParseIP("10.0.0.3")))))
+	assert.Equal(map[string]string{"00:00:00:00:00:00": "10.0.0.4"}, fdbEntries(k, "vtep10"))
+}
+
+func TestVirtualNetworkGateway(t *testing.T) {
+	assert := assert.New(t)
+
+	c := testVirtualNetwork
+	c.GatewayAddresses = []string{"192.168.10.1/24", "2001:db8:10::1/64"}
+	c.GatewayMac = "00:00:5e:00:01:01"
+	k := NewFakeKernel()
+	gobgp := testutil.NewGobgpClient()
+	n := NewVirtualNetwork(c, "10.0.0.1", 65000, &Client{GobgpApiClient: gobgp}, false, 0, config.DuplicateMac{}, k)
+	br, err := n.createLinks()
+	assert.Nil(err)
+	assert.Nil(n.setGateway(br))
+	// setting it again leaves the addresses as they are
+	assert.Nil(n.setGateway(br))
+	link, _ := k.LinkByName("br10")
+	assert.Equal("00:00:5e:00:01:01", link.Attrs().HardwareAddr.String())
+	addrs, _ := k.AddrList(link, netlink.FAMILY_ALL)
+	assert.Len(addrs, 2)
+
+	// the gateway addresses are advertised with the Default Gateway
+	// extended community, a sticky MAC and the export route targets
+	n.importRts, n.exportRts, err = n.routeTargets()
+	assert.Nil(err)
+	assert.Nil(n.advertiseGateway(false))
+	assert.Len(gobgp.Paths(), 2)
+	for _, p := range gobgp.Paths() {
+		nlri, _ := apiutil.GetNativeNlri(p)
+		e := nlri.(*bgp.EVPNNLRI).RouteTypeData.(*bgp.EVPNMacIPAdvertisementRoute)
+		assert.Equal("00:00:5e:00:01:01", e.MacAddress.String())
+		attrs, _ := apiutil.GetNativePathAttributes(p)
+		gateway, sticky := false, false
+		for _, a := range attrs {
+			if ecs, ok := a.(*bgp.PathAttributeExtendedCommunities); ok {
+				for _, ec := range ecs.Value {
+					switch v := ec.(type) {
+					case *bgp.DefaultGatewayExtended:
+						gateway = true
+					case *bgp.MacMobilityExtended:
+						sticky = v.IsSticky
+					}
+				}
+			}
+		}
+		assert.True(gateway)
+		assert.True(sticky)
+		assert.Equal("65000:10", routeTargetsFromPathAttributes(attrs)[0].String())
+	}
+
+	// the routes of the other hosts for the gateway are ignored
+	mac, _ := net.ParseMAC("00:00:5e:00:01:01")
+	assert.True(n.isGatewayMac(mac))
+	mac, _ = net.ParseMAC("aa:bb:cc:00:00:01")
+	assert.False(n.isGatewayMac(mac))
+}
+
+func TestVirtualNetworkDuplicateMac(t *testing.T) {
+	assert := assert.New(t)
+
+	k := NewFakeKernel()
+	n := NewVirtualNetwork(testVirtualNetwork, "10.0.0.1", 65000, nil, false, 0, config.DuplicateMac{Moves: 3}, k)
+	_, err := n.createLinks()
+	assert.Nil(err)
+	// the main loop isn't running, so the management operations run
+	// directly
+	close(n.doneCh)
+
+	mac, _ := net.ParseMAC("aa:bb:cc:00:00:01")
+	for _, vtep := range []string{"10.0.0.2", "10.0.0.3", "10.0.0.2"} {
+		p := testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, mac.String(), vtep, false)
+		assert.False(n.holdPath(p))
+		assert.Nil(n.modFdb(p))
+	}
+	// the third move, to the local host, freezes the MAC
+	assert.True(n.holdEvent(&netlinkEvent{mac: mac}))
+	assert.True(n.holdPath(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, mac.String(), "10.0.0.3", false)))
+	assert.True(n.holdPath(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, mac.String(), "10.0.0.2", true)))
+	assert.Equal("10.0.0.2", n.remoteFdb[mac.String()].Vtep.String())
+	dups := n.State().DuplicateMacs
+	assert.Len(dups, 1)
+	assert.Equal(mac.String(), dups[0].Mac.String())
+	assert.Equal("10.0.0.3", dups[0].Vtep.String())
+	assert.Equal(3, dups[0].Moves)
+
+	// clearing applies the route held last
+	assert.Nil(n.ClearDuplicateMac(mac))
+	assert.Equal("10.0.0.3", n.remoteFdb[mac.String()].Vtep.String())
+	assert.Empty(n.State().DuplicateMacs)
+	assert.NotNil(n.ClearDuplicateMac(mac))
+	assert.False(n.holdPath(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, mac.String(), "10.0.0.2", false)))
+
+	// the routes of a MAC gone for good are forgotten
+	other, _ := net.ParseMAC("aa:bb:cc:00:00:02")
+	assert.False(n.holdPath(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, other.String(), "10.0.0.2", false)))
+	assert.False(n.holdPath(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, other.String(), "10.0.0.2", true)))
+	_, ok := n.macMoves[other.String()]
+	assert.False(ok)
+}
@@ -15,6 +15,8 @@
 
 package netlink
 
+import "fmt"
+
 type VXLAN struct {
 	VNI uint32
 }
@@ -33,3 +35,16 @@ func (v *VXLAN) Serialize() []byte {
 	buf[6] = byte(v.VNI & 0xff)
 	return buf
 }
+
+// DecodeFromBytes reads the VXLAN header at the beginning of data. The
+// encapsulated frame follows it.
+func (v *VXLAN) DecodeFromBytes(data []byte) error {
+	if len(data) < 8 {
+		return fmt.Errorf("vxlan header is too short: %d bytes", len(data))
+	}
+	if data[0]&(1<<3) == 0 {
+		return fmt.Errorf("vxlan header has no valid VNI")
+	}
+	v.VNI = uint32(data[4])<<16 | uint32(data[5])<<8 | uint32(data[6])
+	return nil
+}
@@ -0,0 +1,871 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsdb
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"golang.org/x/net/context"
+
+	api "github.com/osrg/gobgp/api"
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	log "github.com/sirupsen/logrus"
+	"github.com/ttsubo/goplane/config"
+	"github.com/ttsubo/goplane/dataplane"
+	"github.com/ttsubo/goplane/internal/pkg/apiutil"
+	db "github.com/ttsubo/goplane/internal/pkg/ovsdb"
+	"github.com/ttsubo/goplane/internal/pkg/table"
+	"github.com/ttsubo/goplane/netlink"
+	"gopkg.in/tomb.v2"
+)
+
+// the connection to the ovsdb-server is retried after ovsdbRetryInterval
+const ovsdbRetryInterval = 5 * time.Second
+
+// unknownDst is the MAC of the Mcast_Macs_Remote row receiving the
+// broadcast, unknown unicast and multicast traffic.
+const unknownDst = "unknown-dst"
+
+type mgmtOp struct {
+	f     func() error
+	errCh chan error
+}
+
+// localMac is a row of Ucast_Macs_Local.
+type localMac struct {
+	dataplane.MacEntry
+	ls db.UUID
+}
+
+// Dataplane programs a switch through the hardware_vtep database of its
+// ovsdb-server. Each virtual network is a Logical_Switch whose
+// tunnel_key is the VNI. The remote MACs and VTEPs learned by BGP are
+// written to Ucast_Macs_Remote and Mcast_Macs_Remote, and the MACs the
+// switch learns in Ucast_Macs_Local are advertised.
+type Dataplane struct {
+	t        tomb.Tomb
+	config   *config.Config
+	grpcHost string
+	client   *netlink.Client
+	// conn is nil while the ovsdb-server isn't connected
+	conn     *db.Client
+	database string
+	routerId string
+	localAS  uint32
+	vnMap    map[string]*VirtualNetwork
+	// locators map the dst_ip of the Physical_Locator rows to their
+	// UUIDs
+	locators  map[string]db.UUID
+	localMacs map[db.UUID]*localMac
+	evpnCh    chan *api.Path
+	resyncCh  chan struct{}
+	mgmtCh    chan *mgmtOp
+}
+
+// txn collects the operations of a transaction and the
+// Physical_Locator rows it inserts.
+type txn struct {
+	ops []db.Operation
+	// inserted maps the dst_ip of the inserted locators to the index of
+	// their operation
+	inserted map[string]int
+	// cached are the locators referred to by the UUID cached in
+	// Dataplane.locators
+	cached []string
+}
+
+func (t *txn) add(ops ...db.Operation) {
+	t.ops = append(t.ops, ops...)
+}
+
+// locator returns the reference to the Physical_Locator of vtep,
+// inserting it in t unless it is known to exist.
+func (d *Dataplane) locator(t *txn, vtep net.IP) interface{} {
+	addr := vtep.String()
+	if u, ok := d.locators[addr]; ok {
+		t.cached = append(t.cached, addr)
+		return u
+	}
+	if i, ok := t.inserted[addr]; ok {
+		return db.NamedUUID(t.ops[i].UUIDName)
+	}
+	name := fmt.Sprintf("locator%d", len(t.inserted))
+	t.inserted[addr] = len(t.ops)
+	t.add(db.Insert("Physical_Locator", db.Row{
+		"encapsulation_type": "vxlan_over_ipv4",
+		"dst_ip":             addr,
+	}, name))
+	return db.NamedUUID(name)
+}
+
+// transact runs the operations added by build. The server garbage
+// collects the Physical_Locator rows no longer referred to, so the
+// cached ones may be gone: the transaction is built again without them
+// when it fails.
+func (d *Dataplane) transact(build func(t *txn)) error {
+	if d.conn == nil {
+		return nil
+	}
+	for retry := false; ; retry = true {
+		t := &txn{
+			inserted: make(map[string]int),
+		}
+		build(t)
+		if len(t.ops) == 0 {
+			return nil
+		}
+		results, err := d.conn.Transact(d.database, t.ops...)
+		if err == nil {
+			for addr, i := range t.inserted {
+				d.locators[addr] = results[i].UUID
+			}
+			return nil
+		}
+		if _, ok := err.(db.TransactError); !ok || retry || len(t.cached) == 0 {
+			return err
+		}
+		for _, addr := range t.cached {
+			delete(d.locators, addr)
+		}
+	}
+}
+
+func (d *Dataplane) routerIdPath() *table.Path {
+	return table.NewPath(nil, bgp.NewIPAddrPrefix(uint8(32), d.routerId), false, []bgp.PathAttributeInterface{
+		bgp.NewPathAttributeNextHop("0.0.0.0"),
+		bgp.NewPathAttributeOrigin(bgp.BGP_ORIGIN_ATTR_TYPE_IGP),
+	}, time.Now(), false)
+}
+
+func (d *Dataplane) addPath(vrfID string, path *table.Path) error {
+	resource := api.TableType_GLOBAL
+	if vrfID != "" {
+		resource = api.TableType_VRF
+	}
+	_, err := d.client.AddPath(context.Background(), &api.AddPathRequest{
+		TableType: resource,
+		VrfId:     vrfID,
+		Path:      netlink.ToPathApi(path, nil),
+	})
+	return err
+}
+
+func (d *Dataplane) modVrf(vn *VirtualNetwork, withdraw bool) error {
+	if withdraw {
+		_, err := d.client.DeleteVrf(context.Background(), &api.DeleteVrfRequest{
+			Name: vn.config.Key(),
+		})
+		return err
+	}
+	rd, err := bgp.ParseRouteDistinguisher(vn.rd)
+	if err != nil {
+		return err
+	}
+	_, err = d.client.AddVrf(context.Background(), &api.AddVrfRequest{
+		Vrf: &api.Vrf{
+			Name:     vn.config.Key(),
+			Rd:       apiutil.MarshalRD(rd),
+			ImportRt: apiutil.MarshalRTs(vn.importRts),
+			ExportRt: apiutil.MarshalRTs(vn.exportRts),
+		},
+	})
+	return err
+}
+
+// modEvpn updates the remote VTEPs and MACs of the virtual networks
+// importing a best EVPN path.
+func (d *Dataplane) modEvpn(p *api.Path) error {
+	nlri, err := apiutil.GetNativeNlri(p)
+	if err != nil {
+		return err
+	}
+	attrs, err := apiutil.GetNativePathAttributes(p)
+	if err != nil {
+		return err
+	}
+	nexthop := netlink.GetNextHopFromPathAttributes(attrs)
+	if nexthop == nil || nexthop.IsUnspecified() {
+		return nil
+	}
+	switch r := nlri.(*bgp.EVPNNLRI).RouteTypeData.(type) {
+	case *bgp.EVPNMulticastEthernetTagRoute:
+		for _, vn := range d.vnMap {
+			if !vn.isImported(r.ETag, attrs) {
+				continue
+			}
+			if err := d.modRemoteVtep(vn, nexthop, p.IsWithdraw); err != nil {
+				return err
+			}
+		}
+	case *bgp.EVPNMacIPAdvertisementRoute:
+		var ip net.IP
+		if r.IPAddressLength > 0 {
+			ip = r.IPAddress
+		}
+		for _, vn := range d.vnMap {
+			if !vn.isImported(r.ETag, attrs) {
+				continue
+			}
+			e := &remoteMac{
+				FdbEntry: dataplane.FdbEntry{
+					Mac:  r.MacAddress,
+					Vtep: nexthop,
+				},
+				ip: ip,
+			}
+			if err := d.modRemoteMac(vn, e, p.IsWithdraw); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeMcast replaces the unknown-dst row of vn with the one flooding
+// to the current remote VTEPs.
+func (d *Dataplane) writeMcast(vn *VirtualNetwork) error {
+	return d.transact(func(t *txn) {
+		t.add(db.Delete("Mcast_Macs_Remote", db.Equal("MAC", unknownDst), db.Equal("logical_switch", vn.lsUUID)))
+		if len(vn.remoteVteps) == 0 {
+			return
+		}
+		addrs := make([]string, 0, len(vn.remoteVteps))
+		for addr := range vn.remoteVteps {
+			addrs = append(addrs, addr)
+		}
+		sort.Strings(addrs)
+		locators := make(db.Set, 0, len(addrs))
+		for _, addr := range addrs {
+			locators = append(locators, d.locator(t, vn.remoteVteps[addr]))
+		}
+		t.add(db.Insert("Physical_Locator_Set", db.Row{
+			"locators": locators,
+		}, "set"), db.Insert("Mcast_Macs_Remote", db.Row{
+			"MAC":            unknownDst,
+			"logical_switch": vn.lsUUID,
+			"locator_set":    db.NamedUUID("set"),
+		}, ""))
+	})
+}
+
+// writeRemoteMac replaces the Ucast_Macs_Remote row of mac in vn.
+func (d *Dataplane) writeRemoteMac(vn *VirtualNetwork, mac net.HardwareAddr) error {
+	return d.transact(func(t *txn) {
+		t.add(db.Delete("Ucast_Macs_Remote", db.Equal("MAC", mac.String()), db.Equal("logical_switch", vn.lsUUID)))
+		e := vn.remoteMacEntry(mac)
+		if e == nil {
+			return
+		}
+		row := db.Row{
+			"MAC":            mac.String(),
+			"logical_switch": vn.lsUUID,
+			"locator":        d.locator(t, e.Vtep),
+		}
+		if e.ip != nil {
+			row["ipaddr"] = e.ip.String()
+		}
+		t.add(db.Insert("Ucast_Macs_Remote", row, ""))
+	})
+}
+
+// modRemoteVtep updates the flood list of vn. The entries learned while
+// the ovsdb-server isn't connected are written when it is.
+func (d *Dataplane) modRemoteVtep(vn *VirtualNetwork, vtep net.IP, withdraw bool) error {
+	addr := vtep.String()
+	if withdraw {
+		if _, ok := vn.remoteVteps[addr]; !ok {
+			return nil
+		}
+		delete(vn.remoteVteps, addr)
+	} else {
+		vn.remoteVteps[addr] = vtep
+	}
+	if vn.lsUUID == "" {
+		return nil
+	}
+	return d.writeMcast(vn)
+}
+
+func (d *Dataplane) modRemoteMac(vn *VirtualNetwork, e *remoteMac, withdraw bool) error {
+	key := vn.key(e.Mac, e.ip)
+	if withdraw {
+		if _, ok := vn.remoteFdb[key]; !ok {
+			return nil
+		}
+		delete(vn.remoteFdb, key)
+	} else {
+		vn.remoteFdb[key] = e
+	}
+	if vn.lsUUID == "" {
+		return nil
+	}
+	return d.writeRemoteMac(vn, e.Mac)
+}
+
+// writeRemote writes all the remote VTEPs and MACs of vn.
+func (d *Dataplane) writeRemote(vn *VirtualNetwork) error {
+	if err := d.writeMcast(vn); err != nil {
+		return err
+	}
+	written := make(map[string]bool)
+	for _, e := range vn.remoteFdb {
+		if written[e.Mac.String()] {
+			continue
+		}
+		written[e.Mac.String()] = true
+		if err := d.writeRemoteMac(vn, e.Mac); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeRemote deletes the remote rows of vn. The Logical_Switch is
+// left in place as the port bindings and the local MACs of the switch
+// refer to it.
+func (d *Dataplane) removeRemote(vn *VirtualNetwork) {
+	if vn.lsUUID == "" {
+		return
+	}
+	err := d.transact(func(t *txn) {
+		t.add(db.Delete("Ucast_Macs_Remote", db.Equal("logical_switch", vn.lsUUID)),
+			db.Delete("Mcast_Macs_Remote", db.Equal("logical_switch", vn.lsUUID)))
+	})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"Topic": "Dataplane",
+			"Key":   vn.config.Key(),
+		}).Warnf("failed to remove the remote MACs: %s", err)
+	}
+}
+
+// logicalSwitch returns the Logical_Switch of vn, creating it or
+// setting its tunnel_key.
+func (d *Dataplane) logicalSwitch(vn *VirtualNetwork) (db.UUID, error) {
+	name := vn.logicalSwitchName()
+	results, err := d.conn.Transact(d.database, db.Select("Logical_Switch", []string{"_uuid"}, db.Equal("name", name)))
+	if err != nil {
+		return "", err
+	}
+	row := db.Row{
+		"name":       name,
+		"tunnel_key": vn.config.VNI,
+	}
+	if len(results[0].Rows) > 0 {
+		u := results[0].Rows[0].UUID("_uuid")
+		_, err := d.conn.Transact(d.database, db.Update("Logical_Switch", row, db.Equal("_uuid", u)))
+		return u, err
+	}
+	results, err = d.conn.Transact(d.database, db.Insert("Logical_Switch", row, ""))
+	if err != nil {
+		return "", err
+	}
+	return results[0].UUID, nil
+}
+
+// modPath advertises or withdraws a MAC learned by the switch, like
+// modPath of the netlink dataplane does for the MACs of a bridge.
+func (d *Dataplane) modPath(uuid db.UUID, m *localMac, withdraw bool) error {
+	vn := d.vnByLogicalSwitch(m.ls)
+	if vn == nil {
+		return nil
+	}
+	e, ok := vn.localMacs[uuid]
+	if withdraw {
+		if !ok {
+			return nil
+		}
+		delete(vn.localMacs, uuid)
+	} else {
+		e = &m.MacEntry
+		vn.localMacs[uuid] = e
+	}
+	path, err := vn.macAdvPath(e, withdraw)
+	if err != nil {
+		return err
+	}
+	return d.addPath("", path)
+}
+
+func (d *Dataplane) vnByLogicalSwitch(u db.UUID) *VirtualNetwork {
+	if u == "" {
+		return nil
+	}
+	for _, vn := range d.vnMap {
+		if vn.lsUUID == u {
+			return vn
+		}
+	}
+	return nil
+}
+
+// handleUpdates applies the changes of the monitored tables.
+func (d *Dataplane) handleUpdates(updates db.TableUpdates) {
+	for u, r := range updates["Physical_Locator"] {
+		if r.New == nil {
+			if addr := r.Old.String("dst_ip"); d.locators[addr] == u {
+				delete(d.locators, addr)
+			}
+			continue
+		}
+		if r.New.String("encapsulation_type") == "vxlan_over_ipv4" {
+			d.locators[r.New.String("dst_ip")] = u
+		}
+	}
+	for u, r := range updates["Ucast_Macs_Local"] {
+		if old, ok := d.localMacs[u]; ok {
+			delete(d.localMacs, u)
+			if err := d.modPath(u, old, true); err != nil {
+				log.WithFields(log.Fields{
+					"Topic": "Dataplane",
+				}).Errorf("failed to withdraw %s: %s", old.Mac, err)
+			}
+		}
+		if r.New == nil {
+			continue
+		}
+		mac, err := net.ParseMAC(r.New.String("MAC"))
+		if err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Dataplane",
+			}).Warnf("invalid local MAC: %s", err)
+			continue
+		}
+		m := &localMac{
+			MacEntry: dataplane.MacEntry{
+				Mac: mac,
+				IP:  net.ParseIP(r.New.String("ipaddr")),
+			},
+			ls: r.New.UUID("logical_switch"),
+		}
+		d.localMacs[u] = m
+		if err := d.modPath(u, m, false); err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Dataplane",
+			}).Errorf("failed to advertise %s: %s", m.Mac, err)
+		}
+	}
+}
+
+// startVirtualNetwork maps vn to its Logical_Switch, writes the remote
+// entries and advertises the switch as the VTEP of vn.
+func (d *Dataplane) startVirtualNetwork(vn *VirtualNetwork) error {
+	if d.conn == nil || vn.lsUUID != "" {
+		return nil
+	}
+	u, err := d.logicalSwitch(vn)
+	if err != nil {
+		return fmt.Errorf("failed to set up logical switch: %s", err)
+	}
+	if err := d.modVrf(vn, false); err != nil {
+		return fmt.Errorf("failed to add vrf: %s", err)
+	}
+	vn.lsUUID = u
+	path, err := vn.multicastPath(net.ParseIP(d.routerId), false)
+	if err != nil {
+		return err
+	}
+	if err := d.addPath(vn.config.Key(), path); err != nil {
+		return fmt.Errorf("failed to advertise multicast route: %s", err)
+	}
+	if err := d.writeRemote(vn); err != nil {
+		return fmt.Errorf("failed to write remote MACs: %s", err)
+	}
+	for uuid, m := range d.localMacs {
+		if m.ls != u {
+			continue
+		}
+		if err := d.modPath(uuid, m, false); err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Dataplane",
+				"Key":   vn.config.Key(),
+			}).Warnf("failed to advertise %s: %s", m.Mac, err)
+		}
+	}
+	log.WithFields(log.Fields{
+		"Topic":         "Dataplane",
+		"Key":           vn.config.Key(),
+		"LogicalSwitch": vn.logicalSwitchName(),
+	}).Info("logical switch is up")
+	return nil
+}
+
+// stopVirtualNetwork withdraws the local paths of vn. Deleting the VRF
+// withdraws the Type-3 route.
+func (d *Dataplane) stopVirtualNetwork(vn *VirtualNetwork) {
+	if vn.lsUUID == "" {
+		return
+	}
+	for uuid, e := range vn.localMacs {
+		path, err := vn.macAdvPath(e, true)
+		if err == nil {
+			err = d.addPath("", path)
+		}
+		if err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Dataplane",
+				"Key":   vn.config.Key(),
+			}).Warnf("failed to withdraw %s: %s", e.Mac, err)
+		}
+		delete(vn.localMacs, uuid)
+	}
+	if err := d.modVrf(vn, true); err != nil {
+		log.WithFields(log.Fields{
+			"Topic": "Dataplane",
+			"Key":   vn.config.Key(),
+		}).Warnf("failed to delete vrf: %s", err)
+	}
+	vn.lsUUID = ""
+}
+
+// connect connects to the ovsdb-server, monitors the local MACs and
+// the locators, and sets up the virtual networks.
+func (d *Dataplane) connect() error {
+	o := &d.config.Dataplane.OVSDB
+	network, address, err := o.NetworkAddress()
+	if err != nil {
+		return err
+	}
+	c, err := db.Dial(network, address)
+	if err != nil {
+		return err
+	}
+	updates, err := c.Monitor(d.database, map[string]db.MonitorRequest{
+		"Physical_Locator": {Columns: []string{"encapsulation_type", "dst_ip"}},
+		"Ucast_Macs_Local": {Columns: []string{"MAC", "logical_switch", "ipaddr"}},
+	})
+	if err != nil {
+		c.Close()
+		return err
+	}
+	d.conn = c
+	log.WithFields(log.Fields{
+		"Topic": "Dataplane",
+	}).Infof("connected to ovsdb-server at %s:%s", network, address)
+	d.handleUpdates(updates)
+	for _, vn := range d.vnMap {
+		if err := d.startVirtualNetwork(vn); err != nil {
+			vn.lastError = err.Error()
+			log.WithFields(log.Fields{
+				"Topic": "Dataplane",
+				"Key":   vn.config.Key(),
+			}).Error(err)
+		}
+	}
+	return nil
+}
+
+// disconnect forgets the rows of a lost ovsdb-server. They are read
+// again on the next connection.
+func (d *Dataplane) disconnect() {
+	d.conn.Close()
+	d.conn = nil
+	for _, vn := range d.vnMap {
+		d.stopVirtualNetwork(vn)
+	}
+	d.locators = make(map[string]db.UUID)
+	d.localMacs = make(map[db.UUID]*localMac)
+}
+
+func (d *Dataplane) resync() {
+	log.WithFields(log.Fields{
+		"Topic": "Dataplane",
+	}).Info("reconnected to gobgpd. advertise the local paths again")
+	if err := d.addPath("", d.routerIdPath()); err != nil {
+		log.Error("failed to adv path: ", err)
+	}
+	for _, vn := range d.vnMap {
+		if vn.lsUUID == "" {
+			continue
+		}
+		if err := d.modVrf(vn, false); err != nil {
+			// gobgpd didn't restart if the VRF still exists
+			log.Debugf("failed to add vrf: %s", err)
+		}
+		path, err := vn.multicastPath(net.ParseIP(d.routerId), false)
+		if err == nil {
+			err = d.addPath(vn.config.Key(), path)
+		}
+		if err != nil {
+			log.Warnf("failed to advertise multicast route: %s", err)
+		}
+		for _, e := range vn.localMacs {
+			path, err := vn.macAdvPath(e, false)
+			if err == nil {
+				err = d.addPath("", path)
+			}
+			if err != nil {
+				log.Warnf("failed to advertise %s: %s", e.Mac, err)
+			}
+		}
+	}
+}
+
+func (d *Dataplane) Serve() error {
+	client, err := netlink.NewClient(d.grpcHost, &d.config.API.Client)
+	if err != nil {
+		return fmt.Errorf("failed to create gobgpd client: %s", err)
+	}
+	defer client.Close()
+	return d.serve(client)
+}
+
+func (d *Dataplane) serve(client *netlink.Client) error {
+	d.client = client
+	g := d.config.BGP.Global.Config
+	d.routerId, d.localAS = g.RouterId, g.As
+	if d.routerId == "" || d.localAS == 0 {
+		return fmt.Errorf("router-id and as of the BGP server are not configured")
+	}
+	if err := d.addPath("", d.routerIdPath()); err != nil {
+		log.Error("failed to adv path: ", err)
+	}
+
+	d.t.Go(func() error {
+		return d.client.WatchBest(&d.t, netlink.ToApiFamily(bgp.AFI_L2VPN, bgp.SAFI_EVPN), func() {
+			select {
+			case d.resyncCh <- struct{}{}:
+			case <-d.t.Dying():
+			}
+		}, func(p *api.Path) {
+			select {
+			case d.evpnCh <- p:
+			case <-d.t.Dying():
+			}
+		})
+	})
+
+	var updateCh <-chan db.TableUpdates
+	var doneCh <-chan struct{}
+	retryCh := time.After(0)
+	for {
+		select {
+		case <-d.t.Dying():
+			if d.conn != nil {
+				d.conn.Close()
+			}
+			return nil
+		case <-retryCh:
+			if err := d.connect(); err != nil {
+				log.WithFields(log.Fields{
+					"Topic": "Dataplane",
+				}).Warnf("failed to connect to ovsdb-server: %s. retry in %s", err, ovsdbRetryInterval)
+				retryCh = time.After(ovsdbRetryInterval)
+				continue
+			}
+			retryCh = nil
+			updateCh, doneCh = d.conn.Updates(), d.conn.Done()
+		case <-doneCh:
+			log.WithFields(log.Fields{
+				"Topic": "Dataplane",
+			}).Warnf("lost the connection to ovsdb-server. reconnect in %s", ovsdbRetryInterval)
+			d.disconnect()
+			updateCh, doneCh = nil, nil
+			retryCh = time.After(ovsdbRetryInterval)
+		case u := <-updateCh:
+			d.handleUpdates(u)
+		case <-d.resyncCh:
+			d.resync()
+		case p := <-d.evpnCh:
+			if err := d.modEvpn(p); err != nil {
+				log.Error("failed to mod evpn: ", err)
+			}
+		case op := <-d.mgmtCh:
+			op.errCh <- op.f()
+		}
+	}
+}
+
+func (d *Dataplane) mgmtOperation(f func() error) error {
+	op := &mgmtOp{
+		f:     f,
+		errCh: make(chan error, 1),
+	}
+	select {
+	case d.mgmtCh <- op:
+	case <-d.t.Dying():
+		return fmt.Errorf("dataplane is not running")
+	}
+	return <-op.errCh
+}
+
+func (d *Dataplane) addVirtualNetwork(c config.VirtualNetwork) error {
+	if _, ok := d.vnMap[c.Key()]; ok {
+		return fmt.Errorf("VirtualNetwork %s already exists", c.Key())
+	}
+	vn, err := NewVirtualNetwork(c, d.routerId, d.localAS)
+	if err != nil {
+		return err
+	}
+	d.vnMap[c.Key()] = vn
+	if err := d.startVirtualNetwork(vn); err != nil {
+		vn.lastError = err.Error()
+		return err
+	}
+	return nil
+}
+
+func (d *Dataplane) deleteVirtualNetwork(c config.VirtualNetwork) error {
+	vn, ok := d.vnMap[c.Key()]
+	if !ok {
+		return fmt.Errorf("VirtualNetwork %s doesn't exist", c.Key())
+	}
+	d.removeRemote(vn)
+	d.stopVirtualNetwork(vn)
+	delete(d.vnMap, c.Key())
+	return nil
+}
+
+func (d *Dataplane) updateVirtualNetwork(c config.VirtualNetwork) error {
+	vn, ok := d.vnMap[c.Key()]
+	if !ok {
+		log.Warnf("VirtualNetwork %s doesn't exist. add it", c.Key())
+		return d.addVirtualNetwork(c)
+	}
+	if !vn.config.NeedsRecreate(&c) {
+		vn.config = c
+		return nil
+	}
+	log.Infof("VirtualNetwork %s needs to be recreated", c.Key())
+	if err := d.deleteVirtualNetwork(vn.config); err != nil {
+		return err
+	}
+	return d.addVirtualNetwork(c)
+}
+
+func (d *Dataplane) AddVirtualNetwork(c config.VirtualNetwork) error {
+	return d.mgmtOperation(func() error {
+		return d.addVirtualNetwork(c)
+	})
+}
+
+func (d *Dataplane) DeleteVirtualNetwork(c config.VirtualNetwork) error {
+	return d.mgmtOperation(func() error {
+		return d.deleteVirtualNetwork(c)
+	})
+}
+
+func (d *Dataplane) UpdateVirtualNetwork(c config.VirtualNetwork) error {
+	return d.mgmtOperation(func() error {
+		return d.updateVirtualNetwork(c)
+	})
+}
+
+func (d *Dataplane) ListVirtualNetwork() ([]config.VirtualNetwork, error) {
+	var l []config.VirtualNetwork
+	err := d.mgmtOperation(func() error {
+		l = make([]config.VirtualNetwork, 0, len(d.vnMap))
+		for _, vn := range d.vnMap {
+			l = append(l, vn.config)
+		}
+		return nil
+	})
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Key() < l[j].Key()
+	})
+	return l, err
+}
+
+func (d *Dataplane) GetVirtualNetwork(name string) (*dataplane.VirtualNetworkState, error) {
+	var s *dataplane.VirtualNetworkState
+	err := d.mgmtOperation(func() error {
+		vn, ok := d.vnMap[name]
+		if !ok {
+			return fmt.Errorf("VirtualNetwork %s doesn't exist", name)
+		}
+		s = vn.state()
+		return nil
+	})
+	return s, err
+}
+
+// GetFibDiff isn't supported as the switch has no routes to compare.
+func (d *Dataplane) GetFibDiff() ([]dataplane.FibDiff, error) {
+	return nil, fmt.Errorf("FIB diff isn't supported by the ovsdb dataplane")
+}
+
+// ClearDuplicateMac isn't supported as the MACs are learned by the switch.
+func (d *Dataplane) ClearDuplicateMac(name string, mac net.HardwareAddr) error {
+	return fmt.Errorf("duplicate MAC detection isn't supported by the ovsdb dataplane")
+}
+
+func (d *Dataplane) GetStatistics() (*dataplane.Statistics, error) {
+	s := &dataplane.Statistics{}
+	err := d.mgmtOperation(func() error {
+		s.QueueDepths = map[string]int{
+			"modevpn": len(d.evpnCh),
+		}
+		s.VirtualNetworks = make([]dataplane.VirtualNetworkStatistics, 0, len(d.vnMap))
+		for _, vn := range d.vnMap {
+			s.VirtualNetworks = append(s.VirtualNetworks, *vn.statistics())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(s.VirtualNetworks, func(i, j int) bool {
+		return s.VirtualNetworks[i].Name < s.VirtualNetworks[j].Name
+	})
+	return s, nil
+}
+
+// Shutdown stops all the virtual networks. When flush is true, their
+// local paths are withdrawn and the remote rows are deleted. Otherwise
+// both are kept for a graceful restart.
+func (d *Dataplane) Shutdown(flush bool) error {
+	err := d.mgmtOperation(func() error {
+		for key, vn := range d.vnMap {
+			if flush {
+				d.removeRemote(vn)
+				d.stopVirtualNetwork(vn)
+			}
+			delete(d.vnMap, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	d.t.Kill(nil)
+	return nil
+}
+
+func init() {
+	dataplane.RegisterBackend("ovsdb", func(c *config.Config, o *dataplane.Options) (dataplane.Dataplaner, error) {
+		return NewDataplane(c, o), nil
+	})
+}
+
+// NewDataplane creates a dataplane programming the hardware_vtep
+// database of a switch. The remote rows are replaced on each
+// connection, so o.Restarting is ignored.
+func NewDataplane(c *config.Config, o *dataplane.Options) *Dataplane {
+	return &Dataplane{
+		config:    c,
+		grpcHost:  o.GrpcHost,
+		database:  c.Dataplane.OVSDB.DatabaseName(),
+		vnMap:     make(map[string]*VirtualNetwork),
+		locators:  make(map[string]db.UUID),
+		localMacs: make(map[db.UUID]*localMac),
+		evpnCh:    make(chan *api.Path, 16),
+		resyncCh:  make(chan struct{}),
+		mgmtCh:    make(chan *mgmtOp),
+	}
+}
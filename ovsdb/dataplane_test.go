@@ -0,0 +1,205 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ovsdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	"github.com/stretchr/testify/assert"
+	"github.com/ttsubo/goplane/config"
+	"github.com/ttsubo/goplane/dataplane"
+	db "github.com/ttsubo/goplane/internal/pkg/ovsdb"
+	"github.com/ttsubo/goplane/internal/pkg/testutil"
+	"github.com/ttsubo/goplane/netlink"
+	"google.golang.org/grpc"
+)
+
+type rpcMessage struct {
+	ID     interface{}       `json:"id"`
+	Method string            `json:"method,omitempty"`
+	Params []json.RawMessage `json:"params,omitempty"`
+	Result interface{}       `json:"result,omitempty"`
+}
+
+// fakeServer is an ovsdb-server accepting any transaction. The inserted
+// rows are given the UUIDs <table>-<n>, and the operations are sent to
+// opCh.
+type fakeServer struct {
+	mu    sync.Mutex
+	conn  net.Conn
+	enc   *json.Encoder
+	count map[string]int
+	opCh  chan db.Operation
+}
+
+func (s *fakeServer) send(m *rpcMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(m)
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	s.conn = conn
+	s.enc = json.NewEncoder(conn)
+	dec := json.NewDecoder(conn)
+	for {
+		m := &rpcMessage{}
+		if err := dec.Decode(m); err != nil {
+			return
+		}
+		var result interface{}
+		switch m.Method {
+		case "transact":
+			results := make([]map[string]interface{}, 0, len(m.Params))
+			for _, b := range m.Params[1:] {
+				var op db.Operation
+				json.Unmarshal(b, &op)
+				r := map[string]interface{}{}
+				switch op.Op {
+				case "insert":
+					r["uuid"] = []string{"uuid", fmt.Sprintf("%s-%d", op.Table, s.count[op.Table])}
+					s.count[op.Table]++
+				case "select":
+					r["rows"] = []interface{}{}
+				default:
+					r["count"] = 0
+				}
+				results = append(results, r)
+				s.opCh <- op
+			}
+			result = results
+		case "monitor":
+			result = map[string]interface{}{}
+		default:
+			continue
+		}
+		s.send(&rpcMessage{ID: m.ID, Result: result})
+	}
+}
+
+// expectOp reads the operations until one of op on table, and returns
+// it.
+func (s *fakeServer) expectOp(op, table string) (*db.Operation, error) {
+	for {
+		select {
+		case o := <-s.opCh:
+			if o.Op == op && o.Table == table {
+				return &o, nil
+			}
+		case <-time.After(5 * time.Second):
+			return nil, fmt.Errorf("no %s on %s", op, table)
+		}
+	}
+}
+
+func TestDataplane(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "goplane-ovsdb")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "db.sock")
+	l, err := net.Listen("unix", sock)
+	assert.Nil(err)
+	defer l.Close()
+	server := &fakeServer{
+		count: map[string]int{},
+		opCh:  make(chan db.Operation, 64),
+	}
+
+	c := &config.Config{}
+	c.BGP.Global.Config.RouterId = "10.0.0.1"
+	c.BGP.Global.Config.As = 65000
+	c.Dataplane.OVSDB.URL = fmt.Sprintf("unix:%s", sock)
+	gobgp := testutil.NewGobgpClient()
+	d := NewDataplane(c, &dataplane.Options{})
+	go d.serve(&netlink.Client{GobgpApiClient: gobgp})
+	defer d.Shutdown(false)
+
+	conn, err := l.Accept()
+	assert.Nil(err)
+	defer conn.Close()
+	go server.serve(conn)
+
+	// the virtual network is mapped to a new logical switch
+	vn := config.VirtualNetwork{
+		RD:  "65000:10",
+		VNI: 10,
+	}
+	assert.Nil(d.AddVirtualNetwork(vn))
+	op, err := server.expectOp("insert", "Logical_Switch")
+	assert.Nil(err)
+	assert.Equal("ls10", op.Row.String("name"))
+	assert.Equal(float64(10), op.Row["tunnel_key"])
+	s, err := d.GetVirtualNetwork("65000:10")
+	assert.Nil(err)
+	assert.Equal(dataplane.VN_STATUS_RUNNING, s.Status)
+	assert.True(gobgp.HasVrf("65000:10"))
+
+	// remote MACs are written with a new locator
+	gobgp.SendBest(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:01", "10.0.0.2", false))
+	op, err = server.expectOp("insert", "Physical_Locator")
+	assert.Nil(err)
+	assert.Equal("10.0.0.2", op.Row.String("dst_ip"))
+	op, err = server.expectOp("insert", "Ucast_Macs_Remote")
+	assert.Nil(err)
+	assert.Equal("aa:bb:cc:00:00:01", op.Row.String("MAC"))
+	assert.Equal(db.UUID("Logical_Switch-0"), op.Row.UUID("logical_switch"))
+
+	// the remote VTEPs refer to the same locator
+	gobgp.SendBest(testutil.NewEvpnPath(bgp.EVPN_INCLUSIVE_MULTICAST_ETHERNET_TAG, "", "10.0.0.2", false))
+	op, err = server.expectOp("insert", "Physical_Locator_Set")
+	assert.Nil(err)
+	assert.Equal([]interface{}{"set", []interface{}{[]interface{}{"uuid", "Physical_Locator-0"}}}, op.Row["locators"])
+	op, err = server.expectOp("insert", "Mcast_Macs_Remote")
+	assert.Nil(err)
+	assert.Equal(unknownDst, op.Row.String("MAC"))
+
+	// local MACs learned by the switch are advertised
+	assert.Nil(server.send(&rpcMessage{
+		Method: "update",
+		Params: []json.RawMessage{
+			json.RawMessage(`null`),
+			json.RawMessage(`{"Ucast_Macs_Local": {"local-0": {"new": {"MAC": "aa:bb:cc:00:00:02", "logical_switch": ["uuid", "Logical_Switch-0"], "ipaddr": ""}}}}`),
+		},
+	}))
+	assert.Eventually(func() bool {
+		return len(gobgp.EvpnPaths(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal("aa:bb:cc:00:00:02", gobgp.EvpnPaths(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT)[0].(*bgp.EVPNMacIPAdvertisementRoute).MacAddress.String())
+	s, err = d.GetVirtualNetwork("65000:10")
+	assert.Nil(err)
+	assert.Equal([]string{"10.0.0.2"}, s.RemoteVteps)
+	assert.Len(s.LocalMacs, 1)
+	assert.Len(s.RemoteFdb, 1)
+
+	// the virtual network waits for the ovsdb-server to come back
+	conn.Close()
+	assert.Eventually(func() bool {
+		s, err := d.GetVirtualNetwork("65000:10")
+		return err == nil && s.Status == dataplane.VN_STATUS_STARTING
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.False(gobgp.HasVrf("65000:10"))
+}
@@ -0,0 +1,449 @@
+// Copyright (C) 2016 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	log "github.com/sirupsen/logrus"
+	"github.com/ttsubo/goplane/internal/pkg/table"
+)
+
+const (
+	measurementUpdate   = "update"
+	measurementPeer     = "peer"
+	measurementTable    = "table"
+	measurementBestPath = "bestpath"
+)
+
+const (
+	collectorBatchSize     = 1000
+	collectorFlushInterval = time.Second
+	collectorRetry         = 3
+	collectorRetryInterval = time.Second
+)
+
+// point is a time-series point in the InfluxDB line protocol.
+type point struct {
+	measurement string
+	tags        map[string]string
+	fields      map[string]interface{}
+	time        time.Time
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	tagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+	fieldEscaper       = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+func (p *point) String() string {
+	var b strings.Builder
+	b.WriteString(measurementEscaper.Replace(p.measurement))
+
+	keys := make([]string, 0, len(p.tags))
+	for k, v := range p.tags {
+		// the line protocol doesn't allow empty tag values
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteByte(',')
+		b.WriteString(tagEscaper.Replace(k))
+		b.WriteByte('=')
+		b.WriteString(tagEscaper.Replace(p.tags[k]))
+	}
+
+	keys = keys[:0]
+	for k := range p.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		if i == 0 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteByte(',')
+		}
+		b.WriteString(tagEscaper.Replace(k))
+		b.WriteByte('=')
+		switch v := p.fields[k].(type) {
+		case string:
+			b.WriteString(`"` + fieldEscaper.Replace(v) + `"`)
+		case bool:
+			b.WriteString(strconv.FormatBool(v))
+		case int:
+			b.WriteString(strconv.FormatInt(int64(v), 10) + "i")
+		case int64:
+			b.WriteString(strconv.FormatInt(v, 10) + "i")
+		case uint32:
+			b.WriteString(strconv.FormatUint(uint64(v), 10) + "i")
+		case uint64:
+			b.WriteString(strconv.FormatUint(v, 10) + "i")
+		case float64:
+			b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			b.WriteString(`"` + fieldEscaper.Replace(fmt.Sprintf("%v", v)) + `"`)
+		}
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(p.time.UnixNano(), 10))
+	return b.String()
+}
+
+// influxWriter sends points to an InfluxDB HTTP endpoint in batches.
+// A batch is sent when it gets full or every flushInterval, and is
+// retried on connection errors and 5xx responses.
+type influxWriter struct {
+	url           string
+	dbName        string
+	client        *http.Client
+	pointCh       chan *point
+	closeCh       chan struct{}
+	doneCh        chan struct{}
+	batchSize     int
+	flushInterval time.Duration
+	retry         int
+	retryInterval time.Duration
+	dbCreated     bool
+}
+
+func newInfluxWriter(url, dbName string) *influxWriter {
+	return &influxWriter{
+		url:           strings.TrimRight(url, "/"),
+		dbName:        dbName,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		pointCh:       make(chan *point, collectorBatchSize),
+		closeCh:       make(chan struct{}),
+		doneCh:        make(chan struct{}),
+		batchSize:     collectorBatchSize,
+		flushInterval: collectorFlushInterval,
+		retry:         collectorRetry,
+		retryInterval: collectorRetryInterval,
+	}
+}
+
+func (w *influxWriter) write(points ...*point) {
+	for _, p := range points {
+		select {
+		case w.pointCh <- p:
+		case <-w.closeCh:
+			return
+		}
+	}
+}
+
+func (w *influxWriter) stop() {
+	close(w.closeCh)
+	<-w.doneCh
+}
+
+func (w *influxWriter) loop() {
+	defer close(w.doneCh)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*point, 0, w.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.flush(batch); err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Collector",
+				"Key":   w.url,
+				"Error": err,
+			}).Warnf("failed to write %d points. drop them", len(batch))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case p := <-w.pointCh:
+			batch = append(batch, p)
+			if len(batch) >= w.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-w.closeCh:
+			for {
+				select {
+				case p := <-w.pointCh:
+					batch = append(batch, p)
+					if len(batch) >= w.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *influxWriter) flush(batch []*point) error {
+	var buf bytes.Buffer
+	for _, p := range batch {
+		buf.WriteString(p.String())
+		buf.WriteByte('\n')
+	}
+
+	var err error
+	for i := 0; i <= w.retry; i++ {
+		if i > 0 {
+			time.Sleep(w.retryInterval << uint(i-1))
+		}
+		var retry bool
+		if !w.dbCreated {
+			if retry, err = w.createDatabase(); err != nil {
+				if !retry {
+					return err
+				}
+				continue
+			}
+			w.dbCreated = true
+		}
+		v := url.Values{}
+		v.Set("db", w.dbName)
+		v.Set("precision", "ns")
+		if retry, err = w.post(w.url+"/write?"+v.Encode(), "text/plain; charset=utf-8", bytes.NewReader(buf.Bytes())); err == nil || !retry {
+			return err
+		}
+		log.WithFields(log.Fields{
+			"Topic": "Collector",
+			"Key":   w.url,
+			"Error": err,
+		}).Debug("failed to write points. retry")
+	}
+	return err
+}
+
+func (w *influxWriter) createDatabase() (bool, error) {
+	v := url.Values{}
+	v.Set("q", fmt.Sprintf("CREATE DATABASE %q", w.dbName))
+	return w.post(w.url+"/query", "application/x-www-form-urlencoded", strings.NewReader(v.Encode()))
+}
+
+// post returns whether the request is worth retrying on error.
+func (w *influxWriter) post(u, contentType string, body io.Reader) (bool, error) {
+	rsp, err := w.client.Post(u, contentType, body)
+	if err != nil {
+		return true, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode/100 == 2 {
+		io.Copy(ioutil.Discard, rsp.Body)
+		return false, nil
+	}
+	msg, _ := ioutil.ReadAll(io.LimitReader(rsp.Body, 512))
+	return rsp.StatusCode >= 500, fmt.Errorf("%s: %s", rsp.Status, strings.TrimSpace(string(msg)))
+}
+
+// Collector writes peer state changes, received updates, best path
+// changes and periodic dumps of Adj-RIB-In to InfluxDB.
+type Collector struct {
+	s        *BgpServer
+	writer   *influxWriter
+	interval uint64
+	closeCh  chan struct{}
+	doneCh   chan struct{}
+}
+
+func path2data(path *table.Path) (map[string]interface{}, map[string]string) {
+	fields := map[string]interface{}{
+		"RouterID": path.GetSource().ID.String(),
+	}
+	if asPath := path.GetAsPath(); asPath != nil {
+		fields["ASPath"] = asPath.String()
+	}
+	if origin, err := path.GetOrigin(); err == nil {
+		typ := "-"
+		switch origin {
+		case bgp.BGP_ORIGIN_ATTR_TYPE_IGP:
+			typ = "i"
+		case bgp.BGP_ORIGIN_ATTR_TYPE_EGP:
+			typ = "e"
+		case bgp.BGP_ORIGIN_ATTR_TYPE_INCOMPLETE:
+			typ = "?"
+		}
+		fields["Origin"] = typ
+	}
+	if med, err := path.GetMed(); err == nil {
+		fields["Med"] = med
+	}
+
+	tags := map[string]string{
+		"PeerAddress": path.GetSource().Address.String(),
+		"PeerAS":      fmt.Sprintf("%v", path.GetSource().AS),
+		"Timestamp":   path.GetTimestamp().String(),
+	}
+	if nexthop := path.GetNexthop(); len(nexthop) > 0 {
+		fields["NextHop"] = nexthop.String()
+	}
+	if originAS := path.GetSourceAs(); originAS != 0 {
+		fields["OriginAS"] = fmt.Sprintf("%v", originAS)
+	}
+
+	if err := bgp.FlatUpdate(tags, path.GetNlri().Flat()); err != nil {
+		log.WithFields(log.Fields{
+			"Topic": "Collector",
+			"Error": err,
+		}).Error("NLRI FlatUpdate failed")
+	}
+	for _, p := range path.GetPathAttrs() {
+		if err := bgp.FlatUpdate(tags, p.Flat()); err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Collector",
+				"Error": err,
+			}).Error("PathAttr FlatUpdate failed")
+		}
+	}
+	return fields, tags
+}
+
+func pathsToPoints(measurement string, paths []*table.Path, withdraw bool, now time.Time) []*point {
+	points := make([]*point, 0, len(paths))
+	for _, path := range paths {
+		if path == nil {
+			continue
+		}
+		fields, tags := path2data(path)
+		if withdraw {
+			tags["Withdraw"] = strconv.FormatBool(path.IsWithdraw)
+		}
+		points = append(points, &point{
+			measurement: measurement,
+			tags:        tags,
+			fields:      fields,
+			time:        now,
+		})
+	}
+	return points
+}
+
+func (c *Collector) peerToPoint(msg *watchEventPeerState) *point {
+	t := msg.Timestamp
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return &point{
+		measurement: measurementPeer,
+		tags: map[string]string{
+			"PeerAddress": msg.PeerAddress.String(),
+			"PeerAS":      fmt.Sprintf("%v", msg.PeerAS),
+			"State":       msg.State.String(),
+		},
+		fields: map[string]interface{}{
+			"PeerID": msg.PeerID.String(),
+		},
+		time: t,
+	}
+}
+
+func (c *Collector) loop() {
+	defer close(c.doneCh)
+
+	w := c.s.Watch(watchPeerState(true), watchUpdate(false), WatchBestPath(false))
+	defer w.Stop()
+
+	var tickCh <-chan time.Time
+	if c.interval != 0 {
+		ticker := time.NewTicker(time.Second * time.Duration(c.interval))
+		defer ticker.Stop()
+		tickCh = ticker.C
+	}
+
+	for {
+		select {
+		case <-tickCh:
+			if err := w.Generate(watchEventTypePreUpdate); err != nil {
+				log.WithFields(log.Fields{
+					"Topic": "Collector",
+					"Error": err,
+				}).Warn("failed to dump Adj-RIB-In")
+			}
+		case ev := <-w.Event():
+			switch msg := ev.(type) {
+			case *WatchEventUpdate:
+				// End-of-RIB
+				if len(msg.PathList) == 0 {
+					continue
+				}
+				c.writer.write(pathsToPoints(measurementUpdate, msg.PathList, true, time.Now())...)
+			case *WatchEventBestPath:
+				c.writer.write(pathsToPoints(measurementBestPath, msg.PathList, true, time.Now())...)
+			case *watchEventPeerState:
+				c.writer.write(c.peerToPoint(msg))
+			case *watchEventAdjIn:
+				c.writer.write(pathsToPoints(measurementTable, msg.PathList, false, time.Now())...)
+			}
+		case <-c.closeCh:
+			return
+		}
+	}
+}
+
+// Stop stops watching the BGP server and flushes pending points.
+func (c *Collector) Stop() {
+	close(c.closeCh)
+	<-c.doneCh
+	c.writer.stop()
+}
+
+// NewCollector starts writing the events of s to the InfluxDB at url.
+// The Adj-RIB-In is dumped every interval seconds unless interval is
+// zero. The database, "gobgp" unless dbName is specified, is created
+// on the first write.
+func NewCollector(s *BgpServer, rawurl, dbName string, interval uint64) (*Collector, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported collector url: %s", rawurl)
+	}
+	if dbName == "" {
+		dbName = "gobgp"
+	}
+
+	c := &Collector{
+		s:        s,
+		writer:   newInfluxWriter(rawurl, dbName),
+		interval: interval,
+		closeCh:  make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	go c.writer.loop()
+	go c.loop()
+	return c, nil
+}
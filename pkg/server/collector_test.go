@@ -0,0 +1,147 @@
+// Copyright (C) 2016 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPointString(t *testing.T) {
+	assert := assert.New(t)
+
+	p := &point{
+		measurement: "update",
+		tags: map[string]string{
+			"PeerAddress": "10.0.0.1",
+			"Prefix":      "10.0.0.0/24",
+			"Community":   "65000:1 65000:2",
+			"Empty":       "",
+		},
+		fields: map[string]interface{}{
+			"ASPath": `65001 "x"`,
+			"Med":    uint32(10),
+		},
+		time: time.Unix(1, 5),
+	}
+	assert.Equal(`update,Community=65000:1\ 65000:2,PeerAddress=10.0.0.1,Prefix=10.0.0.0/24 ASPath="65001 \"x\"",Med=10i 1000000005`, p.String())
+}
+
+type fakeInfluxDB struct {
+	mu      sync.Mutex
+	queries []string
+	writes  []string
+	fail    int
+}
+
+func (f *fakeInfluxDB) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.URL.Path {
+	case "/query":
+		r.ParseForm()
+		f.queries = append(f.queries, r.Form.Get("q"))
+	case "/write":
+		if f.fail > 0 {
+			f.fail--
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Query().Get("db") != "gobgp" {
+			http.Error(w, "database not found", http.StatusNotFound)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		f.writes = append(f.writes, string(b))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeInfluxDB) get() ([]string, []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.queries...), append([]string{}, f.writes...)
+}
+
+func newTestInfluxWriter(url, dbName string) *influxWriter {
+	w := newInfluxWriter(url, dbName)
+	w.batchSize = 2
+	w.flushInterval = time.Hour
+	w.retryInterval = time.Millisecond
+	return w
+}
+
+func testPoint(prefix string) *point {
+	return &point{
+		measurement: "update",
+		tags:        map[string]string{"Prefix": prefix},
+		fields:      map[string]interface{}{"Med": uint32(0)},
+		time:        time.Unix(0, 0),
+	}
+}
+
+func TestInfluxWriterBatch(t *testing.T) {
+	assert := assert.New(t)
+
+	db := &fakeInfluxDB{}
+	s := httptest.NewServer(db)
+	defer s.Close()
+
+	w := newTestInfluxWriter(s.URL, "gobgp")
+	go w.loop()
+	w.write(testPoint("10.0.0.0/24"), testPoint("10.0.1.0/24"), testPoint("10.0.2.0/24"))
+	w.stop()
+
+	queries, writes := db.get()
+	assert.Equal([]string{`CREATE DATABASE "gobgp"`}, queries)
+	assert.Equal([]string{
+		"update,Prefix=10.0.0.0/24 Med=0i 0\nupdate,Prefix=10.0.1.0/24 Med=0i 0\n",
+		"update,Prefix=10.0.2.0/24 Med=0i 0\n",
+	}, writes)
+}
+
+func TestInfluxWriterRetry(t *testing.T) {
+	assert := assert.New(t)
+
+	db := &fakeInfluxDB{fail: 2}
+	s := httptest.NewServer(db)
+	defer s.Close()
+
+	w := newTestInfluxWriter(s.URL, "gobgp")
+	assert.Nil(w.flush([]*point{testPoint("10.0.0.0/24")}))
+	_, writes := db.get()
+	assert.Equal([]string{"update,Prefix=10.0.0.0/24 Med=0i 0\n"}, writes)
+
+	// 5xx responses are retried up to w.retry times
+	db.mu.Lock()
+	db.fail = w.retry + 1
+	db.mu.Unlock()
+	assert.NotNil(w.flush([]*point{testPoint("10.0.1.0/24")}))
+
+	// 4xx responses are not retried
+	w = newTestInfluxWriter(s.URL, "unknown")
+	assert.NotNil(w.flush([]*point{testPoint("10.0.2.0/24")}))
+	_, writes = db.get()
+	assert.Equal(1, len(writes))
+	assert.True(strings.HasPrefix(writes[0], "update,Prefix=10.0.0.0/24"))
+}
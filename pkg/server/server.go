@@ -99,8 +99,9 @@ func newTCPListener(address string, port uint32, ch chan *net.TCPConn) (*tcpList
 }
 
 type options struct {
-	grpcAddress string
-	grpcOption  []grpc.ServerOption
+	grpcAddress  string
+	grpcOption   []grpc.ServerOption
+	grpcRegister []func(*grpc.Server)
 }
 
 type ServerOption func(*options)
@@ -117,6 +118,14 @@ func GrpcOption(opt []grpc.ServerOption) ServerOption {
 	}
 }
 
+// GrpcRegister registers additional services on the gRPC server
+// before it starts serving.
+func GrpcRegister(f func(*grpc.Server)) ServerOption {
+	return func(o *options) {
+		o.grpcRegister = append(o.grpcRegister, f)
+	}
+}
+
 type BgpServer struct {
 	bgpConfig    config.Bgp
 	acceptCh     chan *net.TCPConn
@@ -158,7 +167,11 @@ func NewBgpServer(opt ...ServerOption) *BgpServer {
 	s.mrtManager = newMrtManager(s)
 	if len(opts.grpcAddress) != 0 {
 		grpc.EnableTracing = false
-		api := newAPIserver(s, grpc.NewServer(opts.grpcOption...), opts.grpcAddress)
+		g := grpc.NewServer(opts.grpcOption...)
+		for _, f := range opts.grpcRegister {
+			f(g)
+		}
+		api := newAPIserver(s, g, opts.grpcAddress)
 		go func() {
 			if err := api.serve(); err != nil {
 				log.Fatalf("failed to listen grpc port: %s", err)
@@ -0,0 +1,788 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zebra
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/context"
+
+	api "github.com/osrg/gobgp/api"
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	log "github.com/sirupsen/logrus"
+	"github.com/ttsubo/goplane/config"
+	"github.com/ttsubo/goplane/dataplane"
+	"github.com/ttsubo/goplane/internal/pkg/apiutil"
+	"github.com/ttsubo/goplane/internal/pkg/table"
+	zapi "github.com/ttsubo/goplane/internal/pkg/zebra"
+	"github.com/ttsubo/goplane/netlink"
+	"gopkg.in/tomb.v2"
+)
+
+// the connection to zebra is retried after zebraRetryInterval
+const zebraRetryInterval = 5 * time.Second
+
+type mgmtOp struct {
+	f     func() error
+	errCh chan error
+}
+
+// Dataplane hands the forwarding over to FRR's zebra. goplane takes the
+// place of bgpd as the EVPN client of zebra: zebra reports the VNIs and
+// the MACs of the VXLAN devices it manages, and goplane sends it the
+// remote VTEPs and MACs learned by BGP as well as the best IPv4 routes.
+type Dataplane struct {
+	t        tomb.Tomb
+	config   *config.Config
+	grpcHost string
+	client   *netlink.Client
+	// zclient is nil while zebra isn't connected
+	zclient  *zapi.Client
+	routerId string
+	localAS  uint32
+	vnMap    map[string]*VirtualNetwork
+	// vnis are the local VNIs reported by zebra
+	vnis map[uint32]*zapi.VniUpdateBody
+	// routes are the routes sent to zebra. They are sent again when
+	// zebra is reconnected.
+	routes    map[string]*zapi.IPRouteBody
+	ribCh     chan *api.Path
+	evpnCh    chan *api.Path
+	resyncCh  chan struct{}
+	mgmtCh    chan *mgmtOp
+	ribErrors uint64
+}
+
+// getMacMobility returns the flags and the sequence number of the MAC
+// mobility extended community (RFC 7432 section 7.7).
+func getMacMobility(attrs []bgp.PathAttributeInterface) (zapi.MacIPFlag, uint32) {
+	for _, attr := range attrs {
+		a, ok := attr.(*bgp.PathAttributeExtendedCommunities)
+		if !ok {
+			continue
+		}
+		for _, ec := range a.Value {
+			if m, ok := ec.(*bgp.MacMobilityExtended); ok {
+				var flags zapi.MacIPFlag
+				if m.IsSticky {
+					flags |= zapi.MacIPFlagSticky
+				}
+				return flags, m.Sequence
+			}
+		}
+	}
+	return 0, 0
+}
+
+func (d *Dataplane) routerIdPath() *table.Path {
+	return table.NewPath(nil, bgp.NewIPAddrPrefix(uint8(32), d.routerId), false, []bgp.PathAttributeInterface{
+		bgp.NewPathAttributeNextHop("0.0.0.0"),
+		bgp.NewPathAttributeOrigin(bgp.BGP_ORIGIN_ATTR_TYPE_IGP),
+	}, time.Now(), false)
+}
+
+func (d *Dataplane) addPath(vrfID string, path *table.Path) error {
+	resource := api.TableType_GLOBAL
+	if vrfID != "" {
+		resource = api.TableType_VRF
+	}
+	_, err := d.client.AddPath(context.Background(), &api.AddPathRequest{
+		TableType: resource,
+		VrfId:     vrfID,
+		Path:      netlink.ToPathApi(path, nil),
+	})
+	return err
+}
+
+func (d *Dataplane) modVrf(vn *VirtualNetwork, withdraw bool) error {
+	if withdraw {
+		_, err := d.client.DeleteVrf(context.Background(), &api.DeleteVrfRequest{
+			Name: vn.config.Key(),
+		})
+		return err
+	}
+	rd, err := bgp.ParseRouteDistinguisher(vn.rd)
+	if err != nil {
+		return err
+	}
+	_, err = d.client.AddVrf(context.Background(), &api.AddVrfRequest{
+		Vrf: &api.Vrf{
+			Name:     vn.config.Key(),
+			Rd:       apiutil.MarshalRD(rd),
+			ImportRt: apiutil.MarshalRTs(vn.importRts),
+			ExportRt: apiutil.MarshalRTs(vn.exportRts),
+		},
+	})
+	return err
+}
+
+// ipRouteBody returns the ZAPI route of a best IPv4 path. zebra applies
+// the default distances of BGP unless they are configured.
+func (d *Dataplane) ipRouteBody(p *api.Path) (*zapi.IPRouteBody, error) {
+	nlri, err := apiutil.GetNativeNlri(p)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := apiutil.GetNativePathAttributes(p)
+	if err != nil {
+		return nil, err
+	}
+	prefix, ok := nlri.(*bgp.IPAddrPrefix)
+	if !ok {
+		return nil, fmt.Errorf("unsupported nlri: %s", nlri)
+	}
+	body := &zapi.IPRouteBody{
+		Type:    zapi.RouteBGP,
+		Safi:    zapi.SafiUnicast,
+		Message: zapi.MessageNexthop,
+		Prefix: zapi.Prefix{
+			Family:    syscall.AF_INET,
+			Prefix:    prefix.Prefix.To4(),
+			PrefixLen: prefix.Length,
+		},
+		Nexthops: []zapi.Nexthop{{
+			Gate: netlink.GetNextHopFromPathAttributes(attrs),
+		}},
+	}
+	distance := d.config.BGP.Global.DefaultRouteDistance.Config
+	if p.SourceAsn == d.localAS {
+		body.Flags = zapi.FlagIBGP.ToEach(d.config.Dataplane.Zebra.ZapiVersion(), d.config.Dataplane.Zebra.SoftwareName)
+		body.Distance = distance.InternalRouteDistance
+	} else {
+		body.Distance = distance.ExternalRouteDistance
+	}
+	if body.Distance != 0 {
+		body.Message |= zapi.MessageDistance
+	}
+	return body, nil
+}
+
+// modRib sends a best IPv4 path to zebra. The local paths are skipped.
+func (d *Dataplane) modRib(p *api.Path) error {
+	body, err := d.ipRouteBody(p)
+	if err != nil {
+		return err
+	}
+	if gate := body.Nexthops[0].Gate; gate == nil || gate.IsUnspecified() {
+		return nil
+	}
+	key := fmt.Sprintf("%s/%d", body.Prefix.Prefix, body.Prefix.PrefixLen)
+	if p.IsWithdraw {
+		old, ok := d.routes[key]
+		if !ok {
+			return nil
+		}
+		delete(d.routes, key)
+		body = old
+	} else {
+		d.routes[key] = body
+	}
+	if d.zclient == nil {
+		return nil
+	}
+	return d.zclient.SendIPRoute(zapi.DefaultVrf, body, p.IsWithdraw)
+}
+
+// modEvpn updates the remote VTEPs and MACs of the virtual networks
+// importing a best EVPN path.
+func (d *Dataplane) modEvpn(p *api.Path) error {
+	nlri, err := apiutil.GetNativeNlri(p)
+	if err != nil {
+		return err
+	}
+	attrs, err := apiutil.GetNativePathAttributes(p)
+	if err != nil {
+		return err
+	}
+	nexthop := netlink.GetNextHopFromPathAttributes(attrs)
+	if nexthop == nil || nexthop.IsUnspecified() {
+		return nil
+	}
+	switch r := nlri.(*bgp.EVPNNLRI).RouteTypeData.(type) {
+	case *bgp.EVPNMulticastEthernetTagRoute:
+		for _, vn := range d.vnMap {
+			if !vn.isImported(r.ETag, attrs) {
+				continue
+			}
+			if err := d.modRemoteVtep(vn, nexthop, p.IsWithdraw); err != nil {
+				return err
+			}
+		}
+	case *bgp.EVPNMacIPAdvertisementRoute:
+		flags, seq := getMacMobility(attrs)
+		var ip net.IP
+		if r.IPAddressLength > 0 {
+			ip = r.IPAddress
+		}
+		for _, vn := range d.vnMap {
+			if !vn.isImported(r.ETag, attrs) {
+				continue
+			}
+			e := &remoteMac{
+				FdbEntry: dataplane.FdbEntry{
+					Mac:  r.MacAddress,
+					Vtep: nexthop,
+				},
+				ip:    ip,
+				flags: flags,
+				seq:   seq,
+			}
+			if err := d.modRemoteMac(vn, e, p.IsWithdraw); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// modRemoteVtep updates the flood list of vn. The entries learned
+// before zebra reports the VNI are sent when it does.
+func (d *Dataplane) modRemoteVtep(vn *VirtualNetwork, vtep net.IP, withdraw bool) error {
+	addr := vtep.String()
+	if withdraw {
+		if _, ok := vn.remoteVteps[addr]; !ok {
+			return nil
+		}
+		delete(vn.remoteVteps, addr)
+	} else {
+		vn.remoteVteps[addr] = vtep
+	}
+	if d.zclient == nil || vn.vtepIP == nil {
+		return nil
+	}
+	return d.zclient.SendRemoteVTEP(vn.config.VNI, vtep, zapi.VxlanFloodHeadEndRepl, withdraw)
+}
+
+func (d *Dataplane) modRemoteMac(vn *VirtualNetwork, e *remoteMac, withdraw bool) error {
+	key := vn.key(e.Mac, e.ip)
+	if withdraw {
+		old, ok := vn.remoteFdb[key]
+		if !ok {
+			return nil
+		}
+		delete(vn.remoteFdb, key)
+		e = old
+	} else {
+		vn.remoteFdb[key] = e
+	}
+	if d.zclient == nil || vn.vtepIP == nil {
+		return nil
+	}
+	return d.zclient.SendRemoteMACIP(vn.config.VNI, e.Mac, e.ip, e.Vtep, e.flags, e.seq, withdraw)
+}
+
+// sendRemote sends the remote VTEPs and MACs of vn to zebra, or removes
+// them from zebra when withdraw is true.
+func (d *Dataplane) sendRemote(vn *VirtualNetwork, withdraw bool) {
+	if d.zclient == nil || vn.vtepIP == nil {
+		return
+	}
+	for _, vtep := range vn.remoteVteps {
+		if err := d.zclient.SendRemoteVTEP(vn.config.VNI, vtep, zapi.VxlanFloodHeadEndRepl, withdraw); err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Dataplane",
+				"Key":   vn.config.Key(),
+			}).Warnf("failed to send remote VTEP %s: %s", vtep, err)
+		}
+	}
+	for _, e := range vn.remoteFdb {
+		if err := d.zclient.SendRemoteMACIP(vn.config.VNI, e.Mac, e.ip, e.Vtep, e.flags, e.seq, withdraw); err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Dataplane",
+				"Key":   vn.config.Key(),
+			}).Warnf("failed to send remote MAC %s: %s", e.Mac, err)
+		}
+	}
+}
+
+// modLocalMac advertises or withdraws a MAC learned by zebra on the
+// bridge of a VNI.
+func (d *Dataplane) modLocalMac(b *zapi.MacIPUpdateBody) error {
+	vn := d.vnByVNI(b.Vni)
+	if vn == nil || vn.vtepIP == nil {
+		return nil
+	}
+	withdraw := b.API == zapi.MacIPDel
+	key := vn.key(b.Mac, b.IP)
+	e, ok := vn.localMacs[key]
+	if withdraw {
+		if !ok {
+			return nil
+		}
+		delete(vn.localMacs, key)
+	} else {
+		e = &dataplane.MacEntry{
+			Mac: b.Mac,
+			IP:  b.IP,
+		}
+		vn.localMacs[key] = e
+	}
+	path, err := vn.macAdvPath(e, withdraw)
+	if err != nil {
+		return err
+	}
+	return d.addPath("", path)
+}
+
+func (d *Dataplane) vnByVNI(vni uint32) *VirtualNetwork {
+	for _, vn := range d.vnMap {
+		if vn.config.VNI == vni {
+			return vn
+		}
+	}
+	return nil
+}
+
+// startVirtualNetwork advertises vn once zebra reported its VNI with
+// the address of the local VTEP.
+func (d *Dataplane) startVirtualNetwork(vn *VirtualNetwork, vtepIP net.IP) error {
+	if vn.vtepIP != nil {
+		if vn.vtepIP.Equal(vtepIP) {
+			return nil
+		}
+		d.stopVirtualNetwork(vn)
+	}
+	if err := d.modVrf(vn, false); err != nil {
+		return fmt.Errorf("failed to add vrf: %s", err)
+	}
+	vn.vtepIP = vtepIP
+	path, err := vn.multicastPath(false)
+	if err != nil {
+		return err
+	}
+	if err := d.addPath(vn.config.Key(), path); err != nil {
+		return fmt.Errorf("failed to advertise multicast route: %s", err)
+	}
+	d.sendRemote(vn, false)
+	log.WithFields(log.Fields{
+		"Topic": "Dataplane",
+		"Key":   vn.config.Key(),
+		"Vtep":  vtepIP,
+	}).Info("VNI is up")
+	return nil
+}
+
+// stopVirtualNetwork withdraws the local paths of vn. Deleting the VRF
+// withdraws the Type-3 route.
+func (d *Dataplane) stopVirtualNetwork(vn *VirtualNetwork) {
+	if vn.vtepIP == nil {
+		return
+	}
+	for key, e := range vn.localMacs {
+		path, err := vn.macAdvPath(e, true)
+		if err == nil {
+			err = d.addPath("", path)
+		}
+		if err != nil {
+			log.WithFields(log.Fields{
+				"Topic": "Dataplane",
+				"Key":   vn.config.Key(),
+			}).Warnf("failed to withdraw %s: %s", e.Mac, err)
+		}
+		delete(vn.localMacs, key)
+	}
+	if err := d.modVrf(vn, true); err != nil {
+		log.WithFields(log.Fields{
+			"Topic": "Dataplane",
+			"Key":   vn.config.Key(),
+		}).Warnf("failed to delete vrf: %s", err)
+	}
+	vn.vtepIP = nil
+}
+
+func (d *Dataplane) handleZebraMessage(m *zapi.Message) {
+	var err error
+	switch body := m.Body.(type) {
+	case *zapi.VniUpdateBody:
+		if body.API == zapi.VniDel {
+			delete(d.vnis, body.Vni)
+			if vn := d.vnByVNI(body.Vni); vn != nil {
+				d.stopVirtualNetwork(vn)
+			}
+			return
+		}
+		d.vnis[body.Vni] = body
+		if vn := d.vnByVNI(body.Vni); vn != nil {
+			if err = d.startVirtualNetwork(vn, body.VtepIP); err != nil {
+				vn.lastError = err.Error()
+			}
+		}
+	case *zapi.MacIPUpdateBody:
+		err = d.modLocalMac(body)
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"Topic": "Dataplane",
+		}).Errorf("failed to handle %s from zebra: %s", m.Header.Command, err)
+	}
+}
+
+// connect connects to zebra and asks it for the local VNIs and MACs.
+// The routes and the remote entries are sent again.
+func (d *Dataplane) connect() error {
+	z := &d.config.Dataplane.Zebra
+	network, address, err := z.NetworkAddress()
+	if err != nil {
+		return err
+	}
+	c, err := zapi.NewClient(network, address, zapi.RouteBGP, z.ZapiVersion(), z.SoftwareName, 0)
+	if err != nil {
+		return err
+	}
+	if !c.SupportEvpn() {
+		c.Close()
+		return fmt.Errorf("EVPN isn't supported with ZAPI version %d and software %q", c.Version, c.SoftwareName)
+	}
+	if err := c.SendAdvertiseAllVNI(true, zapi.VxlanFloodHeadEndRepl); err != nil {
+		c.Close()
+		return err
+	}
+	d.zclient = c
+	log.WithFields(log.Fields{
+		"Topic": "Dataplane",
+	}).Infof("connected to zebra at %s:%s", network, address)
+	for _, body := range d.routes {
+		d.zclient.SendIPRoute(zapi.DefaultVrf, body, false)
+	}
+	return nil
+}
+
+// disconnect forgets the VNIs of a lost zebra. zebra reports them again
+// on the next connection.
+func (d *Dataplane) disconnect() {
+	d.zclient.Close()
+	d.zclient = nil
+	for _, vn := range d.vnMap {
+		d.stopVirtualNetwork(vn)
+	}
+	d.vnis = make(map[uint32]*zapi.VniUpdateBody)
+}
+
+func (d *Dataplane) resync() {
+	log.WithFields(log.Fields{
+		"Topic": "Dataplane",
+	}).Info("reconnected to gobgpd. advertise the local paths again")
+	if err := d.addPath("", d.routerIdPath()); err != nil {
+		log.Error("failed to adv path: ", err)
+	}
+	for _, vn := range d.vnMap {
+		if vn.vtepIP == nil {
+			continue
+		}
+		if err := d.modVrf(vn, false); err != nil {
+			// gobgpd didn't restart if the VRF still exists
+			log.Debugf("failed to add vrf: %s", err)
+		}
+		path, err := vn.multicastPath(false)
+		if err == nil {
+			err = d.addPath(vn.config.Key(), path)
+		}
+		if err != nil {
+			log.Warnf("failed to advertise multicast route: %s", err)
+		}
+		for _, e := range vn.localMacs {
+			path, err := vn.macAdvPath(e, false)
+			if err == nil {
+				err = d.addPath("", path)
+			}
+			if err != nil {
+				log.Warnf("failed to advertise %s: %s", e.Mac, err)
+			}
+		}
+	}
+}
+
+// watchBest passes the best paths of family to ch.
+func (d *Dataplane) watchBest(family *api.Family, ch chan *api.Path) error {
+	return d.client.WatchBest(&d.t, family, func() {
+		select {
+		case d.resyncCh <- struct{}{}:
+		case <-d.t.Dying():
+		}
+	}, func(p *api.Path) {
+		select {
+		case ch <- p:
+		case <-d.t.Dying():
+		}
+	})
+}
+
+func (d *Dataplane) Serve() error {
+	client, err := netlink.NewClient(d.grpcHost, &d.config.API.Client)
+	if err != nil {
+		return fmt.Errorf("failed to create gobgpd client: %s", err)
+	}
+	defer client.Close()
+	return d.serve(client)
+}
+
+func (d *Dataplane) serve(client *netlink.Client) error {
+	d.client = client
+	g := d.config.BGP.Global.Config
+	d.routerId, d.localAS = g.RouterId, g.As
+	if d.routerId == "" || d.localAS == 0 {
+		return fmt.Errorf("router-id and as of the BGP server are not configured")
+	}
+	if err := d.addPath("", d.routerIdPath()); err != nil {
+		log.Error("failed to adv path: ", err)
+	}
+
+	d.t.Go(func() error {
+		return d.watchBest(netlink.ToApiFamily(bgp.AFI_IP, bgp.SAFI_UNICAST), d.ribCh)
+	})
+	d.t.Go(func() error {
+		return d.watchBest(netlink.ToApiFamily(bgp.AFI_L2VPN, bgp.SAFI_EVPN), d.evpnCh)
+	})
+
+	var msgCh chan *zapi.Message
+	retryCh := time.After(0)
+	for {
+		select {
+		case <-d.t.Dying():
+			if d.zclient != nil {
+				d.zclient.Close()
+			}
+			return nil
+		case <-retryCh:
+			if err := d.connect(); err != nil {
+				log.WithFields(log.Fields{
+					"Topic": "Dataplane",
+				}).Warnf("failed to connect to zebra: %s. retry in %s", err, zebraRetryInterval)
+				retryCh = time.After(zebraRetryInterval)
+				continue
+			}
+			retryCh = nil
+			msgCh = d.zclient.Receive()
+		case m, ok := <-msgCh:
+			if !ok {
+				log.WithFields(log.Fields{
+					"Topic": "Dataplane",
+				}).Warnf("lost the connection to zebra. reconnect in %s", zebraRetryInterval)
+				d.disconnect()
+				msgCh = nil
+				retryCh = time.After(zebraRetryInterval)
+				continue
+			}
+			d.handleZebraMessage(m)
+		case <-d.resyncCh:
+			d.resync()
+		case p := <-d.ribCh:
+			if err := d.modRib(p); err != nil {
+				d.ribErrors++
+				log.Error("failed to mod rib: ", err)
+			}
+		case p := <-d.evpnCh:
+			if err := d.modEvpn(p); err != nil {
+				log.Error("failed to mod evpn: ", err)
+			}
+		case op := <-d.mgmtCh:
+			op.errCh <- op.f()
+		}
+	}
+}
+
+func (d *Dataplane) mgmtOperation(f func() error) error {
+	op := &mgmtOp{
+		f:     f,
+		errCh: make(chan error, 1),
+	}
+	select {
+	case d.mgmtCh <- op:
+	case <-d.t.Dying():
+		return fmt.Errorf("dataplane is not running")
+	}
+	return <-op.errCh
+}
+
+func (d *Dataplane) addVirtualNetwork(c config.VirtualNetwork) error {
+	if _, ok := d.vnMap[c.Key()]; ok {
+		return fmt.Errorf("VirtualNetwork %s already exists", c.Key())
+	}
+	vn, err := NewVirtualNetwork(c, d.routerId, d.localAS)
+	if err != nil {
+		return err
+	}
+	d.vnMap[c.Key()] = vn
+	if body, ok := d.vnis[c.VNI]; ok {
+		if err := d.startVirtualNetwork(vn, body.VtepIP); err != nil {
+			vn.lastError = err.Error()
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dataplane) deleteVirtualNetwork(c config.VirtualNetwork) error {
+	vn, ok := d.vnMap[c.Key()]
+	if !ok {
+		return fmt.Errorf("VirtualNetwork %s doesn't exist", c.Key())
+	}
+	d.sendRemote(vn, true)
+	d.stopVirtualNetwork(vn)
+	delete(d.vnMap, c.Key())
+	return nil
+}
+
+func (d *Dataplane) updateVirtualNetwork(c config.VirtualNetwork) error {
+	vn, ok := d.vnMap[c.Key()]
+	if !ok {
+		log.Warnf("VirtualNetwork %s doesn't exist. add it", c.Key())
+		return d.addVirtualNetwork(c)
+	}
+	if !vn.config.NeedsRecreate(&c) {
+		vn.config = c
+		return nil
+	}
+	log.Infof("VirtualNetwork %s needs to be recreated", c.Key())
+	if err := d.deleteVirtualNetwork(vn.config); err != nil {
+		return err
+	}
+	return d.addVirtualNetwork(c)
+}
+
+func (d *Dataplane) AddVirtualNetwork(c config.VirtualNetwork) error {
+	return d.mgmtOperation(func() error {
+		return d.addVirtualNetwork(c)
+	})
+}
+
+func (d *Dataplane) DeleteVirtualNetwork(c config.VirtualNetwork) error {
+	return d.mgmtOperation(func() error {
+		return d.deleteVirtualNetwork(c)
+	})
+}
+
+func (d *Dataplane) UpdateVirtualNetwork(c config.VirtualNetwork) error {
+	return d.mgmtOperation(func() error {
+		return d.updateVirtualNetwork(c)
+	})
+}
+
+func (d *Dataplane) ListVirtualNetwork() ([]config.VirtualNetwork, error) {
+	var l []config.VirtualNetwork
+	err := d.mgmtOperation(func() error {
+		l = make([]config.VirtualNetwork, 0, len(d.vnMap))
+		for _, vn := range d.vnMap {
+			l = append(l, vn.config)
+		}
+		return nil
+	})
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Key() < l[j].Key()
+	})
+	return l, err
+}
+
+func (d *Dataplane) GetVirtualNetwork(name string) (*dataplane.VirtualNetworkState, error) {
+	var s *dataplane.VirtualNetworkState
+	err := d.mgmtOperation(func() error {
+		vn, ok := d.vnMap[name]
+		if !ok {
+			return fmt.Errorf("VirtualNetwork %s doesn't exist", name)
+		}
+		s = vn.state()
+		return nil
+	})
+	return s, err
+}
+
+// GetFibDiff isn't supported as the FIB is owned by zebra.
+func (d *Dataplane) GetFibDiff() ([]dataplane.FibDiff, error) {
+	return nil, fmt.Errorf("FIB diff isn't supported by the zebra dataplane")
+}
+
+// ClearDuplicateMac isn't supported as the MACs are learned by zebra.
+func (d *Dataplane) ClearDuplicateMac(name string, mac net.HardwareAddr) error {
+	return fmt.Errorf("duplicate MAC detection isn't supported by the zebra dataplane")
+}
+
+func (d *Dataplane) GetStatistics() (*dataplane.Statistics, error) {
+	s := &dataplane.Statistics{}
+	err := d.mgmtOperation(func() error {
+		s.RoutesInstalled = len(d.routes)
+		s.RibErrors = d.ribErrors
+		s.QueueDepths = map[string]int{
+			"modrib":  len(d.ribCh),
+			"modevpn": len(d.evpnCh),
+		}
+		s.VirtualNetworks = make([]dataplane.VirtualNetworkStatistics, 0, len(d.vnMap))
+		for _, vn := range d.vnMap {
+			s.VirtualNetworks = append(s.VirtualNetworks, *vn.statistics())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(s.VirtualNetworks, func(i, j int) bool {
+		return s.VirtualNetworks[i].Name < s.VirtualNetworks[j].Name
+	})
+	return s, nil
+}
+
+// Shutdown stops all the virtual networks. When flush is true, their
+// local paths are withdrawn and the routes and the remote entries sent
+// to zebra are removed. Otherwise they are kept for a graceful restart.
+func (d *Dataplane) Shutdown(flush bool) error {
+	err := d.mgmtOperation(func() error {
+		for key, vn := range d.vnMap {
+			if flush {
+				d.sendRemote(vn, true)
+				d.stopVirtualNetwork(vn)
+			}
+			delete(d.vnMap, key)
+		}
+		if flush && d.zclient != nil {
+			for key, body := range d.routes {
+				d.zclient.SendIPRoute(zapi.DefaultVrf, body, true)
+				delete(d.routes, key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	d.t.Kill(nil)
+	return nil
+}
+
+func init() {
+	dataplane.RegisterBackend("zebra", func(c *config.Config, o *dataplane.Options) (dataplane.Dataplaner, error) {
+		return NewDataplane(c, o), nil
+	})
+}
+
+// NewDataplane creates a dataplane programming zebra. The stale entries
+// of a graceful restart are handled by zebra, so o.Restarting is
+// ignored.
+func NewDataplane(c *config.Config, o *dataplane.Options) *Dataplane {
+	return &Dataplane{
+		config:   c,
+		grpcHost: o.GrpcHost,
+		vnMap:    make(map[string]*VirtualNetwork),
+		vnis:     make(map[uint32]*zapi.VniUpdateBody),
+		routes:   make(map[string]*zapi.IPRouteBody),
+		ribCh:    make(chan *api.Path, 16),
+		evpnCh:   make(chan *api.Path, 16),
+		resyncCh: make(chan struct{}),
+		mgmtCh:   make(chan *mgmtOp),
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zebra
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	"github.com/stretchr/testify/assert"
+	"github.com/ttsubo/goplane/config"
+	"github.com/ttsubo/goplane/dataplane"
+	"github.com/ttsubo/goplane/internal/pkg/table"
+	"github.com/ttsubo/goplane/internal/pkg/testutil"
+	zapi "github.com/ttsubo/goplane/internal/pkg/zebra"
+	"github.com/ttsubo/goplane/netlink"
+	"google.golang.org/grpc"
+)
+
+// commands of FRR 7.3 (ZAPI version 6) not exported by the zebra package
+const (
+	zapiAdvertiseAllVNI = 60
+	zapiRemoteVTEPAdd   = 67
+	zapiRemoteMACIPAdd  = 73
+)
+
+func writeMessage(conn net.Conn, command zapi.APIType, body []byte) error {
+	hdr := make([]byte, 10)
+	binary.BigEndian.PutUint16(hdr[0:2], uint16(len(hdr)+len(body)))
+	hdr[2] = zapi.HeaderMarker(6)
+	hdr[3] = 6
+	binary.BigEndian.PutUint16(hdr[8:10], uint16(command))
+	_, err := conn.Write(append(hdr, body...))
+	return err
+}
+
+// expectMessage reads the messages from the dataplane until one of
+// command, and returns its body.
+func expectMessage(conn net.Conn, command zapi.APIType) ([]byte, error) {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		hdr := make([]byte, 10)
+		if _, err := io.ReadFull(conn, hdr); err != nil {
+			return nil, err
+		}
+		body := make([]byte, binary.BigEndian.Uint16(hdr[0:2])-uint16(len(hdr)))
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return nil, err
+		}
+		if zapi.APIType(binary.BigEndian.Uint16(hdr[8:10])) == command {
+			return body, nil
+		}
+	}
+}
+
+func vniAddBody(vni uint32, vtep string) []byte {
+	b := make([]byte, 4, 16)
+	binary.BigEndian.PutUint32(b, vni)
+	b = append(b, net.ParseIP(vtep).To4()...)
+	return append(b, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+func macIPAddBody(vni uint32, mac string) []byte {
+	hw, _ := net.ParseMAC(mac)
+	b := make([]byte, 4, 19)
+	binary.BigEndian.PutUint32(b, vni)
+	b = append(b, hw...)
+	// no IP address, then the flags and the sequence number
+	return append(b, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+func TestDataplane(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "goplane-zebra")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "zserv.api")
+	l, err := net.Listen("unix", sock)
+	assert.Nil(err)
+	defer l.Close()
+
+	c := &config.Config{}
+	c.BGP.Global.Config.RouterId = "10.0.0.1"
+	c.BGP.Global.Config.As = 65000
+	c.Dataplane.Zebra.URL = fmt.Sprintf("unix:%s", sock)
+	gobgp := testutil.NewGobgpClient()
+	d := NewDataplane(c, &dataplane.Options{})
+	go d.serve(&netlink.Client{GobgpApiClient: gobgp})
+	defer d.Shutdown(false)
+
+	// the client waits for a first message from zebra
+	conn, err := l.Accept()
+	assert.Nil(err)
+	defer conn.Close()
+	assert.Nil(writeMessage(conn, zapi.VniAdd.ToEach(6, ""), vniAddBody(10, "10.0.0.1")))
+	body, err := expectMessage(conn, zapiAdvertiseAllVNI)
+	assert.Nil(err)
+	assert.Equal([]byte{1, byte(zapi.VxlanFloodHeadEndRepl)}, body)
+
+	// the virtual network starts with the VNI known to zebra
+	vn := config.VirtualNetwork{
+		RD:            "65000:10",
+		VNI:           10,
+		VtepInterface: "vxlan10",
+	}
+	assert.Nil(d.AddVirtualNetwork(vn))
+	s, err := d.GetVirtualNetwork("65000:10")
+	assert.Nil(err)
+	assert.Equal(dataplane.VN_STATUS_RUNNING, s.Status)
+	assert.True(gobgp.HasVrf("65000:10"))
+	assert.Len(gobgp.EvpnPaths(bgp.EVPN_INCLUSIVE_MULTICAST_ETHERNET_TAG), 1)
+
+	// remote VTEPs and MACs are sent to zebra
+	gobgp.SendBest(testutil.NewEvpnPath(bgp.EVPN_INCLUSIVE_MULTICAST_ETHERNET_TAG, "", "10.0.0.2", false))
+	body, err = expectMessage(conn, zapiRemoteVTEPAdd)
+	assert.Nil(err)
+	assert.Equal([]byte{0, 0, 0, 10, 10, 0, 0, 2}, body[:8])
+
+	gobgp.SendBest(testutil.NewEvpnPath(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT, "aa:bb:cc:00:00:01", "10.0.0.2", false))
+	body, err = expectMessage(conn, zapiRemoteMACIPAdd)
+	assert.Nil(err)
+	assert.Equal([]byte{0, 0, 0, 10, 0xaa, 0xbb, 0xcc, 0, 0, 1, 0, 0, 0, 0, 10, 0, 0, 2}, body[:18])
+
+	// best IPv4 routes are sent to zebra
+	route := netlink.ToPathApi(table.NewPath(nil, bgp.NewIPAddrPrefix(24, "10.1.0.0"), false, []bgp.PathAttributeInterface{
+		bgp.NewPathAttributeNextHop("10.0.0.2"),
+	}, time.Now(), false), nil)
+	gobgp.SendBest(route)
+	_, err = expectMessage(conn, zapi.RouteAdd.ToEach(6, ""))
+	assert.Nil(err)
+
+	// local MACs learned by zebra are advertised
+	assert.Nil(writeMessage(conn, zapi.MacIPAdd.ToEach(6, ""), macIPAddBody(10, "aa:bb:cc:00:00:02")))
+	assert.Eventually(func() bool {
+		return len(gobgp.EvpnPaths(bgp.EVPN_ROUTE_TYPE_MAC_IP_ADVERTISEMENT)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	s, err = d.GetVirtualNetwork("65000:10")
+	assert.Nil(err)
+	assert.Equal([]string{"10.0.0.2"}, s.RemoteVteps)
+	assert.Len(s.LocalMacs, 1)
+	assert.Len(s.RemoteFdb, 1)
+
+	// the virtual network waits for zebra to come back
+	conn.Close()
+	assert.Eventually(func() bool {
+		s, err := d.GetVirtualNetwork("65000:10")
+		return err == nil && s.Status == dataplane.VN_STATUS_STARTING
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.False(gobgp.HasVrf("65000:10"))
+}
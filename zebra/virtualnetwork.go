@@ -0,0 +1,130 @@
+// Copyright (C) 2015 Nippon Telegraph and Telephone Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zebra
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/osrg/gobgp/pkg/packet/bgp"
+	"github.com/ttsubo/goplane/config"
+	"github.com/ttsubo/goplane/dataplane"
+	"github.com/ttsubo/goplane/internal/pkg/table"
+	zapi "github.com/ttsubo/goplane/internal/pkg/zebra"
+	"github.com/ttsubo/goplane/netlink"
+)
+
+// VirtualNetwork is the EVPN state of a VNI whose VXLAN device and
+// bridge are managed by zebra. It is only accessed from the dataplane
+// loop.
+type VirtualNetwork struct {
+	config    config.VirtualNetwork
+	rd        string
+	importRts []bgp.ExtendedCommunityInterface
+	exportRts []bgp.ExtendedCommunityInterface
+	// vtepIP is the local VTEP address reported by zebra. It is nil
+	// until zebra sends VNI_ADD for the VNI.
+	vtepIP      net.IP
+	remoteVteps map[string]net.IP
+	localMacs   map[string]*dataplane.MacEntry
+	remoteFdb   map[string]*remoteMac
+	lastError   string
+}
+
+// remoteMac is a MAC learned from a Type-2 route and sent to zebra.
+type remoteMac struct {
+	dataplane.FdbEntry
+	ip    net.IP
+	flags zapi.MacIPFlag
+	seq   uint32
+}
+
+func (n *VirtualNetwork) key(mac net.HardwareAddr, ip net.IP) string {
+	if ip == nil {
+		return mac.String()
+	}
+	return fmt.Sprintf("%s/%s", mac, ip)
+}
+
+func (n *VirtualNetwork) state() *dataplane.VirtualNetworkState {
+	s := &dataplane.VirtualNetworkState{
+		Config:      n.config,
+		RD:          n.rd,
+		Vtep:        n.config.VtepInterface,
+		RemoteVteps: make([]string, 0, len(n.remoteVteps)),
+		LocalMacs:   make([]dataplane.MacEntry, 0, len(n.localMacs)),
+		RemoteFdb:   make([]dataplane.FdbEntry, 0, len(n.remoteFdb)),
+		Status:      dataplane.VN_STATUS_STARTING,
+		LastError:   n.lastError,
+	}
+	if n.vtepIP != nil {
+		s.Status = dataplane.VN_STATUS_RUNNING
+	}
+	for addr := range n.remoteVteps {
+		s.RemoteVteps = append(s.RemoteVteps, addr)
+	}
+	sort.Strings(s.RemoteVteps)
+	for _, e := range n.localMacs {
+		s.LocalMacs = append(s.LocalMacs, *e)
+	}
+	for _, e := range n.remoteFdb {
+		s.RemoteFdb = append(s.RemoteFdb, e.FdbEntry)
+	}
+	return s
+}
+
+func (n *VirtualNetwork) statistics() *dataplane.VirtualNetworkStatistics {
+	return &dataplane.VirtualNetworkStatistics{
+		Name:        n.config.Key(),
+		VNI:         n.config.VNI,
+		FdbEntries:  len(n.remoteFdb),
+		RemoteVteps: len(n.remoteVteps),
+	}
+}
+
+// isImported reports whether a received EVPN path belongs to the
+// virtual network and uses its encapsulation.
+func (n *VirtualNetwork) isImported(etag uint32, attrs []bgp.PathAttributeInterface) bool {
+	return etag == n.config.Etag && netlink.IsImported(&n.config, n.importRts, attrs) && netlink.EncapMatches(&n.config, attrs)
+}
+
+// multicastPath returns the Type-3 route of the local VTEP.
+func (n *VirtualNetwork) multicastPath(withdraw bool) (*table.Path, error) {
+	return netlink.MulticastPath(&n.config, n.rd, n.vtepIP, withdraw)
+}
+
+// macAdvPath returns the Type-2 route of a local MAC.
+func (n *VirtualNetwork) macAdvPath(e *dataplane.MacEntry, withdraw bool) (*table.Path, error) {
+	return netlink.MacAdvPath(&n.config, n.rd, n.exportRts, e, withdraw)
+}
+
+func NewVirtualNetwork(c config.VirtualNetwork, routerId string, localAS uint32) (*VirtualNetwork, error) {
+	rd := netlink.RouteDistinguisher(&c, routerId)
+	im, ex, err := netlink.RouteTargets(&c, rd, localAS)
+	if err != nil {
+		return nil, err
+	}
+	return &VirtualNetwork{
+		config:      c,
+		rd:          rd,
+		importRts:   im,
+		exportRts:   ex,
+		remoteVteps: make(map[string]net.IP),
+		localMacs:   make(map[string]*dataplane.MacEntry),
+		remoteFdb:   make(map[string]*remoteMac),
+	}, nil
+}